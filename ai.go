@@ -0,0 +1,168 @@
+package main
+
+import (
+	"math"
+
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+// AIState is what an enemy is doing right now
+type AIState int
+
+const (
+	AIIdle   AIState = iota // Standing around with nobody in sight
+	AIWander                // Strolling to a spot nearby, drifting towards the noise of the fight
+	AIChase                 // Closing in on the player, around obstacles
+	AIAttack                // Lunging at the player
+	AIFlee                  // Running away for a moment after getting badly hurt
+)
+
+// aiStateNames label the states on the debug overlay
+var aiStateNames = map[AIState]string{
+	AIIdle:   "idle",
+	AIWander: "wander",
+	AIChase:  "chase",
+	AIAttack: "attack",
+	AIFlee:   "flee",
+}
+
+func (s AIState) String() string {
+	return aiStateNames[s]
+}
+
+// Enemy AI tuning
+const (
+	aggroRadius          = 220.0 // Idle and wandering enemies notice players this close
+	leashRadius          = 700.0 // Chasing enemies lose track of players further away
+	attackRange          = 40.0  // Chasing enemies lunge at players this close
+	lungeTime            = 0.35
+	lungeSpeedMultiplier = 2.2
+	attackCooldown       = 1.2  // Time between lunges
+	fleeHealthShare      = 0.34 // Enemies flee once when their health drops this low
+	fleeTime             = 1.5
+	idleTime             = 1.0
+	wanderDistance       = 60.0
+	wanderTime           = 3.0 // Wandering enemies give up on a spot they cannot reach by then
+	debugPathSteps       = 40
+)
+
+// setState switches to another state and starts its timer
+func (e *Enemy) setState(state AIState, timer float32) {
+	e.State = state
+	e.StateTimer = timer
+}
+
+// think moves the enemy between states, based on how far away the player is and how hurt it is
+func (e *Enemy) think(deltaTime float32) {
+	e.StateTimer -= deltaTime
+	if e.AttackCooldown > 0 {
+		e.AttackCooldown -= deltaTime
+	}
+	distance := r.Vector2Distance(e.center(), e.Player.center())
+
+	// Regular enemies run once they are nearly beaten, elites fight to the end
+	if e.Elite == nil && !e.HasFled && e.CurrentHealth > 0 &&
+		float32(e.CurrentHealth) <= float32(e.MaxHealth)*fleeHealthShare {
+		e.HasFled = true
+		e.setState(AIFlee, fleeTime)
+		return
+	}
+
+	switch e.State {
+	case AIIdle, AIWander:
+		if distance < aggroRadius {
+			e.setState(AIChase, 0)
+		} else if e.State == AIIdle && e.StateTimer <= 0 {
+			e.startWander()
+		} else if e.State == AIWander && (e.StateTimer <= 0 || r.Vector2Distance(e.center(), e.WanderTarget) < 4) {
+			e.setState(AIIdle, idleTime)
+		}
+
+	case AIChase:
+		if distance > leashRadius {
+			e.startWander()
+		} else if e.Ranged == nil && distance < attackRange && e.AttackCooldown <= 0 {
+			e.setState(AIAttack, lungeTime)
+			e.LungeDirection = r.Vector2Normalize(r.Vector2Subtract(e.Player.center(), e.center()))
+			e.AttackCooldown = attackCooldown
+		}
+
+	case AIAttack, AIFlee:
+		if e.StateTimer <= 0 {
+			e.setState(AIChase, 0)
+		}
+	}
+}
+
+// startWander picks a spot nearby, turned towards the player so lost enemies find their way back
+func (e *Enemy) startWander() {
+	toPlayer := math.Atan2(float64(e.Player.Y-e.Y), float64(e.Player.X-e.X))
+	angle := toPlayer + (e.Rand.Float64()-0.5)*math.Pi
+	e.WanderTarget = r.Vector2Add(e.center(), r.Vector2{
+		X: float32(math.Cos(angle)) * wanderDistance,
+		Y: float32(math.Sin(angle)) * wanderDistance,
+	})
+	e.setState(AIWander, wanderTime)
+}
+
+// act moves the enemy the way its state asks for
+func (e *Enemy) act(deltaTime float32) {
+	switch e.State {
+	case AIWander:
+		e.walk(e.blend(e.pathTo(e.WanderTarget, false)), e.Speed/2, deltaTime)
+	case AIChase:
+		// Shooters keep their distance instead of chasing
+		if e.Ranged != nil {
+			e.updateRanged(deltaTime)
+			return
+		}
+		distance := r.Vector2Distance(e.center(), e.Player.center())
+		e.walk(e.blend(e.pathTo(e.Player.center(), true)), e.arrivalSpeed(distance), deltaTime)
+	case AIAttack:
+		e.walk(e.LungeDirection, e.Speed*lungeSpeedMultiplier, deltaTime)
+	case AIFlee:
+		e.walk(e.blend(r.Vector2Normalize(r.Vector2Subtract(e.center(), e.Player.center()))), e.Speed, deltaTime)
+	}
+}
+
+// pathTo returns the way to walk towards a point. Chasers follow the flow field
+// around obstacles, ghosts and everything else go straight.
+func (e *Enemy) pathTo(target r.Vector2, chasing bool) r.Vector2 {
+	if chasing && e.Nav != nil && e.Ghost == nil {
+		if direction, ok := e.Nav.Direction(e.center()); ok {
+			return direction
+		}
+	}
+	return r.Vector2Normalize(r.Vector2Subtract(target, e.center()))
+}
+
+// walk moves the enemy along a direction, slower while chilled, and turns it to face where it goes
+func (e *Enemy) walk(direction r.Vector2, speed, deltaTime float32) {
+	e.Direction = Vector2{X: direction.X, Y: direction.Y}
+	if direction.X != 0 {
+		e.FacingLeft = direction.X < 0
+	}
+	speed = e.slowed(speed)
+	e.X += direction.X * speed * deltaTime
+	e.Y += direction.Y * speed * deltaTime
+}
+
+// center returns the middle of the enemy
+func (e *Enemy) center() r.Vector2 {
+	return r.Vector2{X: e.X + float32(e.Width)/2, Y: e.Y + float32(e.Height)/2}
+}
+
+// drawAI shows the enemy's state and the path it is following, for the debug overlay
+func (e *Enemy) drawAI() {
+	color := r.SkyBlue
+	if e.State == AIChase && e.Nav != nil && e.Ghost == nil && e.Ranged == nil {
+		path := e.Nav.Path(e.center(), debugPathSteps)
+		for i := 1; i < len(path); i++ {
+			r.DrawLineV(path[i-1], path[i], r.ColorAlpha(r.Yellow, 0.6))
+		}
+	}
+	if e.State == AIWander {
+		r.DrawLineV(e.center(), e.WanderTarget, r.ColorAlpha(color, 0.6))
+	}
+	r.DrawText(e.State.String(), int32(e.X), int32(e.Y+float32(e.Height)+2), 10, color)
+}
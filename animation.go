@@ -0,0 +1,495 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+// AnimationsFile holds the sprite sheets and clips of every animated entity
+const AnimationsFile = "assets/animations.json"
+
+// animations is the sprite sheet registry shared by every animator
+var animations = NewAnimationRegistry()
+
+// AnimMode is how a clip carries on after its last frame
+type AnimMode string
+
+const (
+	AnimLoop     AnimMode = "loop"      // Starts over from the first frame
+	AnimOnce     AnimMode = "once"      // Holds the last frame, or moves on to the clip's next clip
+	AnimPingPong AnimMode = "ping_pong" // Plays backwards to the first frame, then forwards again
+)
+
+// ClipDef is one named animation of a sprite sheet
+type ClipDef struct {
+	Frames    []int     `json:"frames"`              // Cells of the sheet in play order, counted left to right, top to bottom
+	Duration  float32   `json:"duration"`            // Seconds every frame is shown
+	Durations []float32 `json:"durations,omitempty"` // Seconds per frame, in place of Duration
+	Mode      AnimMode  `json:"mode"`
+	Next      string    `json:"next,omitempty"` // Clip a once clip hands over to when it ends
+}
+
+// FrameDuration returns how long the frame at position i of the clip is shown
+func (clip *ClipDef) FrameDuration(i int) float32 {
+	if len(clip.Durations) > 0 {
+		return clip.Durations[i]
+	}
+	return clip.Duration
+}
+
+// Length returns how long one pass through the clip takes
+func (clip *ClipDef) Length() float32 {
+	var length float32
+	for i := range clip.Frames {
+		length += clip.FrameDuration(i)
+	}
+	return length
+}
+
+// SheetDef is a texture cut into equal cells and the clips played from them
+type SheetDef struct {
+	ID          string              `json:"id"`
+	Texture     string              `json:"texture"`
+	FrameWidth  int32               `json:"frame_width,omitempty"`  // 0 takes the width of the texture
+	FrameHeight int32               `json:"frame_height,omitempty"` // 0 takes the height of the texture
+	TrimX       int32               `json:"trim_x,omitempty"`       // Pixels cut off both sides of every cell, hiding bleed from its neighbours
+	FacesLeft   bool                `json:"faces_left,omitempty"`   // The art looks left, so it is flipped for owners facing right
+	Default     string              `json:"default"`                // Clip an animator starts with
+	Clips       map[string]*ClipDef `json:"clips"`
+}
+
+// AnimationRegistry looks sprite sheets up by ID
+type AnimationRegistry struct {
+	sheets map[string]*SheetDef
+}
+
+// NewAnimationRegistry creates an empty registry
+func NewAnimationRegistry() *AnimationRegistry {
+	return &AnimationRegistry{sheets: make(map[string]*SheetDef)}
+}
+
+// LoadAnimations reads and validates an animation file. Aseprite files next to it
+// that no sheet uses become sheets of their own, named after the file. Broken ones
+// are skipped with a warning, so one bad file dropped in does not break every sheet.
+func LoadAnimations(path string) (*AnimationRegistry, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var sheets []*SheetDef
+	if err := json.Unmarshal(raw, &sheets); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	reg := NewAnimationRegistry()
+	for _, sheet := range sheets {
+		if sheet.ID == "" {
+			return nil, fmt.Errorf("%s: sheet %q has no id", path, sheet.Texture)
+		}
+		if _, exists := reg.sheets[sheet.ID]; exists {
+			return nil, fmt.Errorf("%s: sheet %q is defined twice", path, sheet.ID)
+		}
+		if isAseprite(sheet.Texture) {
+			if err := importAseprite(sheet); err != nil {
+				return nil, fmt.Errorf("%s: sheet %q: %w", path, sheet.ID, err)
+			}
+		}
+		if err := validateSheet(sheet); err != nil {
+			return nil, fmt.Errorf("%s: sheet %q: %w", path, sheet.ID, err)
+		}
+		reg.sheets[sheet.ID] = sheet
+	}
+	for _, sheet := range discoverAseprites(filepath.Dir(path), sheets) {
+		reg.sheets[sheet.ID] = sheet
+	}
+	return reg, nil
+}
+
+// discoverAseprites makes a sheet for every Aseprite file in dir the given sheets do not use,
+// unless a sheet already has the file's name. Files that do not make a playable sheet are left out.
+func discoverAseprites(dir string, sheets []*SheetDef) []*SheetDef {
+	taken := make(map[string]bool)
+	for _, sheet := range sheets {
+		taken[sheet.ID] = true
+		taken[filepath.Clean(sheet.Texture)] = true
+	}
+
+	var files []string
+	for _, pattern := range []string{"*.aseprite", "*.ase"} {
+		found, _ := filepath.Glob(filepath.Join(dir, pattern))
+		files = append(files, found...)
+	}
+
+	var discovered []*SheetDef
+	for _, file := range files {
+		id := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		if taken[id] || taken[file] {
+			continue
+		}
+		sheet := &SheetDef{ID: id, Texture: file}
+		if err := importAseprite(sheet); err != nil {
+			fmt.Println("Warning: Skipping animation", file+":", err)
+			continue
+		}
+		if err := validateSheet(sheet); err != nil {
+			fmt.Println("Warning: Skipping animation", file+":", err)
+			continue
+		}
+		taken[id] = true
+		discovered = append(discovered, sheet)
+	}
+	return discovered
+}
+
+// importAseprite fills a sheet in from its Aseprite file. The frame size always comes from the
+// file, clips come from its tags unless the sheet defines them, and the first tag is the default.
+func importAseprite(sheet *SheetDef) error {
+	sprite, err := LoadAseprite(sheet.Texture)
+	if err != nil {
+		return err
+	}
+
+	sheet.FrameWidth = int32(sprite.Width)
+	sheet.FrameHeight = int32(sprite.Height)
+	if sheet.Clips == nil {
+		sheet.Clips = make(map[string]*ClipDef)
+	}
+	for name, clip := range sprite.Clips() {
+		if _, exists := sheet.Clips[name]; !exists {
+			sheet.Clips[name] = clip
+		}
+	}
+	if sheet.Default == "" {
+		sheet.Default = sprite.DefaultClip()
+	}
+	return nil
+}
+
+// validateSheet checks a sheet has a texture and every clip can be played
+func validateSheet(sheet *SheetDef) error {
+	if sheet.Texture == "" {
+		return fmt.Errorf("has no texture")
+	}
+	if sheet.FrameWidth < 0 || sheet.FrameHeight < 0 {
+		return fmt.Errorf("frame size must not be negative")
+	}
+	if sheet.TrimX < 0 || (sheet.FrameWidth > 0 && 2*sheet.TrimX >= sheet.FrameWidth) {
+		return fmt.Errorf("trim leaves nothing of the frame")
+	}
+	if len(sheet.Clips) == 0 {
+		return fmt.Errorf("has no clips")
+	}
+	if _, exists := sheet.Clips[sheet.Default]; !exists {
+		return fmt.Errorf("default clip %q is not defined", sheet.Default)
+	}
+
+	for name, clip := range sheet.Clips {
+		if len(clip.Frames) == 0 {
+			return fmt.Errorf("clip %q has no frames", name)
+		}
+		for _, frame := range clip.Frames {
+			if frame < 0 {
+				return fmt.Errorf("clip %q has a negative frame", name)
+			}
+		}
+		if len(clip.Durations) > 0 {
+			if len(clip.Durations) != len(clip.Frames) {
+				return fmt.Errorf("clip %q has %d durations for %d frames", name, len(clip.Durations), len(clip.Frames))
+			}
+			for _, duration := range clip.Durations {
+				if duration <= 0 {
+					return fmt.Errorf("clip %q has a frame duration that is not positive", name)
+				}
+			}
+		} else if clip.Duration <= 0 {
+			return fmt.Errorf("clip %q needs a positive duration", name)
+		}
+
+		switch clip.Mode {
+		case "":
+			clip.Mode = AnimLoop
+		case AnimLoop, AnimOnce, AnimPingPong:
+		default:
+			return fmt.Errorf("clip %q has unknown mode %q", name, clip.Mode)
+		}
+		if clip.Next != "" {
+			if clip.Mode != AnimOnce {
+				return fmt.Errorf("clip %q only ends when played once, so it cannot move on", name)
+			}
+			if _, exists := sheet.Clips[clip.Next]; !exists {
+				return fmt.Errorf("clip %q moves on to unknown clip %q", name, clip.Next)
+			}
+		}
+	}
+	return nil
+}
+
+// Lookup returns the definition of a sheet, if it exists
+func (reg *AnimationRegistry) Lookup(id string) (*SheetDef, bool) {
+	sheet, exists := reg.sheets[id]
+	return sheet, exists
+}
+
+// Get returns the definition of a sheet. Unknown sheets get a stand-in
+// showing the fallback texture, so a broken file never stops the game.
+func (reg *AnimationRegistry) Get(id string) *SheetDef {
+	if sheet, exists := reg.sheets[id]; exists {
+		return sheet
+	}
+	return &SheetDef{
+		ID:      id,
+		Default: "idle",
+		Clips:   map[string]*ClipDef{"idle": {Frames: []int{0}, Duration: 1, Mode: AnimLoop}},
+	}
+}
+
+// loadAnimations replaces the registry with the animation file, keeping the old one if it is broken
+func loadAnimations(path string) {
+	loaded, err := LoadAnimations(path)
+	if err != nil {
+		fmt.Println("Warning: Could not load animations:", err)
+		return
+	}
+	animations = loaded
+}
+
+// Animator plays the clips of one sprite sheet for one owner.
+// Owners pick clips by name, so what a clip looks like lives in the animation file.
+type Animator struct {
+	Sheet      *SheetDef
+	Texture    r.Texture2D
+	Clip       string
+	Frame      int     // Position in the clip's frames
+	Timer      float32 // Time the current frame has been shown
+	Done       bool    // Set once a clip played once has ended
+	FacingLeft bool    // Which way the owner looks, the art is flipped to match
+	// OnComplete is called with the clip's name every time a clip finishes a pass,
+	// before a once clip hands over to its next clip
+	OnComplete func(clip string)
+	backwards  bool // Ping-pong clips on their way back
+}
+
+// NewAnimator takes a reference to a sheet's texture and starts its default clip
+func NewAnimator(sheetID string) *Animator {
+	sheet := animations.Get(sheetID)
+	a := &Animator{
+		Sheet:   sheet,
+		Texture: loadTexture(sheet.Texture),
+	}
+	a.Restart(sheet.Default)
+	return a
+}
+
+// Play switches to a clip unless it is already playing. Unknown clips are ignored.
+func (a *Animator) Play(clip string) {
+	if clip == a.Clip && !a.Done {
+		return
+	}
+	a.Restart(clip)
+}
+
+// Restart plays a clip from its first frame, even if it is already playing
+func (a *Animator) Restart(clip string) {
+	if _, exists := a.Sheet.Clips[clip]; !exists {
+		return
+	}
+	a.Clip = clip
+	a.Frame = 0
+	a.Timer = 0
+	a.Done = false
+	a.backwards = false
+}
+
+// Busy reports whether a clip played once is still running, owners let it end
+// before they go back to the clip for what they are doing
+func (a *Animator) Busy() bool {
+	return a.clip().Mode == AnimOnce && !a.Done
+}
+
+// clip returns the definition of the clip playing
+func (a *Animator) clip() *ClipDef {
+	return a.Sheet.Clips[a.Clip]
+}
+
+// Update advances the clip, stepping over as many frames as deltaTime covers
+func (a *Animator) Update(deltaTime float32) {
+	if a.Done {
+		return
+	}
+	a.Timer += deltaTime
+	for !a.Done {
+		clip := a.clip()
+		duration := clip.FrameDuration(a.Frame)
+		if a.Timer < duration {
+			return
+		}
+		a.Timer -= duration
+		a.advance(clip)
+	}
+}
+
+// advance moves to the next frame of a clip, handling its end the way its mode asks
+func (a *Animator) advance(clip *ClipDef) {
+	last := len(clip.Frames) - 1
+	switch clip.Mode {
+	case AnimLoop:
+		if a.Frame < last {
+			a.Frame++
+			return
+		}
+		a.Frame = 0
+		a.complete()
+	case AnimPingPong:
+		if last == 0 {
+			a.complete()
+			return
+		}
+		if a.backwards {
+			a.Frame--
+			if a.Frame == 0 {
+				a.backwards = false
+				a.complete()
+			}
+			return
+		}
+		a.Frame++
+		if a.Frame == last {
+			a.backwards = true
+		}
+	case AnimOnce:
+		if a.Frame < last {
+			a.Frame++
+			return
+		}
+		a.Done = true
+		a.Timer = 0
+		name := a.Clip
+		a.complete()
+		// The callback may have started another clip already
+		if a.Done && a.Clip == name && clip.Next != "" {
+			a.Restart(clip.Next)
+		}
+	}
+}
+
+// complete tells the owner a pass through the clip ended
+func (a *Animator) complete() {
+	if a.OnComplete != nil {
+		a.OnComplete(a.Clip)
+	}
+}
+
+// FrameSize returns the size of one cell of the sheet
+func (a *Animator) FrameSize() (float32, float32) {
+	width, height := a.Sheet.FrameWidth, a.Sheet.FrameHeight
+	if width == 0 {
+		width = a.Texture.Width
+	}
+	if height == 0 {
+		height = a.Texture.Height
+	}
+	return float32(width), float32(height)
+}
+
+// Source returns the part of the texture to draw for the current frame, flipped to the owner's facing
+func (a *Animator) Source() r.Rectangle {
+	return a.SourceFacing(a.FacingLeft)
+}
+
+// SourceFacing returns the current frame as Source does, for an owner looking the given way
+func (a *Animator) SourceFacing(facingLeft bool) r.Rectangle {
+	width, height := a.FrameSize()
+	columns := int32(1)
+	if width > 0 && int32(width) <= a.Texture.Width {
+		columns = a.Texture.Width / int32(width)
+	}
+	cell := int32(a.clip().Frames[a.Frame])
+	trim := float32(a.Sheet.TrimX)
+
+	source := r.Rectangle{
+		X:      float32(cell%columns)*width + trim,
+		Y:      float32(cell/columns) * height,
+		Width:  width - 2*trim,
+		Height: height,
+	}
+	if facingLeft != a.Sheet.FacesLeft {
+		source.Width = -source.Width
+	}
+	return source
+}
+
+// Draw draws the current frame into dest, turned around origin by rotation degrees
+func (a *Animator) Draw(dest r.Rectangle, origin r.Vector2, rotation float32, tint r.Color) {
+	r.DrawTexturePro(a.Texture, a.Source(), dest, origin, rotation, tint)
+}
+
+// DrawAt draws the current frame at its own size times scale, with its top left corner at position
+func (a *Animator) DrawAt(position r.Vector2, scale float32, tint r.Color) {
+	width, height := a.FrameSize()
+	a.Draw(r.Rectangle{X: position.X, Y: position.Y, Width: width * scale, Height: height * scale}, r.Vector2{}, 0, tint)
+}
+
+// Unload drops the animator's reference to its texture
+func (a *Animator) Unload() {
+	unloadTexture(a.Texture)
+}
+
+// AnimEffect plays one clip where something happened, like an enemy dying,
+// and is taken out of the world by the game once the clip ended
+type AnimEffect struct {
+	X      float32
+	Y      float32
+	Scale  float32
+	Tint   r.Color
+	Anim   *Animator
+	Timer  float32 // Time since the effect started, fades it out over the clip
+	IsDone bool
+}
+
+// NewAnimEffect starts a clip of a sheet at a position, looking the given way
+func NewAnimEffect(sheetID, clip string, x, y, scale float32, facingLeft bool, tint r.Color) *AnimEffect {
+	effect := &AnimEffect{
+		X:     x,
+		Y:     y,
+		Scale: scale,
+		Tint:  tint,
+		Anim:  NewAnimator(sheetID),
+	}
+	effect.Anim.FacingLeft = facingLeft
+	effect.Anim.Restart(clip)
+	effect.Anim.OnComplete = func(string) {
+		effect.IsDone = true
+	}
+	return effect
+}
+
+func (e *AnimEffect) Update(deltaTime float32) {
+	e.Timer += deltaTime
+	e.Anim.Update(deltaTime)
+}
+
+func (e *AnimEffect) Draw(debug bool) {
+	alpha := 1 - e.Timer/max(e.Anim.clip().Length(), 0.001)
+	e.Anim.DrawAt(r.Vector2{X: e.X, Y: e.Y}, e.Scale, r.ColorAlpha(e.Tint, max(0, min(1, alpha))*float32(e.Tint.A)/255))
+}
+
+func (e *AnimEffect) Unload() {
+	e.Anim.Unload()
+}
+
+// GetBounds returns the area the effect covers
+func (e *AnimEffect) GetBounds() r.Rectangle {
+	width, height := e.Anim.FrameSize()
+	return r.Rectangle{X: e.X, Y: e.Y, Width: width * e.Scale, Height: height * e.Scale}
+}
+
+// DrawLayer keeps effects on the ground, under whoever is still standing
+func (e *AnimEffect) DrawLayer() Layer {
+	return LayerGround
+}
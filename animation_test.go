@@ -0,0 +1,191 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+// testAnimator plays clips of a four cell wide, two cell high sheet of 16 pixel cells
+func testAnimator(clips map[string]*ClipDef) *Animator {
+	sheet := &SheetDef{ID: "test", Texture: "test.png", FrameWidth: 16, FrameHeight: 16, Default: "idle", Clips: clips}
+	if err := validateSheet(sheet); err != nil {
+		panic(err)
+	}
+	a := &Animator{Sheet: sheet, Texture: r.Texture2D{Width: 64, Height: 32}}
+	a.Restart(sheet.Default)
+	return a
+}
+
+// playedFrames steps an animator a frame of duration at a time and returns the cells it showed
+func playedFrames(a *Animator, steps int, duration float32) []int {
+	var cells []int
+	for i := 0; i < steps; i++ {
+		cells = append(cells, a.clip().Frames[a.Frame])
+		a.Update(duration)
+	}
+	return cells
+}
+
+func TestAnimationFileLoads(t *testing.T) {
+	reg, err := LoadAnimations(AnimationsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"player", "slash", "enemy", "boss", "portal", "exit_portal", "door", "merchant", "dummy"} {
+		if _, exists := reg.Lookup(id); !exists {
+			t.Errorf("sheet %q is not defined", id)
+		}
+	}
+	if slash := reg.Get("slash").Clips["slash"]; slash.Mode != AnimOnce || len(slash.Frames) != 6 {
+		t.Fatalf("slash clip = %+v", slash)
+	}
+}
+
+func TestLoadAnimationsRejectsBadFiles(t *testing.T) {
+	files := map[string]string{
+		"missing id":       `[{"texture": "a.png", "default": "a", "clips": {"a": {"frames": [0], "duration": 1}}}]`,
+		"duplicate":        `[{"id": "a", "texture": "a.png", "default": "a", "clips": {"a": {"frames": [0], "duration": 1}}}, {"id": "a", "texture": "a.png", "default": "a", "clips": {"a": {"frames": [0], "duration": 1}}}]`,
+		"no texture":       `[{"id": "a", "default": "a", "clips": {"a": {"frames": [0], "duration": 1}}}]`,
+		"unknown default":  `[{"id": "a", "texture": "a.png", "default": "b", "clips": {"a": {"frames": [0], "duration": 1}}}]`,
+		"no frames":        `[{"id": "a", "texture": "a.png", "default": "a", "clips": {"a": {"duration": 1}}}]`,
+		"no duration":      `[{"id": "a", "texture": "a.png", "default": "a", "clips": {"a": {"frames": [0]}}}]`,
+		"short durations":  `[{"id": "a", "texture": "a.png", "default": "a", "clips": {"a": {"frames": [0, 1], "durations": [1]}}}]`,
+		"unknown mode":     `[{"id": "a", "texture": "a.png", "default": "a", "clips": {"a": {"frames": [0], "duration": 1, "mode": "shuffle"}}}]`,
+		"unknown next":     `[{"id": "a", "texture": "a.png", "default": "a", "clips": {"a": {"frames": [0], "duration": 1, "mode": "once", "next": "b"}}}]`,
+		"looping next":     `[{"id": "a", "texture": "a.png", "default": "a", "clips": {"a": {"frames": [0], "duration": 1, "next": "a"}}}]`,
+		"trimmed to empty": `[{"id": "a", "texture": "a.png", "frame_width": 4, "trim_x": 2, "default": "a", "clips": {"a": {"frames": [0], "duration": 1}}}]`,
+		"not json":         `[{"id": }]`,
+	}
+	for name, content := range files {
+		if _, err := LoadAnimations(writeTestFile(t, "animations.json", content)); err == nil {
+			t.Errorf("%s: loaded without an error", name)
+		}
+	}
+}
+
+func TestAnimationModes(t *testing.T) {
+	a := testAnimator(map[string]*ClipDef{
+		"idle":  {Frames: []int{0, 1, 2}, Duration: 0.1, Mode: AnimLoop},
+		"swing": {Frames: []int{4, 5, 6}, Durations: []float32{0.1, 0.2, 0.1}, Mode: AnimOnce, Next: "idle"},
+		"bob":   {Frames: []int{0, 1, 2}, Duration: 0.1, Mode: AnimPingPong},
+	})
+
+	if cells := playedFrames(a, 7, 0.1); !slices.Equal(cells, []int{0, 1, 2, 0, 1, 2, 0}) {
+		t.Fatalf("loop played %v", cells)
+	}
+
+	// Once clips honour per-frame durations and hand over to their next clip
+	var completed []string
+	a.OnComplete = func(clip string) { completed = append(completed, clip) }
+	a.Restart("swing")
+	if cells := playedFrames(a, 5, 0.1); !slices.Equal(cells, []int{4, 5, 5, 6, 0}) {
+		t.Fatalf("once played %v", cells)
+	}
+	if a.Clip != "idle" || len(completed) != 1 || completed[0] != "swing" {
+		t.Fatalf("after the swing playing %q, completed %v", a.Clip, completed)
+	}
+
+	a.Restart("bob")
+	if cells := playedFrames(a, 7, 0.1); !slices.Equal(cells, []int{0, 1, 2, 1, 0, 1, 2}) {
+		t.Fatalf("ping-pong played %v", cells)
+	}
+
+	// A long frame steps over several frames at once
+	a.Restart("idle")
+	a.Update(0.25)
+	if a.Frame != 2 {
+		t.Fatalf("frame %d after 0.25 seconds, want 2", a.Frame)
+	}
+}
+
+func TestAnimatorBusyAndPlay(t *testing.T) {
+	a := testAnimator(map[string]*ClipDef{
+		"idle": {Frames: []int{0, 1}, Duration: 0.1, Mode: AnimLoop},
+		"hit":  {Frames: []int{7}, Duration: 0.1, Mode: AnimOnce},
+	})
+
+	// Playing the clip already on does not restart it
+	a.Update(0.1)
+	a.Play("idle")
+	if a.Frame != 1 {
+		t.Fatal("playing the same clip restarted it")
+	}
+
+	a.Play("hit")
+	if !a.Busy() {
+		t.Fatal("once clip is not busy while it plays")
+	}
+	a.Update(0.1)
+	if a.Busy() || !a.Done || a.Clip != "hit" {
+		t.Fatal("once clip without a next clip did not hold its last frame")
+	}
+	a.Play("missing")
+	if a.Clip != "hit" {
+		t.Fatal("unknown clip was played")
+	}
+}
+
+func TestAnimatorSourceCellsAndFlips(t *testing.T) {
+	a := testAnimator(map[string]*ClipDef{
+		"idle": {Frames: []int{5}, Duration: 1, Mode: AnimLoop},
+	})
+	source := a.Source()
+	if source.X != 16 || source.Y != 16 || source.Width != 16 {
+		t.Fatalf("cell 5 source = %+v", source)
+	}
+
+	a.FacingLeft = true
+	if a.Source().Width != -16 {
+		t.Fatal("art facing right was not flipped for an owner facing left")
+	}
+	a.Sheet.FacesLeft = true
+	if a.Source().Width != 16 {
+		t.Fatal("art facing left was flipped for an owner facing left")
+	}
+
+	a.Sheet.TrimX = 1
+	if source := a.SourceFacing(false); source.X != 17 || source.Width != -14 {
+		t.Fatalf("trimmed source = %+v", source)
+	}
+}
+
+func TestSwordSlashEndsWithItsAnimation(t *testing.T) {
+	g := NewHeadlessGame(NewScriptedInput(), 4)
+	defer g.Cleanup()
+
+	sword := NewSword()
+	defer sword.Unload()
+	sword.OnActivate(g.player)
+	frames := int(sword.SlashAnim.clip().Length()*60) - 1
+	for i := 0; i < frames; i++ {
+		sword.Update(1.0/60, g.player)
+		if !sword.IsSlashing {
+			t.Fatalf("slash ended after %d of %d frames", i+1, frames+1)
+		}
+	}
+	sword.Update(1.0/30, g.player)
+	if sword.IsSlashing || sword.Active {
+		t.Fatal("slash outlasted its animation")
+	}
+}
+
+func TestEnemyDeathPlaysOut(t *testing.T) {
+	g := NewHeadlessGame(NewScriptedInput(), 6)
+	defer g.Cleanup()
+	clearObstacles(g)
+
+	enemy := g.spawnEnemy(r.Vector2{X: g.player.X + 200, Y: g.player.Y}, Spawn{Kind: "grunt"}).(*Enemy)
+	g.dealDamage(DamageEvent{Source: g.player, Target: enemy, Amount: enemy.CurrentHealth})
+	if len(Query[*AnimEffect](g.world)) != 1 {
+		t.Fatal("dead enemy left no death animation")
+	}
+
+	for i := 0; i < 60; i++ {
+		g.Step(1.0 / 60)
+	}
+	if len(Query[*AnimEffect](g.world)) != 0 {
+		t.Fatal("death animation stayed after it played out")
+	}
+}
@@ -0,0 +1,568 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Aseprite files are decoded by the image package like any PNG, into an atlas of their frames
+func init() {
+	image.RegisterFormat("aseprite", "????\xe0\xa5", decodeAsepriteAtlas, decodeAsepriteAtlasConfig)
+}
+
+// Magic numbers of the Aseprite file format
+const (
+	asepriteFileMagic  = 0xA5E0
+	asepriteFrameMagic = 0xF1FA
+	asepriteHeaderSize = 128
+	asepriteMaxColors  = 65536 // Palettes never hold more entries than this
+)
+
+// Chunk types of the Aseprite file format
+const (
+	asepriteChunkOldPalette   = 0x0004
+	asepriteChunkOldPalette64 = 0x0011
+	asepriteChunkLayer        = 0x2004
+	asepriteChunkCel          = 0x2005
+	asepriteChunkTags         = 0x2018
+	asepriteChunkPalette      = 0x2019
+)
+
+// Cel types of the Aseprite file format
+const (
+	asepriteCelRaw        = 0
+	asepriteCelLinked     = 1
+	asepriteCelCompressed = 2
+	asepriteCelTilemap    = 3
+)
+
+// Layer flags and types of the Aseprite file format
+const (
+	asepriteLayerVisible    = 1
+	asepriteLayerBackground = 8
+	asepriteLayerGroup      = 1
+	asepriteOpacityValid    = 1 // Header flag telling layer opacity is set
+)
+
+// Tag directions of the Aseprite file format
+const (
+	asepriteForward         = 0
+	asepriteReverse         = 1
+	asepritePingPong        = 2
+	asepritePingPongReverse = 3
+)
+
+// isAseprite reports whether path names an Aseprite source file
+func isAseprite(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".aseprite" || ext == ".ase"
+}
+
+// AsepriteLayer is one layer of an Aseprite file
+type AsepriteLayer struct {
+	Name       string
+	Visible    bool // Hidden layers, and the children of hidden groups, are left out of the frames
+	Group      bool
+	Background bool
+	Opacity    uint8
+	ChildLevel int
+}
+
+// AsepriteTag is a named range of frames, which becomes an animation clip
+type AsepriteTag struct {
+	Name      string
+	From      int
+	To        int
+	Direction int
+	Repeat    int // Times the tag plays, 0 plays it forever
+}
+
+// AsepriteFrame is one flattened frame of an Aseprite file
+type AsepriteFrame struct {
+	Image    *image.RGBA
+	Duration float32 // Seconds
+}
+
+// Aseprite is a decoded Aseprite file, with every frame flattened into one image
+type Aseprite struct {
+	Width   int
+	Height  int
+	Layers  []AsepriteLayer
+	Tags    []AsepriteTag
+	Palette color.Palette
+	Frames  []AsepriteFrame
+}
+
+// asepriteCel is the image of one layer in one frame
+type asepriteCel struct {
+	Layer   int
+	X       int
+	Y       int
+	Opacity uint8
+	Image   *image.NRGBA
+}
+
+// asepriteReader reads the little-endian values of an Aseprite file
+type asepriteReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+// take returns the next n bytes, or nothing once the data ran out
+func (ar *asepriteReader) take(n int) []byte {
+	if ar.err != nil {
+		return make([]byte, n)
+	}
+	if n < 0 || ar.pos+n > len(ar.data) {
+		ar.err = io.ErrUnexpectedEOF
+		return make([]byte, n)
+	}
+	b := ar.data[ar.pos : ar.pos+n]
+	ar.pos += n
+	return b
+}
+
+// The value types are named as in the file format's documentation
+func (ar *asepriteReader) u8() uint8     { return ar.take(1)[0] }
+func (ar *asepriteReader) word() uint16  { return binary.LittleEndian.Uint16(ar.take(2)) }
+func (ar *asepriteReader) short() int16  { return int16(ar.word()) }
+func (ar *asepriteReader) dword() uint32 { return binary.LittleEndian.Uint32(ar.take(4)) }
+func (ar *asepriteReader) skip(n int)    { ar.take(n) }
+func (ar *asepriteReader) str() string   { return string(ar.take(int(ar.word()))) }
+
+// LoadAseprite reads and decodes an Aseprite file
+func LoadAseprite(path string) (*Aseprite, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sprite, err := DecodeAseprite(file)
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", path, err)
+	}
+	return sprite, nil
+}
+
+// DecodeAseprite reads an Aseprite file and flattens its visible layers into every frame.
+// Layers are blended normally, whatever blend mode the file asks for, and tilemap cels are skipped.
+func DecodeAseprite(reader io.Reader) (*Aseprite, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	ar := &asepriteReader{data: data}
+
+	// File header
+	ar.dword() // File size
+	if ar.word() != asepriteFileMagic {
+		return nil, fmt.Errorf("not an Aseprite file")
+	}
+	frameCount := int(ar.word())
+	sprite := &Aseprite{Width: int(ar.word()), Height: int(ar.word())}
+	depth := ar.word()
+	flags := ar.dword()
+	ar.skip(2 + 4 + 4) // Deprecated speed and two reserved values
+	transparent := ar.u8()
+	ar.skip(3)
+	colors := int(ar.word())
+	if ar.err != nil {
+		return nil, ar.err
+	}
+	if depth != 32 && depth != 16 && depth != 8 {
+		return nil, fmt.Errorf("unsupported color depth %d", depth)
+	}
+	if sprite.Width <= 0 || sprite.Height <= 0 {
+		return nil, fmt.Errorf("sprite has no size")
+	}
+	if frameCount == 0 {
+		return nil, fmt.Errorf("sprite has no frames")
+	}
+	// Every index of an 8-bit pixel has an entry, old palette chunks fill up to 256 whatever the header says
+	colors = max(colors, 256)
+	sprite.Palette = make(color.Palette, colors)
+	for i := range sprite.Palette {
+		sprite.Palette[i] = color.NRGBA{}
+	}
+	ar.pos = asepriteHeaderSize
+
+	frames := make([][]asepriteCel, frameCount)
+	for f := 0; f < frameCount; f++ {
+		start := ar.pos
+		size := int(ar.dword())
+		if ar.word() != asepriteFrameMagic || size < 16 {
+			return nil, fmt.Errorf("frame %d: bad frame magic", f)
+		}
+		oldChunks := int(ar.word())
+		duration := ar.word()
+		ar.skip(2)
+		chunks := int(ar.dword())
+		if chunks == 0 {
+			chunks = oldChunks
+		}
+		sprite.Frames = append(sprite.Frames, AsepriteFrame{Duration: float32(duration) / 1000})
+
+		for c := 0; c < chunks; c++ {
+			chunkStart := ar.pos
+			chunkSize := int(ar.dword())
+			chunkType := ar.word()
+			if ar.err != nil || chunkSize < 6 || chunkStart+chunkSize > len(data) {
+				return nil, fmt.Errorf("frame %d: chunk %d is cut short", f, c)
+			}
+			chunk := &asepriteReader{data: data[ar.pos : chunkStart+chunkSize]}
+
+			switch chunkType {
+			case asepriteChunkOldPalette, asepriteChunkOldPalette64:
+				if err := sprite.readOldPalette(chunk, chunkType == asepriteChunkOldPalette64); err != nil {
+					return nil, fmt.Errorf("frame %d: %w", f, err)
+				}
+			case asepriteChunkPalette:
+				if err := sprite.readPalette(chunk); err != nil {
+					return nil, fmt.Errorf("frame %d: %w", f, err)
+				}
+			case asepriteChunkLayer:
+				sprite.readLayer(chunk, flags&asepriteOpacityValid != 0)
+			case asepriteChunkTags:
+				sprite.readTags(chunk)
+			case asepriteChunkCel:
+				cel, linked, err := sprite.readCel(chunk, depth, transparent)
+				if err != nil {
+					return nil, fmt.Errorf("frame %d: %w", f, err)
+				}
+				if linked >= 0 {
+					if linked >= f {
+						return nil, fmt.Errorf("frame %d: cel links to frame %d", f, linked)
+					}
+					for _, other := range frames[linked] {
+						if other.Layer == cel.Layer {
+							cel.X, cel.Y, cel.Image = other.X, other.Y, other.Image
+						}
+					}
+				}
+				if cel.Image != nil {
+					frames[f] = append(frames[f], cel)
+				}
+			}
+			if chunk.err != nil {
+				return nil, fmt.Errorf("frame %d: chunk %#04x is cut short", f, chunkType)
+			}
+			ar.pos = chunkStart + chunkSize
+		}
+		ar.pos = start + size
+	}
+
+	for f, cels := range frames {
+		sprite.Frames[f].Image = sprite.flatten(cels)
+	}
+	for _, tag := range sprite.Tags {
+		if tag.From > tag.To || tag.To >= len(sprite.Frames) {
+			return nil, fmt.Errorf("tag %q covers frames %d to %d of %d", tag.Name, tag.From, tag.To, len(sprite.Frames))
+		}
+	}
+	return sprite, nil
+}
+
+// readOldPalette reads a palette chunk of files saved by old versions
+func (sprite *Aseprite) readOldPalette(chunk *asepriteReader, sixBit bool) error {
+	index := 0
+	packets := int(chunk.word())
+	for p := 0; p < packets; p++ {
+		index += int(chunk.u8())
+		count := int(chunk.u8())
+		if count == 0 {
+			count = 256
+		}
+		for i := 0; i < count; i++ {
+			c := color.NRGBA{R: chunk.u8(), G: chunk.u8(), B: chunk.u8(), A: 255}
+			if sixBit {
+				c.R, c.G, c.B = sixToEight(c.R), sixToEight(c.G), sixToEight(c.B)
+			}
+			if err := sprite.setColor(index, c); err != nil {
+				return err
+			}
+			index++
+		}
+	}
+	return nil
+}
+
+// sixToEight scales a color channel from 0-63 to 0-255
+func sixToEight(channel uint8) uint8 {
+	return uint8(int(channel) * 255 / 63)
+}
+
+// readPalette reads a palette chunk, which replaces the old ones. The chunk resizes the
+// palette, and the entries it sets have to fit in the new size.
+func (sprite *Aseprite) readPalette(chunk *asepriteReader) error {
+	size := int(chunk.dword())
+	first := int(chunk.dword())
+	last := int(chunk.dword())
+	chunk.skip(8)
+	if chunk.err != nil {
+		return chunk.err
+	}
+	if size > asepriteMaxColors || last < first || last >= size {
+		return fmt.Errorf("palette of %d colors sets entries %d to %d", size, first, last)
+	}
+	for len(sprite.Palette) < size {
+		sprite.Palette = append(sprite.Palette, color.NRGBA{})
+	}
+
+	for index := first; index <= last && chunk.err == nil; index++ {
+		flags := chunk.word()
+		rgba := chunk.take(4)
+		if flags&1 != 0 {
+			chunk.str() // Color name
+		}
+		if err := sprite.setColor(index, color.NRGBA{R: rgba[0], G: rgba[1], B: rgba[2], A: rgba[3]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setColor sets a palette entry, which has to be inside the palette
+func (sprite *Aseprite) setColor(index int, c color.NRGBA) error {
+	if index >= len(sprite.Palette) {
+		return fmt.Errorf("palette entry %d is past the end of the %d color palette", index, len(sprite.Palette))
+	}
+	sprite.Palette[index] = c
+	return nil
+}
+
+// readLayer reads a layer chunk. Layers come in order, the first is layer 0.
+func (sprite *Aseprite) readLayer(chunk *asepriteReader, opacityValid bool) {
+	flags := chunk.word()
+	layerType := chunk.word()
+	childLevel := int(chunk.word())
+	chunk.skip(4) // Default width and height, unused
+	chunk.word()  // Blend mode
+	opacity := chunk.u8()
+	chunk.skip(3)
+	name := chunk.str()
+	if !opacityValid {
+		opacity = 255
+	}
+
+	layer := AsepriteLayer{
+		Name:       name,
+		Visible:    flags&asepriteLayerVisible != 0,
+		Group:      layerType == asepriteLayerGroup,
+		Background: flags&asepriteLayerBackground != 0,
+		Opacity:    opacity,
+		ChildLevel: childLevel,
+	}
+
+	// Children of a hidden group are hidden too
+	for i := len(sprite.Layers) - 1; i >= 0; i-- {
+		parent := sprite.Layers[i]
+		if parent.ChildLevel < childLevel {
+			layer.Visible = layer.Visible && parent.Visible
+			break
+		}
+	}
+	sprite.Layers = append(sprite.Layers, layer)
+}
+
+// readTags reads the animation tags chunk
+func (sprite *Aseprite) readTags(chunk *asepriteReader) {
+	count := int(chunk.word())
+	chunk.skip(8)
+	for i := 0; i < count && chunk.err == nil; i++ {
+		tag := AsepriteTag{
+			From:      int(chunk.word()),
+			To:        int(chunk.word()),
+			Direction: int(chunk.u8()),
+			Repeat:    int(chunk.word()),
+		}
+		chunk.skip(6 + 3 + 1) // Reserved, deprecated color and an extra byte
+		tag.Name = chunk.str()
+		sprite.Tags = append(sprite.Tags, tag)
+	}
+}
+
+// readCel reads a cel chunk into an image. Linked cels come back without an image
+// and with the frame they share their image with, every other cel with a link of -1.
+func (sprite *Aseprite) readCel(chunk *asepriteReader, depth uint16, transparent uint8) (asepriteCel, int, error) {
+	cel := asepriteCel{
+		Layer: int(chunk.word()),
+		X:     int(chunk.short()),
+		Y:     int(chunk.short()),
+	}
+	cel.Opacity = chunk.u8()
+	celType := chunk.word()
+	chunk.skip(2 + 5) // Z-index, which is not honoured, and reserved bytes
+	if cel.Layer >= len(sprite.Layers) {
+		return cel, -1, fmt.Errorf("cel on unknown layer %d", cel.Layer)
+	}
+
+	var pixels []byte
+	var width, height int
+	switch celType {
+	case asepriteCelLinked:
+		return cel, int(chunk.word()), nil
+	case asepriteCelRaw:
+		width, height = int(chunk.word()), int(chunk.word())
+		pixels = chunk.data[chunk.pos:]
+	case asepriteCelCompressed:
+		width, height = int(chunk.word()), int(chunk.word())
+		inflated, err := zlib.NewReader(bytes.NewReader(chunk.data[chunk.pos:]))
+		if err != nil {
+			return cel, -1, fmt.Errorf("cel on layer %d: %w", cel.Layer, err)
+		}
+		defer inflated.Close()
+		if pixels, err = io.ReadAll(inflated); err != nil {
+			return cel, -1, fmt.Errorf("cel on layer %d: %w", cel.Layer, err)
+		}
+	case asepriteCelTilemap:
+		return cel, -1, nil
+	default:
+		return cel, -1, fmt.Errorf("cel on layer %d has unknown type %d", cel.Layer, celType)
+	}
+
+	bytesPerPixel := int(depth / 8)
+	if len(pixels) < width*height*bytesPerPixel {
+		return cel, -1, fmt.Errorf("cel on layer %d has %d bytes of pixels, want %d", cel.Layer, len(pixels), width*height*bytesPerPixel)
+	}
+
+	background := sprite.Layers[cel.Layer].Background
+	cel.Image = image.NewNRGBA(image.Rect(0, 0, width, height))
+	for i := 0; i < width*height; i++ {
+		px := pixels[i*bytesPerPixel:]
+		var c color.NRGBA
+		switch depth {
+		case 32:
+			c = color.NRGBA{R: px[0], G: px[1], B: px[2], A: px[3]}
+		case 16:
+			c = color.NRGBA{R: px[0], G: px[0], B: px[0], A: px[1]}
+		case 8:
+			// The transparent index is only see-through outside of the background layer
+			if px[0] != transparent || background {
+				if int(px[0]) < len(sprite.Palette) {
+					c = sprite.Palette[px[0]].(color.NRGBA)
+				}
+			}
+		}
+		cel.Image.SetNRGBA(i%width, i/width, c)
+	}
+	return cel, -1, nil
+}
+
+// flatten draws the cels of one frame of visible layers over each other, from the bottom layer up
+func (sprite *Aseprite) flatten(cels []asepriteCel) *image.RGBA {
+	frame := image.NewRGBA(image.Rect(0, 0, sprite.Width, sprite.Height))
+	for layer := range sprite.Layers {
+		if !sprite.Layers[layer].Visible || sprite.Layers[layer].Group {
+			continue
+		}
+		for _, cel := range cels {
+			if cel.Layer != layer {
+				continue
+			}
+			opacity := uint8(int(cel.Opacity) * int(sprite.Layers[layer].Opacity) / 255)
+			bounds := cel.Image.Bounds().Add(image.Pt(cel.X, cel.Y))
+			draw.DrawMask(frame, bounds, cel.Image, image.Point{}, image.NewUniform(color.Alpha{A: opacity}), image.Point{}, draw.Over)
+		}
+	}
+	return frame
+}
+
+// atlasColumns returns how many frames fit side by side in the atlas, which is kept close to square
+func (sprite *Aseprite) atlasColumns() int {
+	return max(1, int(math.Ceil(math.Sqrt(float64(len(sprite.Frames))))))
+}
+
+// atlasSize returns the size of the atlas of the sprite's frames
+func (sprite *Aseprite) atlasSize() (int, int) {
+	columns := sprite.atlasColumns()
+	rows := (len(sprite.Frames) + columns - 1) / columns
+	return columns * sprite.Width, max(1, rows) * sprite.Height
+}
+
+// Atlas lays the frames out in rows, left to right and top to bottom, so frame i is cell i of a sprite sheet
+func (sprite *Aseprite) Atlas() *image.RGBA {
+	width, height := sprite.atlasSize()
+	atlas := image.NewRGBA(image.Rect(0, 0, width, height))
+	columns := sprite.atlasColumns()
+	for i, frame := range sprite.Frames {
+		at := image.Pt(i%columns*sprite.Width, i/columns*sprite.Height)
+		draw.Draw(atlas, frame.Image.Bounds().Add(at), frame.Image, image.Point{}, draw.Src)
+	}
+	return atlas
+}
+
+// Clips turns the tags into animation clips, timed by the frame durations of the file.
+// A file without tags gets one looping clip of every frame, named idle.
+func (sprite *Aseprite) Clips() map[string]*ClipDef {
+	clips := make(map[string]*ClipDef)
+	if len(sprite.Tags) == 0 {
+		clips["idle"] = sprite.clip(0, len(sprite.Frames)-1, false, AnimLoop)
+		return clips
+	}
+
+	for _, tag := range sprite.Tags {
+		mode := AnimLoop
+		switch {
+		case tag.Direction == asepritePingPong || tag.Direction == asepritePingPongReverse:
+			mode = AnimPingPong
+		case tag.Repeat == 1:
+			mode = AnimOnce
+		}
+		reverse := tag.Direction == asepriteReverse || tag.Direction == asepritePingPongReverse
+		clips[tag.Name] = sprite.clip(tag.From, tag.To, reverse, mode)
+	}
+	return clips
+}
+
+// clip makes a clip of the frames from first to last
+func (sprite *Aseprite) clip(first, last int, reverse bool, mode AnimMode) *ClipDef {
+	clip := &ClipDef{Mode: mode}
+	for i := first; i <= last; i++ {
+		frame := i
+		if reverse {
+			frame = first + last - i
+		}
+		clip.Frames = append(clip.Frames, frame)
+		clip.Durations = append(clip.Durations, max(sprite.Frames[frame].Duration, 0.001))
+	}
+	return clip
+}
+
+// DefaultClip returns the clip an animator of the sprite starts with, its first tag
+func (sprite *Aseprite) DefaultClip() string {
+	if len(sprite.Tags) == 0 {
+		return "idle"
+	}
+	return sprite.Tags[0].Name
+}
+
+// decodeAsepriteAtlas decodes an Aseprite file into the atlas of its frames
+func decodeAsepriteAtlas(reader io.Reader) (image.Image, error) {
+	sprite, err := DecodeAseprite(reader)
+	if err != nil {
+		return nil, err
+	}
+	return sprite.Atlas(), nil
+}
+
+// decodeAsepriteAtlasConfig reports the size of the atlas an Aseprite file decodes into
+func decodeAsepriteAtlasConfig(reader io.Reader) (image.Config, error) {
+	sprite, err := DecodeAseprite(reader)
+	if err != nil {
+		return image.Config{}, err
+	}
+	width, height := sprite.atlasSize()
+	return image.Config{ColorModel: color.RGBAModel, Width: width, Height: height}, nil
+}
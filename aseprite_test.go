@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"image/color"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// asepriteWriter builds Aseprite files for tests, one frame of chunks at a time
+type asepriteWriter struct {
+	width, height, depth int
+	transparent          uint8
+	frames               [][]byte
+	durations            []int
+}
+
+func (w *asepriteWriter) frame(duration int, chunks ...[]byte) {
+	w.frames = append(w.frames, append(le(uint16(len(chunks))), bytes.Join(chunks, nil)...))
+	w.durations = append(w.durations, duration)
+}
+
+func (w *asepriteWriter) bytes() []byte {
+	var file bytes.Buffer
+	header := make([]byte, asepriteHeaderSize)
+	binary.LittleEndian.PutUint16(header[4:], asepriteFileMagic)
+	binary.LittleEndian.PutUint16(header[6:], uint16(len(w.frames)))
+	binary.LittleEndian.PutUint16(header[8:], uint16(w.width))
+	binary.LittleEndian.PutUint16(header[10:], uint16(w.height))
+	binary.LittleEndian.PutUint16(header[12:], uint16(w.depth))
+	binary.LittleEndian.PutUint32(header[14:], asepriteOpacityValid)
+	header[28] = w.transparent
+	binary.LittleEndian.PutUint16(header[32:], 256)
+	file.Write(header)
+
+	for i, frame := range w.frames {
+		chunkCount, chunks := frame[:2], frame[2:]
+		file.Write(le(uint32(16 + len(chunks))))
+		file.Write(le(uint16(asepriteFrameMagic)))
+		file.Write(chunkCount)
+		file.Write(le(uint16(w.durations[i])))
+		file.Write([]byte{0, 0, 0, 0, 0, 0})
+		file.Write(chunks)
+	}
+	out := file.Bytes()
+	binary.LittleEndian.PutUint32(out, uint32(len(out)))
+	return out
+}
+
+// le encodes values little-endian, strings with their length in front
+func le(values ...any) []byte {
+	var buf bytes.Buffer
+	for _, value := range values {
+		if s, ok := value.(string); ok {
+			binary.Write(&buf, binary.LittleEndian, uint16(len(s)))
+			buf.WriteString(s)
+			continue
+		}
+		binary.Write(&buf, binary.LittleEndian, value)
+	}
+	return buf.Bytes()
+}
+
+func chunk(chunkType uint16, body []byte) []byte {
+	return append(le(uint32(6+len(body)), chunkType), body...)
+}
+
+func layerChunk(name string, flags, layerType, childLevel uint16, opacity uint8) []byte {
+	return chunk(asepriteChunkLayer, le(flags, layerType, childLevel, uint16(0), uint16(0), uint16(0), opacity, [3]byte{}, name))
+}
+
+func celHeader(layer uint16, x, y int16, opacity uint8, celType uint16) []byte {
+	return le(layer, x, y, opacity, celType, int16(0), [5]byte{})
+}
+
+func rawCel(layer uint16, x, y int16, width, height uint16, pixels []byte) []byte {
+	return chunk(asepriteChunkCel, append(append(celHeader(layer, x, y, 255, asepriteCelRaw), le(width, height)...), pixels...))
+}
+
+func compressedCel(layer uint16, x, y int16, width, height uint16, pixels []byte) []byte {
+	var packed bytes.Buffer
+	zw := zlib.NewWriter(&packed)
+	zw.Write(pixels)
+	zw.Close()
+	return chunk(asepriteChunkCel, append(append(celHeader(layer, x, y, 255, asepriteCelCompressed), le(width, height)...), packed.Bytes()...))
+}
+
+func linkedCel(layer uint16, frame uint16) []byte {
+	return chunk(asepriteChunkCel, append(celHeader(layer, 0, 0, 255, asepriteCelLinked), le(frame)...))
+}
+
+func tagsChunk(tags ...AsepriteTag) []byte {
+	body := le(uint16(len(tags)), [8]byte{})
+	for _, tag := range tags {
+		body = append(body, le(uint16(tag.From), uint16(tag.To), uint8(tag.Direction), uint16(tag.Repeat), [6]byte{}, [3]byte{}, uint8(0), tag.Name)...)
+	}
+	return chunk(asepriteChunkTags, body)
+}
+
+func paletteChunk(colors ...color.NRGBA) []byte {
+	body := le(uint32(len(colors)), uint32(0), uint32(len(colors)-1), [8]byte{})
+	for _, c := range colors {
+		body = append(body, le(uint16(0), c.R, c.G, c.B, c.A)...)
+	}
+	return chunk(asepriteChunkPalette, body)
+}
+
+// testSprite is a 4x2 indexed sprite of three frames: a red background, a hidden blue layer,
+// a hidden group with a green child, and a white dot that moves, then stays put through a linked cel
+func testSprite() []byte {
+	w := &asepriteWriter{width: 4, height: 2, depth: 8, transparent: 0}
+	red, blue, green, white := uint8(1), uint8(2), uint8(3), uint8(4)
+	background := bytes.Repeat([]byte{red}, 8)
+	w.frame(100,
+		paletteChunk(color.NRGBA{}, color.NRGBA{R: 255, A: 255}, color.NRGBA{B: 255, A: 255}, color.NRGBA{G: 255, A: 255}, color.NRGBA{R: 255, G: 255, B: 255, A: 255}),
+		layerChunk("background", asepriteLayerVisible|asepriteLayerBackground, 0, 0, 255),
+		layerChunk("hidden", 0, 0, 0, 255),
+		layerChunk("group", 0, asepriteLayerGroup, 0, 255),
+		layerChunk("child", asepriteLayerVisible, 0, 1, 255),
+		layerChunk("dot", asepriteLayerVisible, 0, 0, 255),
+		tagsChunk(
+			AsepriteTag{Name: "walk", From: 0, To: 2, Direction: asepriteForward},
+			AsepriteTag{Name: "back", From: 0, To: 1, Direction: asepriteReverse},
+			AsepriteTag{Name: "bob", From: 1, To: 2, Direction: asepritePingPong},
+			AsepriteTag{Name: "pop", From: 2, To: 2, Direction: asepriteForward, Repeat: 1},
+		),
+		rawCel(0, 0, 0, 4, 2, background),
+		rawCel(1, 0, 0, 4, 2, bytes.Repeat([]byte{blue}, 8)),
+		rawCel(3, 0, 0, 4, 2, bytes.Repeat([]byte{green}, 8)),
+		rawCel(4, 0, 0, 2, 1, []byte{white, 0}),
+	)
+	w.frame(200,
+		compressedCel(0, 0, 0, 4, 2, background),
+		compressedCel(4, 2, 1, 1, 1, []byte{white}),
+	)
+	w.frame(300,
+		linkedCel(0, 1),
+		linkedCel(4, 1),
+	)
+	return w.bytes()
+}
+
+func TestDecodeAsepriteFlattensVisibleLayers(t *testing.T) {
+	sprite, err := DecodeAseprite(bytes.NewReader(testSprite()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sprite.Width != 4 || sprite.Height != 2 || len(sprite.Frames) != 3 || len(sprite.Layers) != 5 {
+		t.Fatalf("decoded %dx%d with %d frames and %d layers", sprite.Width, sprite.Height, len(sprite.Frames), len(sprite.Layers))
+	}
+	if sprite.Layers[1].Visible || sprite.Layers[3].Visible || !sprite.Layers[4].Visible {
+		t.Fatalf("layer visibility = %+v", sprite.Layers)
+	}
+
+	red := color.RGBA{R: 255, A: 255}
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	want := []map[[2]int]color.RGBA{
+		{{0, 0}: white, {1, 0}: red, {0, 1}: red}, // The transparent index shows the background
+		{{0, 0}: red, {2, 1}: white},
+		{{0, 0}: red, {2, 1}: white}, // Linked to the frame before
+	}
+	for f, pixels := range want {
+		for at, c := range pixels {
+			if got := sprite.Frames[f].Image.RGBAAt(at[0], at[1]); got != c {
+				t.Errorf("frame %d pixel %v = %v, want %v", f, at, got, c)
+			}
+		}
+	}
+	if sprite.Frames[1].Duration != 0.2 {
+		t.Fatalf("frame 1 lasts %v, want 0.2", sprite.Frames[1].Duration)
+	}
+}
+
+func TestAsepriteTagsBecomeClips(t *testing.T) {
+	sprite, err := DecodeAseprite(bytes.NewReader(testSprite()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	clips := sprite.Clips()
+	if walk := clips["walk"]; !slices.Equal(walk.Frames, []int{0, 1, 2}) || walk.Mode != AnimLoop || !slices.Equal(walk.Durations, []float32{0.1, 0.2, 0.3}) {
+		t.Errorf("walk = %+v", walk)
+	}
+	if back := clips["back"]; !slices.Equal(back.Frames, []int{1, 0}) {
+		t.Errorf("back = %+v", back)
+	}
+	if bob := clips["bob"]; bob.Mode != AnimPingPong {
+		t.Errorf("bob = %+v", bob)
+	}
+	if pop := clips["pop"]; pop.Mode != AnimOnce {
+		t.Errorf("pop = %+v", pop)
+	}
+	if sprite.DefaultClip() != "walk" {
+		t.Errorf("default clip = %q, want the first tag", sprite.DefaultClip())
+	}
+
+	// Frame i of the file is cell i of the atlas
+	atlas := sprite.Atlas()
+	if atlas.Bounds().Dx() != 8 || atlas.Bounds().Dy() != 4 {
+		t.Fatalf("atlas is %v", atlas.Bounds())
+	}
+	if atlas.RGBAAt(4+2, 1) != (color.RGBA{R: 255, G: 255, B: 255, A: 255}) || atlas.RGBAAt(2, 2+1) != (color.RGBA{R: 255, G: 255, B: 255, A: 255}) {
+		t.Fatal("frames are not laid out row by row")
+	}
+}
+
+func TestDecodeAsepriteRejectsBrokenFiles(t *testing.T) {
+	sprite := testSprite()
+	files := map[string][]byte{
+		"empty":        {},
+		"not aseprite": bytes.Repeat([]byte{1}, asepriteHeaderSize),
+		"cut short":    sprite[:len(sprite)-10],
+		"no frames":    (&asepriteWriter{width: 1, height: 1, depth: 32}).bytes(),
+	}
+	for name, content := range files {
+		if _, err := DecodeAseprite(bytes.NewReader(content)); err == nil {
+			t.Errorf("%s: decoded without an error", name)
+		}
+	}
+
+	w := &asepriteWriter{width: 1, height: 1, depth: 32}
+	w.frame(100, tagsChunk(AsepriteTag{Name: "past", From: 0, To: 3}))
+	if _, err := DecodeAseprite(bytes.NewReader(w.bytes())); err == nil {
+		t.Error("tag past the last frame decoded without an error")
+	}
+
+	// Palette chunks naming entries far out of range fail instead of growing the palette to fit
+	palettes := map[string][]uint32{ // New size, first and last entry
+		"huge palette":      {50_000_001, 50_000_000, 50_000_000},
+		"past its own size": {4, 2, 4},
+		"backwards":         {4, 3, 2},
+	}
+	for name, entries := range palettes {
+		w := &asepriteWriter{width: 1, height: 1, depth: 8}
+		w.frame(100, chunk(asepriteChunkPalette, le(entries[0], entries[1], entries[2], [8]byte{}, uint16(0), [4]byte{})))
+		if _, err := DecodeAseprite(bytes.NewReader(w.bytes())); err == nil {
+			t.Errorf("%s: decoded without an error", name)
+		}
+	}
+}
+
+func TestAssetAsepritesDecode(t *testing.T) {
+	for path, frames := range map[string]int{"assets/door.aseprite": 3, "assets/portal.aseprite": 5} {
+		sprite, err := LoadAseprite(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sprite.Frames) != frames {
+			t.Fatalf("%s has %d frames, want %d", path, len(sprite.Frames), frames)
+		}
+		if sprite.Frames[0].Image.RGBAAt(sprite.Width/2, sprite.Height/2).A == 0 {
+			t.Fatalf("%s is see-through in the middle", path)
+		}
+	}
+}
+
+func TestAsepriteSheetsLoadIntoAnimations(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "slime.aseprite"), testSprite(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "bat.ase"), testSprite(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	empty := &asepriteWriter{width: 4, height: 2, depth: 32}
+	if err := os.WriteFile(filepath.Join(dir, "empty.aseprite"), empty.bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "animations.json")
+	content := `[{"id": "bat", "texture": "` + filepath.Join(dir, "bat.ase") + `", "default": "pop",
+		"clips": {"walk": {"frames": [2], "duration": 1}}}]`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reg, err := LoadAnimations(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Broken files nobody names are skipped, the rest of the sheets still load
+	if _, exists := reg.Lookup("empty"); exists {
+		t.Fatal("sheet without frames was loaded")
+	}
+
+	// Files nobody names are found on their own
+	slime, exists := reg.Lookup("slime")
+	if !exists || slime.Default != "walk" || slime.FrameWidth != 4 || len(slime.Clips) != 4 {
+		t.Fatalf("slime sheet = %+v", slime)
+	}
+
+	// Clips of the animation file win over the tags
+	bat, _ := reg.Lookup("bat")
+	if bat.Default != "pop" || !slices.Equal(bat.Clips["walk"].Frames, []int{2}) || bat.Clips["bob"] == nil {
+		t.Fatalf("bat sheet = %+v", bat)
+	}
+	if len(reg.sheets) != 2 {
+		t.Fatalf("got %d sheets, the named file was imported twice", len(reg.sheets))
+	}
+
+	// The texture cache sizes the atlas like any image
+	headless = true
+	manager := NewAssetManager()
+	texture := manager.Load(slime.Texture)
+	defer manager.Release(texture)
+	if manager.IsFallback(slime.Texture) || texture.Width != 8 || texture.Height != 4 {
+		t.Fatalf("atlas texture is %dx%d", texture.Width, texture.Height)
+	}
+}
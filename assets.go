@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"sort"
+
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+// assets is the texture cache shared by every constructor
+var assets = NewAssetManager()
+
+// loadTexture takes a reference to the texture at path, loading it on first use
+func loadTexture(path string) r.Texture2D {
+	return assets.Load(path)
+}
+
+// unloadTexture drops a reference taken with loadTexture
+func unloadTexture(texture r.Texture2D) {
+	assets.Release(texture)
+}
+
+// textureEntry is one cached texture and how many owners hold it
+type textureEntry struct {
+	path     string
+	texture  r.Texture2D
+	refs     int
+	fallback bool
+}
+
+// AssetManager caches textures by path so each file is on the GPU once.
+// Every Load must be paired with a Release, the texture is freed when the last owner lets go.
+type AssetManager struct {
+	byPath map[string]*textureEntry
+	byID   map[uint32]*textureEntry
+	nextID uint32 // Texture IDs handed out while headless
+}
+
+// NewAssetManager creates an empty texture cache
+func NewAssetManager() *AssetManager {
+	return &AssetManager{
+		byPath: make(map[string]*textureEntry),
+		byID:   make(map[uint32]*textureEntry),
+	}
+}
+
+// Load returns the texture at path, loading it on first use.
+// Missing or broken files get a visible checkerboard instead.
+func (a *AssetManager) Load(path string) r.Texture2D {
+	if entry, exists := a.byPath[path]; exists {
+		entry.refs++
+		return entry.texture
+	}
+
+	entry := &textureEntry{path: path, refs: 1}
+	if headless {
+		entry.texture, entry.fallback = a.placeholderTexture(path)
+	} else if _, err := os.Stat(path); err != nil {
+		fmt.Println("Warning: Could not find", path+", using fallback texture")
+		entry.texture, entry.fallback = a.fallbackTexture(), true
+	} else {
+		entry.texture = a.loadFile(path)
+		if entry.texture.ID == 0 {
+			fmt.Println("Warning: Could not load", path+", using fallback texture")
+			entry.texture, entry.fallback = a.fallbackTexture(), true
+		}
+	}
+
+	a.byPath[path] = entry
+	a.byID[entry.texture.ID] = entry
+	return entry.texture
+}
+
+// Release drops one reference to a texture, freeing it once nobody holds it
+func (a *AssetManager) Release(texture r.Texture2D) {
+	entry, exists := a.byID[texture.ID]
+	if !exists {
+		return
+	}
+
+	entry.refs--
+	if entry.refs > 0 {
+		return
+	}
+	a.free(entry)
+}
+
+// RefCount reports how many owners hold the texture at path
+func (a *AssetManager) RefCount(path string) int {
+	if entry, exists := a.byPath[path]; exists {
+		return entry.refs
+	}
+	return 0
+}
+
+// IsFallback reports whether the texture at path failed to load
+func (a *AssetManager) IsFallback(path string) bool {
+	entry, exists := a.byPath[path]
+	return exists && entry.fallback
+}
+
+// Leaks lists every texture that is still held, with its reference count
+func (a *AssetManager) Leaks() map[string]int {
+	leaks := make(map[string]int)
+	for path, entry := range a.byPath {
+		leaks[path] = entry.refs
+	}
+	return leaks
+}
+
+// ReportLeaks prints every texture still held and frees them all
+func (a *AssetManager) ReportLeaks() {
+	leaks := a.Leaks()
+	var paths []string
+	for path := range leaks {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		fmt.Printf("Warning: Texture %s leaked with %d references\n", path, leaks[path])
+	}
+
+	for _, entry := range a.byPath {
+		a.free(entry)
+	}
+}
+
+// free removes an entry from the cache and frees its GPU memory
+func (a *AssetManager) free(entry *textureEntry) {
+	delete(a.byPath, entry.path)
+	delete(a.byID, entry.texture.ID)
+	if !headless {
+		r.UnloadTexture(entry.texture)
+	}
+}
+
+// loadFile uploads the image at path. Aseprite files are flattened into
+// the atlas of their frames first, raylib reads everything else itself.
+func (a *AssetManager) loadFile(path string) r.Texture2D {
+	if !isAseprite(path) {
+		return r.LoadTexture(path)
+	}
+
+	sprite, err := LoadAseprite(path)
+	if err != nil {
+		fmt.Println("Warning:", err)
+		return r.Texture2D{}
+	}
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, sprite.Atlas()); err != nil {
+		fmt.Println("Warning: Could not encode", path+":", err)
+		return r.Texture2D{}
+	}
+	img := r.LoadImageFromMemory(".png", encoded.Bytes(), int32(encoded.Len()))
+	texture := r.LoadTextureFromImage(img)
+	r.UnloadImage(img)
+	return texture
+}
+
+// fallbackTexture creates a magenta checkerboard that stands out in game
+func (a *AssetManager) fallbackTexture() r.Texture2D {
+	img := r.GenImageChecked(16, 16, 4, 4, r.Magenta, r.Black)
+	texture := r.LoadTextureFromImage(img)
+	r.UnloadImage(img)
+	return texture
+}
+
+// placeholderTexture stands in for a texture while headless. It gets a unique ID and,
+// when the file is readable, the image's real size so layout code behaves as in game.
+func (a *AssetManager) placeholderTexture(path string) (r.Texture2D, bool) {
+	a.nextID++
+	texture := r.Texture2D{ID: a.nextID, Width: 16, Height: 16, Mipmaps: 1}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return texture, true
+	}
+	defer file.Close()
+
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return texture, true
+	}
+	texture.Width = int32(config.Width)
+	texture.Height = int32(config.Height)
+	return texture, false
+}
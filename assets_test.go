@@ -0,0 +1,62 @@
+package main
+
+import "testing"
+
+func TestAssetManagerSharesTextures(t *testing.T) {
+	headless = true
+	manager := NewAssetManager()
+
+	first := manager.Load("assets/tree.png")
+	second := manager.Load("assets/tree.png")
+	if first.ID != second.ID {
+		t.Fatalf("same file loaded twice: IDs %d and %d", first.ID, second.ID)
+	}
+	if refs := manager.RefCount("assets/tree.png"); refs != 2 {
+		t.Fatalf("refs = %d, want 2", refs)
+	}
+
+	manager.Release(first)
+	if refs := manager.RefCount("assets/tree.png"); refs != 1 {
+		t.Fatalf("refs after one release = %d, want 1", refs)
+	}
+	manager.Release(second)
+	if leaks := manager.Leaks(); len(leaks) != 0 {
+		t.Fatalf("texture still held after the last release: %v", leaks)
+	}
+}
+
+func TestAssetManagerFallback(t *testing.T) {
+	headless = true
+	manager := NewAssetManager()
+
+	missing := manager.Load("assets/does_not_exist.png")
+	if !manager.IsFallback("assets/does_not_exist.png") || missing.Width != 16 {
+		t.Fatalf("missing file did not get the fallback: %+v", missing)
+	}
+	found := manager.Load("assets/tree.png")
+	if manager.IsFallback("assets/tree.png") || found.ID == missing.ID {
+		t.Fatal("readable file got the fallback")
+	}
+	manager.ReportLeaks()
+	if leaks := manager.Leaks(); len(leaks) != 0 {
+		t.Fatalf("ReportLeaks kept textures: %v", leaks)
+	}
+}
+
+func TestGameReleasesEveryTexture(t *testing.T) {
+	g := NewHeadlessGame(NewScriptedInput(), 3)
+	if refs, trees := assets.RefCount("assets/tree.png"), len(Query[*Tree](g.world)); refs != trees {
+		t.Fatalf("tree texture refs = %d, want one per tree (%d)", refs, trees)
+	}
+
+	// Play long enough for portals, enemies and drops to come and go
+	for i := 0; i < 2000; i++ {
+		g.Step(1.0 / 60)
+	}
+	g.unloadWorld()
+	unloadTexture(g.cursorTex)
+	g.menu.Cleanup()
+	if leaks := assets.Leaks(); len(leaks) != 0 {
+		t.Fatalf("textures leaked: %v", leaks)
+	}
+}
@@ -1,67 +1,319 @@
 package main
 
 import (
+	"fmt"
+	"math"
+
 	r "github.com/gen2brain/raylib-go/raylib"
 )
 
+// BossDef describes the boss guarding the exit of a dimension
+type BossDef struct {
+	Name   string     `json:"name"`
+	Health int32      `json:"health"`
+	Speed  float32    `json:"speed"` // Pixels per second while chasing
+	Loot   []LootDrop `json:"loot"`  // Everything in it drops when the boss dies
+}
+
+// LootDrop is one guaranteed drop of a loot table
+type LootDrop struct {
+	Item string `json:"item"`
+	Min  int    `json:"min"`
+	Max  int    `json:"max"`
+}
+
+// BossState is what the boss is doing right now
+type BossState int
+
+const (
+	BossChase  BossState = iota // Walking towards the player between attacks
+	BossWindup                  // Flashing before a charge, locked onto a direction
+	BossCharge                  // Rushing in a straight line
+)
+
+// Boss attacks
+const (
+	AttackCharge = "charge"
+	AttackRing   = "ring"
+	AttackSummon = "summon"
+)
+
+// bossPhases lists the attacks the boss cycles through as it loses health.
+// A new phase starts at every third of its health.
+var bossPhases = [][]string{
+	{AttackCharge},
+	{AttackCharge, AttackRing},
+	{AttackCharge, AttackRing, AttackSummon},
+}
+
+// Boss tuning
+const (
+	bossWindupTime    = 0.6
+	bossChargeTime    = 0.5
+	bossChargeSpeed   = 300.0
+	bossChargeDamage  = 25
+	bossContactDamage = 15
+	bossRingDamage    = 10
+	bossRingSpeed     = 120.0
+	bossRingLife      = 3.0
+	bossSummonCount   = 3
+)
+
+// BossProjectile is one shot of a projectile ring
+type BossProjectile struct {
+	Position r.Vector2
+	Velocity r.Vector2
+	Life     float32
+}
+
 type Boss struct {
-	X          float32
-	Y          float32
-	Width      int32
-	Height     int32
-	Texture    r.Texture2D
-	Speed      float32
-	Health     int32
-	Target     *Player
-	DropChance float32
-	FlashTimer float32
-	WasHit     bool
-}
-
-func NewBoss(x, y float32, target *Player) *Boss {
+	X              float32
+	Y              float32
+	Width          int32
+	Height         int32
+	Anim           *Animator
+	Name           string
+	Speed          float32
+	Health         int32
+	MaxHealth      int32
+	Target         *Player
+	DropChance     float32
+	FlashTimer     float32
+	DamageCooldown float32
+	WasHit         bool
+	Loot           []LootDrop
+	Arena          r.Rectangle // The boss never leaves its arena
+	State          BossState
+	StateTimer     float32
+	AttackTimer    float32 // Time until the next attack
+	AttackIndex    int     // Position in the current phase's attack cycle
+	ChargeDir      r.Vector2
+	Projectiles    []BossProjectile
+	summons        []Spawn // Minions waiting for the game to open a portal for them
+}
+
+func NewBoss(x, y float32, target *Player, def *BossDef, arena r.Rectangle) *Boss {
 	return &Boss{
-		X:          x,
-		Y:          y,
-		Width:      46,
-		Height:     66,
-		Texture:    r.LoadTexture("assets/boss.png"),
-		Speed:      1.0,
-		Health:     100, // More health than regular enemy
-		Target:     target,
-		DropChance: 1.0, // Always drops item
-		FlashTimer: 0,
+		X:           x,
+		Y:           y,
+		Width:       46,
+		Height:      66,
+		Anim:        NewAnimator("boss"),
+		Name:        def.Name,
+		Speed:       def.Speed,
+		Health:      def.Health,
+		MaxHealth:   def.Health,
+		Target:      target,
+		DropChance:  1.0, // Always drops its loot
+		FlashTimer:  0,
+		Loot:        def.Loot,
+		Arena:       arena,
+		State:       BossChase,
+		AttackTimer: 3.0, // Give the player a moment to find the boss
+	}
+}
+
+// Phase is the index into bossPhases for the boss's remaining health
+func (b *Boss) Phase() int {
+	lost := float32(b.MaxHealth-b.Health) / float32(b.MaxHealth)
+	phase := int(lost * float32(len(bossPhases)))
+	if phase >= len(bossPhases) {
+		phase = len(bossPhases) - 1
 	}
+	return phase
 }
 
-func (b *Boss) Update() {
+func (b *Boss) Update(deltaTime float32) {
+	if b.FlashTimer > 0 {
+		b.FlashTimer -= deltaTime
+	}
+	if b.DamageCooldown > 0 {
+		b.DamageCooldown -= deltaTime
+	}
+	b.updateProjectiles(deltaTime)
+	b.animate(deltaTime)
+
 	if b.Target == nil {
 		return
 	}
 
-	// Move towards player
-	dx := b.Target.X - b.X
-	dy := b.Target.Y - b.Y
-	dist := float32(r.Vector2Length(r.Vector2{X: dx, Y: dy}))
+	b.StateTimer -= deltaTime
+	switch b.State {
+	case BossChase:
+		// Move towards player
+		dx := b.Target.X - b.X
+		dy := b.Target.Y - b.Y
+		dist := float32(r.Vector2Length(r.Vector2{X: dx, Y: dy}))
+
+		if dist > 0 {
+			b.X += (dx / dist) * b.Speed * deltaTime
+			b.Y += (dy / dist) * b.Speed * deltaTime
+		}
+
+		b.AttackTimer -= deltaTime
+		if b.AttackTimer <= 0 {
+			b.attack()
+		}
 
-	if dist > 0 {
-		b.X += (dx / dist) * b.Speed
-		b.Y += (dy / dist) * b.Speed
+	case BossWindup:
+		if b.StateTimer <= 0 {
+			b.State = BossCharge
+			b.StateTimer = bossChargeTime
+		}
+
+	case BossCharge:
+		b.X += b.ChargeDir.X * bossChargeSpeed * deltaTime
+		b.Y += b.ChargeDir.Y * bossChargeSpeed * deltaTime
+		if b.StateTimer <= 0 {
+			b.State = BossChase
+		}
 	}
 
-	// Update flash effect
-	if b.FlashTimer > 0 {
-		b.FlashTimer -= r.GetFrameTime()
+	b.keepInArena()
+}
+
+// bossClips names the clip played in every state
+var bossClips = map[BossState]string{
+	BossChase:  "walk",
+	BossWindup: "windup",
+	BossCharge: "charge",
+}
+
+// animate plays the clip for the boss's state, letting a hit play out first
+func (b *Boss) animate(deltaTime float32) {
+	if !b.Anim.Busy() {
+		b.Anim.Play(bossClips[b.State])
+	}
+	b.Anim.Update(deltaTime)
+}
+
+// DeathEffect plays the boss's death where it fell
+func (b *Boss) DeathEffect() *AnimEffect {
+	return NewAnimEffect(b.Anim.Sheet.ID, "death", b.X, b.Y, 1, false, r.White)
+}
+
+// attack starts the next attack of the current phase's cycle
+func (b *Boss) attack() {
+	phase := b.Phase()
+	attacks := bossPhases[phase]
+	attack := attacks[b.AttackIndex%len(attacks)]
+	b.AttackIndex++
+
+	// Later phases attack more often
+	b.AttackTimer = 2.5 - 0.5*float32(phase)
+
+	switch attack {
+	case AttackCharge:
+		b.State = BossWindup
+		b.StateTimer = bossWindupTime
+		center := b.center()
+		b.ChargeDir = r.Vector2Normalize(r.Vector2{
+			X: b.Target.X + float32(b.Target.Width)/2 - center.X,
+			Y: b.Target.Y + float32(b.Target.Height)/2 - center.Y,
+		})
+	case AttackRing:
+		count := 12 + 4*phase
+		center := b.center()
+		for i := 0; i < count; i++ {
+			angle := float64(i) / float64(count) * 2 * math.Pi
+			b.Projectiles = append(b.Projectiles, BossProjectile{
+				Position: center,
+				Velocity: r.Vector2{
+					X: float32(math.Cos(angle)) * bossRingSpeed,
+					Y: float32(math.Sin(angle)) * bossRingSpeed,
+				},
+				Life: bossRingLife,
+			})
+		}
+	case AttackSummon:
+		for i := 0; i < bossSummonCount; i++ {
+			b.summons = append(b.summons, Spawn{Kind: "grunt"})
+		}
+	}
+}
+
+// TakeSummons returns the minions the boss called for since the last call
+func (b *Boss) TakeSummons() []Spawn {
+	summons := b.summons
+	b.summons = nil
+	return summons
+}
+
+// updateProjectiles moves the projectile rings and drops the ones that expired
+func (b *Boss) updateProjectiles(deltaTime float32) {
+	var remaining []BossProjectile
+	for _, projectile := range b.Projectiles {
+		projectile.Position.X += projectile.Velocity.X * deltaTime
+		projectile.Position.Y += projectile.Velocity.Y * deltaTime
+		projectile.Life -= deltaTime
+		if projectile.Life > 0 {
+			remaining = append(remaining, projectile)
+		}
+	}
+	b.Projectiles = remaining
+}
+
+// BlockProjectiles drops the projectiles that flew into a wall or anything else that blocks bullets
+func (b *Boss) BlockProjectiles(world *World) {
+	var remaining []BossProjectile
+	for _, projectile := range b.Projectiles {
+		blocked := false
+		for _, blocker := range QueryPoint[BulletBlocker](world, projectile.Position) {
+			if blocker.BlocksBullets() {
+				blocked = true
+				break
+			}
+		}
+		if !blocked {
+			remaining = append(remaining, projectile)
+		}
+	}
+	b.Projectiles = remaining
+}
+
+// ProjectileHits removes the projectiles touching bounds and returns how many there were
+func (b *Boss) ProjectileHits(bounds r.Rectangle) int {
+	hits := 0
+	var remaining []BossProjectile
+	for _, projectile := range b.Projectiles {
+		if r.CheckCollisionCircleRec(projectile.Position, 3, bounds) {
+			hits++
+		} else {
+			remaining = append(remaining, projectile)
+		}
+	}
+	b.Projectiles = remaining
+	return hits
+}
+
+// ContactDamage is how much touching the boss hurts right now
+func (b *Boss) ContactDamage() int32 {
+	if b.State == BossCharge {
+		return bossChargeDamage
+	}
+	return bossContactDamage
+}
+
+// keepInArena pushes the boss back inside its arena
+func (b *Boss) keepInArena() {
+	b.X = float32(math.Max(float64(b.Arena.X), math.Min(float64(b.X), float64(b.Arena.X+b.Arena.Width-float32(b.Width)))))
+	b.Y = float32(math.Max(float64(b.Arena.Y), math.Min(float64(b.Y), float64(b.Arena.Y+b.Arena.Height-float32(b.Height)))))
+}
+
+func (b *Boss) center() r.Vector2 {
+	return r.Vector2{
+		X: b.X + float32(b.Width)/2,
+		Y: b.Y + float32(b.Height)/2,
 	}
 }
 
 func (b *Boss) Draw(debug bool) {
-	r.DrawTextureEx(
-		b.Texture,
-		r.Vector2{X: b.X, Y: b.Y},
-		0,
-		1,
-		r.White,
-	)
+	// Glow red while winding up a charge
+	tint := r.White
+	if b.State == BossWindup {
+		tint = r.Red
+	}
+	b.Anim.DrawAt(r.Vector2{X: b.X, Y: b.Y}, 1, tint)
 
 	if b.FlashTimer > 0 {
 		r.DrawRectangle(
@@ -73,6 +325,10 @@ func (b *Boss) Draw(debug bool) {
 		)
 	}
 
+	for _, projectile := range b.Projectiles {
+		r.DrawCircleV(projectile.Position, 3, r.Purple)
+	}
+
 	if debug {
 		r.DrawRectangleLines(
 			int32(b.X),
@@ -82,7 +338,7 @@ func (b *Boss) Draw(debug bool) {
 			r.Red,
 		)
 		r.DrawText(
-			string(rune(b.Health+'0')),
+			fmt.Sprintf("%d", b.Health),
 			int32(b.X),
 			int32(b.Y-20),
 			20,
@@ -91,6 +347,28 @@ func (b *Boss) Draw(debug bool) {
 	}
 }
 
+// DrawHealthBar draws the boss's name and health across the top of the screen
+func (b *Boss) DrawHealthBar() {
+	barWidth := int32(400)
+	barHeight := int32(14)
+	barX := 400 - barWidth/2
+	barY := int32(100)
+
+	nameWidth := r.MeasureText(b.Name, 20)
+	r.DrawText(b.Name, 400-nameWidth/2, barY-24, 20, r.White)
+
+	r.DrawRectangle(barX, barY, barWidth, barHeight, r.DarkGray)
+	healthWidth := int32(float32(b.Health) / float32(b.MaxHealth) * float32(barWidth))
+	r.DrawRectangle(barX, barY, healthWidth, barHeight, r.Maroon)
+
+	// Mark where the next phases start
+	for i := 1; i < len(bossPhases); i++ {
+		x := barX + barWidth*int32(len(bossPhases)-i)/int32(len(bossPhases))
+		r.DrawLine(x, barY, x, barY+barHeight, r.Black)
+	}
+	r.DrawRectangleLines(barX, barY, barWidth, barHeight, r.Gray)
+}
+
 func (b *Boss) CheckCollision(player *Player) bool {
 	return r.CheckCollisionRecs(
 		r.Rectangle{X: b.X, Y: b.Y, Width: float32(b.Width), Height: float32(b.Height)},
@@ -98,10 +376,23 @@ func (b *Boss) CheckCollision(player *Player) bool {
 	)
 }
 
-func (b *Boss) TakeDamage(amount int32) {
-	b.Health -= amount
+func (b *Boss) TakeDamage(event DamageEvent) bool {
+	if b.DamageCooldown > 0 {
+		return false
+	}
+	b.DamageCooldown = event.Cooldown
+	b.Health -= event.Amount
 	b.FlashTimer = 0.1
 	b.WasHit = true
+	b.Anim.Restart("hit")
+
+	// Bosses are heavy and only take half the knockback, and none while charging
+	if b.State != BossCharge {
+		b.X += event.Knockback.X / 2
+		b.Y += event.Knockback.Y / 2
+		b.keepInArena()
+	}
+	return true
 }
 
 func (b *Boss) IsDead() bool {
@@ -109,12 +400,22 @@ func (b *Boss) IsDead() bool {
 }
 
 func (b *Boss) GetDropPosition() r.Vector2 {
-	return r.Vector2{
-		X: b.X + float32(b.Width)/2,
-		Y: b.Y + float32(b.Height)/2,
-	}
+	return b.center()
 }
 
 func (b *Boss) Unload() {
-	r.UnloadTexture(b.Texture)
+	b.Anim.Unload()
+}
+
+func (b *Boss) GetBounds() r.Rectangle {
+	return r.Rectangle{
+		X:      b.X,
+		Y:      b.Y,
+		Width:  float32(b.Width),
+		Height: float32(b.Height),
+	}
+}
+
+func (b *Boss) DrawLayer() Layer {
+	return LayerActors
 }
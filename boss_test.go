@@ -0,0 +1,66 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+func TestBossPhases(t *testing.T) {
+	headless = true
+	boss := NewBoss(0, 0, nil, &BossDef{Name: "Test", Health: 90}, r.Rectangle{Width: 500, Height: 500})
+	defer boss.Unload()
+
+	for _, c := range []struct {
+		health int32
+		phase  int
+	}{{90, 0}, {61, 0}, {59, 1}, {29, 2}, {0, 2}} {
+		boss.Health = c.health
+		if phase := boss.Phase(); phase != c.phase {
+			t.Errorf("phase at %d health = %d, want %d", c.health, phase, c.phase)
+		}
+	}
+}
+
+func TestBossFight(t *testing.T) {
+	g := NewHeadlessGame(NewScriptedInput(), 12)
+	defer g.Cleanup()
+	g.player.MaxHealth, g.player.CurrentHealth = 1e9, 1e9
+	g.startBossFight()
+	boss := g.boss
+
+	charged, shot, summoned := false, false, false
+	for i := 0; i < 60*60; i++ {
+		// Wear the boss down so it goes through every phase
+		if i%60 == 0 && boss.Health > 10 {
+			boss.Health -= 2
+		}
+		g.Step(1.0 / 60)
+
+		charged = charged || boss.State == BossCharge
+		shot = shot || len(boss.Projectiles) > 0
+		summoned = summoned || len(Query[*Portal](g.world)) > 0
+		if !r.CheckCollisionRecs(boss.GetBounds(), boss.Arena) {
+			t.Fatalf("boss left its arena at %v", boss.GetBounds())
+		}
+		if !r.CheckCollisionPointRec(r.Vector2{X: g.player.X, Y: g.player.Y}, boss.Arena) {
+			t.Fatalf("player left the arena at %v,%v", g.player.X, g.player.Y)
+		}
+	}
+	if !charged || !shot || !summoned {
+		t.Fatalf("charged %v, shot %v, summoned %v", charged, shot, summoned)
+	}
+
+	// Loading a save made mid fight restarts it
+	path := filepath.Join(t.TempDir(), "savegame.json")
+	if err := g.SaveGame(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.LoadGame(path); err != nil {
+		t.Fatal(err)
+	}
+	if g.boss == nil || g.boss.Health != g.boss.MaxHealth {
+		t.Fatal("boss fight not restarted")
+	}
+}
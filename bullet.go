@@ -0,0 +1,92 @@
+package main
+
+import (
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+// bulletSize is the width and height of a bullet's hit box
+const bulletSize = 4
+
+// Bullet is a small projectile flying in a straight line until its life runs out
+type Bullet struct {
+	Position  r.Vector2
+	Direction r.Vector2
+	Speed     float32
+	LifeTimer float32
+}
+
+// Bullets are the projectiles one shooter has in flight. The Pistol and ranged
+// enemies share them, so both follow the same collision rules.
+type Bullets []Bullet
+
+// Bounds returns the bullet's hit box
+func (b Bullet) Bounds() r.Rectangle {
+	return r.Rectangle{
+		X:      b.Position.X - bulletSize/2,
+		Y:      b.Position.Y - bulletSize/2,
+		Width:  bulletSize,
+		Height: bulletSize,
+	}
+}
+
+// Advance moves every bullet and drops the ones whose life ran out
+func (bullets Bullets) Advance(deltaTime float32) Bullets {
+	var remaining Bullets
+	for _, bullet := range bullets {
+		bullet.Position.X += bullet.Direction.X * bullet.Speed * deltaTime
+		bullet.Position.Y += bullet.Direction.Y * bullet.Speed * deltaTime
+		bullet.LifeTimer -= deltaTime
+		if bullet.LifeTimer > 0 {
+			remaining = append(remaining, bullet)
+		}
+	}
+	return remaining
+}
+
+// Blocked drops the bullets that flew into a tree, stone or anything else that blocks them
+func (bullets Bullets) Blocked(world *World) Bullets {
+	var remaining Bullets
+	for _, bullet := range bullets {
+		blocked := false
+		for _, blocker := range QueryRect[BulletBlocker](world, bullet.Bounds()) {
+			if blocker.BlocksBullets() {
+				blocked = true
+				break
+			}
+		}
+		if !blocked {
+			remaining = append(remaining, bullet)
+		}
+	}
+	return remaining
+}
+
+// Hit drops the bullets touching bounds and reports how many there were
+func (bullets Bullets) Hit(bounds r.Rectangle) (Bullets, int) {
+	var remaining Bullets
+	hits := 0
+	for _, bullet := range bullets {
+		if r.CheckCollisionRecs(bullet.Bounds(), bounds) {
+			hits++
+		} else {
+			remaining = append(remaining, bullet)
+		}
+	}
+	return remaining, hits
+}
+
+// AllBounds returns the hit box of every bullet in flight
+func (bullets Bullets) AllBounds() []r.Rectangle {
+	bounds := make([]r.Rectangle, 0, len(bullets))
+	for _, bullet := range bullets {
+		bounds = append(bounds, bullet.Bounds())
+	}
+	return bounds
+}
+
+// Draw draws every bullet as a small square
+func (bullets Bullets) Draw(color r.Color) {
+	for _, bullet := range bullets {
+		r.DrawRectangleRec(bullet.Bounds(), color)
+	}
+}
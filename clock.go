@@ -0,0 +1,22 @@
+package main
+
+import (
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Clock supplies the length of the frame being simulated
+type Clock interface {
+	GetFrameTime() float32
+}
+
+// RaylibClock reports the real frame time measured by raylib
+type RaylibClock struct{}
+
+func (RaylibClock) GetFrameTime() float32 { return r.GetFrameTime() }
+
+// FixedClock always reports the same frame time, for deterministic stepping
+type FixedClock struct {
+	Delta float32
+}
+
+func (c *FixedClock) GetFrameTime() float32 { return c.Delta }
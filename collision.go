@@ -0,0 +1,42 @@
+package main
+
+import (
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+// moveAndSlide moves bounds by delta one axis at a time and stops them flush
+// against solid entities, so movers slide along walls instead of sticking.
+// It returns where the bounds end up.
+func moveAndSlide(world *World, bounds r.Rectangle, delta r.Vector2) r.Vector2 {
+	bounds.X += delta.X
+	for _, solid := range solidsIn(world, bounds) {
+		other := solid.GetBounds()
+		if delta.X > 0 {
+			bounds.X = min(bounds.X, other.X-bounds.Width)
+		} else if delta.X < 0 {
+			bounds.X = max(bounds.X, other.X+other.Width)
+		}
+	}
+
+	bounds.Y += delta.Y
+	for _, solid := range solidsIn(world, bounds) {
+		other := solid.GetBounds()
+		if delta.Y > 0 {
+			bounds.Y = min(bounds.Y, other.Y-bounds.Height)
+		} else if delta.Y < 0 {
+			bounds.Y = max(bounds.Y, other.Y+other.Height)
+		}
+	}
+	return r.Vector2{X: bounds.X, Y: bounds.Y}
+}
+
+// solidsIn returns the solid entities overlapping bounds
+func solidsIn(world *World, bounds r.Rectangle) []Solid {
+	var solids []Solid
+	for _, solid := range QueryRect[Solid](world, bounds) {
+		if solid.IsSolid() {
+			solids = append(solids, solid)
+		}
+	}
+	return solids
+}
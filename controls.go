@@ -0,0 +1,406 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+// ControlsFile is where the player's key bindings are stored
+const ControlsFile = "controls.json"
+
+// Gamepad buttons and axes as numbered by raylib's GamepadButton and GamepadAxis enums
+const (
+	gamepadButtonLeftFaceUp     = 1
+	gamepadButtonLeftFaceRight  = 2
+	gamepadButtonLeftFaceDown   = 3
+	gamepadButtonLeftFaceLeft   = 4
+	gamepadButtonRightFaceUp    = 5
+	gamepadButtonRightFaceRight = 6
+	gamepadButtonRightFaceDown  = 7
+	gamepadButtonRightFaceLeft  = 8
+	gamepadButtonLeftTrigger1   = 9
+	gamepadButtonRightTrigger1  = 11
+	gamepadButtonRightTrigger2  = 12
+	gamepadButtonMiddleLeft     = 13
+
+	gamepadAxisLeftX = 0
+	gamepadAxisLeftY = 1
+)
+
+// Action is a named thing the player can do, independent of the key that triggers it
+type Action int
+
+const (
+	ActionMoveUp Action = iota
+	ActionMoveDown
+	ActionMoveLeft
+	ActionMoveRight
+	ActionDash
+	ActionFire
+	ActionInteract
+	ActionDeploy
+	ActionOpenInventory
+	ActionOpenCrafting
+	ActionOpenGrimoire
+	ActionSwitchWeapon1
+	ActionSwitchWeapon2
+	ActionSwitchWeapon3
+	ActionSwitchWeapon4
+	ActionSwitchWeapon5
+	ActionCastSpell1
+	ActionCastSpell2
+	ActionCastSpell3
+	ActionCastSpell4
+	ActionToggleDebug
+	ActionDebugExplosion
+	ActionQuickSave
+	ActionQuickLoad
+	actionCount
+)
+
+var actionNames = [actionCount]string{
+	ActionMoveUp:         "move_up",
+	ActionMoveDown:       "move_down",
+	ActionMoveLeft:       "move_left",
+	ActionMoveRight:      "move_right",
+	ActionDash:           "dash",
+	ActionFire:           "fire",
+	ActionInteract:       "interact",
+	ActionDeploy:         "deploy",
+	ActionOpenInventory:  "open_inventory",
+	ActionOpenCrafting:   "open_crafting",
+	ActionOpenGrimoire:   "open_grimoire",
+	ActionSwitchWeapon1:  "switch_weapon_1",
+	ActionSwitchWeapon2:  "switch_weapon_2",
+	ActionSwitchWeapon3:  "switch_weapon_3",
+	ActionSwitchWeapon4:  "switch_weapon_4",
+	ActionSwitchWeapon5:  "switch_weapon_5",
+	ActionCastSpell1:     "cast_spell_1",
+	ActionCastSpell2:     "cast_spell_2",
+	ActionCastSpell3:     "cast_spell_3",
+	ActionCastSpell4:     "cast_spell_4",
+	ActionToggleDebug:    "toggle_debug",
+	ActionDebugExplosion: "debug_explosion",
+	ActionQuickSave:      "quick_save",
+	ActionQuickLoad:      "quick_load",
+}
+
+// SwitchWeaponAction returns the action that selects the weapon in the given toolbar slot
+func SwitchWeaponAction(slot int) Action {
+	return ActionSwitchWeapon1 + Action(slot)
+}
+
+// CastSpellAction returns the action that casts the spell in the given spell slot
+func CastSpellAction(slot int) Action {
+	return ActionCastSpell1 + Action(slot)
+}
+
+func (a Action) String() string {
+	if a >= 0 && a < actionCount {
+		return actionNames[a]
+	}
+	return fmt.Sprintf("action(%d)", int(a))
+}
+
+func (a Action) MarshalText() ([]byte, error) {
+	if a < 0 || a >= actionCount {
+		return nil, fmt.Errorf("unknown action %d", int(a))
+	}
+	return []byte(a.String()), nil
+}
+
+func (a *Action) UnmarshalText(text []byte) error {
+	for i, name := range actionNames {
+		if name == string(text) {
+			*a = Action(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown action %q", text)
+}
+
+// Device is the kind of hardware a binding listens to
+type Device int
+
+const (
+	DeviceKeyboard Device = iota
+	DeviceMouse
+	DeviceGamepadButton
+	DeviceGamepadAxis
+)
+
+var deviceNames = map[Device]string{
+	DeviceKeyboard:      "keyboard",
+	DeviceMouse:         "mouse",
+	DeviceGamepadButton: "gamepad_button",
+	DeviceGamepadAxis:   "gamepad_axis",
+}
+
+func (d Device) String() string {
+	if name, ok := deviceNames[d]; ok {
+		return name
+	}
+	return fmt.Sprintf("device(%d)", int(d))
+}
+
+func (d Device) MarshalText() ([]byte, error) {
+	if _, ok := deviceNames[d]; !ok {
+		return nil, fmt.Errorf("unknown device %d", int(d))
+	}
+	return []byte(d.String()), nil
+}
+
+func (d *Device) UnmarshalText(text []byte) error {
+	for device, name := range deviceNames {
+		if name == string(text) {
+			*d = device
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown device %q", text)
+}
+
+// Binding ties one physical input to an action.
+// For gamepad axes, Direction is -1 or 1 and picks which half of the stick counts.
+type Binding struct {
+	Device    Device  `json:"device"`
+	Code      int32   `json:"code"`
+	Direction float32 `json:"direction,omitempty"`
+}
+
+func (b Binding) String() string {
+	if b.Device == DeviceGamepadAxis {
+		return fmt.Sprintf("%s %d (%+.0f)", b.Device, b.Code, b.Direction)
+	}
+	return fmt.Sprintf("%s %d", b.Device, b.Code)
+}
+
+// Conflict reports a binding that triggers more than one action
+type Conflict struct {
+	Binding Binding
+	Actions []Action
+}
+
+func (c Conflict) Error() string {
+	return fmt.Sprintf("%s is bound to %v", c.Binding, c.Actions)
+}
+
+// InputMap translates raw input into actions
+type InputMap struct {
+	Gamepad      int32                `json:"gamepad"`
+	AxisDeadzone float32              `json:"axis_deadzone"`
+	Bindings     map[Action][]Binding `json:"bindings"`
+}
+
+// DefaultInputMap returns the stock controls
+func DefaultInputMap() *InputMap {
+	key := func(code int32) Binding { return Binding{Device: DeviceKeyboard, Code: code} }
+	button := func(code int32) Binding { return Binding{Device: DeviceGamepadButton, Code: code} }
+	axis := func(code int32, direction float32) Binding {
+		return Binding{Device: DeviceGamepadAxis, Code: code, Direction: direction}
+	}
+
+	return &InputMap{
+		Gamepad:      0,
+		AxisDeadzone: 0.3,
+		Bindings: map[Action][]Binding{
+			ActionMoveUp:         {key(r.KeyW), button(gamepadButtonLeftFaceUp), axis(gamepadAxisLeftY, -1)},
+			ActionMoveDown:       {key(r.KeyS), button(gamepadButtonLeftFaceDown), axis(gamepadAxisLeftY, 1)},
+			ActionMoveLeft:       {key(r.KeyA), button(gamepadButtonLeftFaceLeft), axis(gamepadAxisLeftX, -1)},
+			ActionMoveRight:      {key(r.KeyD), button(gamepadButtonLeftFaceRight), axis(gamepadAxisLeftX, 1)},
+			ActionDash:           {key(r.KeySpace), button(gamepadButtonRightFaceDown)},
+			ActionFire:           {{Device: DeviceMouse, Code: r.MouseRightButton}, button(gamepadButtonRightTrigger2)},
+			ActionInteract:       {key(r.KeyF), button(gamepadButtonRightFaceLeft)},
+			ActionDeploy:         {key(r.KeyT)},
+			ActionOpenInventory:  {key(r.KeyE), button(gamepadButtonRightFaceUp)},
+			ActionOpenCrafting:   {key(r.KeyC), button(gamepadButtonRightFaceRight)},
+			ActionOpenGrimoire:   {key(r.KeyG)},
+			ActionSwitchWeapon1:  {key(r.KeyOne), button(gamepadButtonLeftTrigger1)},
+			ActionSwitchWeapon2:  {key(r.KeyTwo), button(gamepadButtonRightTrigger1)},
+			ActionSwitchWeapon3:  {key(r.KeyThree), button(gamepadButtonMiddleLeft)},
+			ActionSwitchWeapon4:  {key(r.KeyFour)},
+			ActionSwitchWeapon5:  {key(r.KeyFive)},
+			ActionCastSpell1:     {key(r.KeyQ)},
+			ActionCastSpell2:     {key(r.KeyR)},
+			ActionCastSpell3:     {key(r.KeyZ)},
+			ActionCastSpell4:     {key(r.KeyX)},
+			ActionToggleDebug:    {key(r.KeyF1)},
+			ActionDebugExplosion: {key(r.KeyF2)},
+			ActionQuickSave:      {key(r.KeyF5)},
+			ActionQuickLoad:      {key(r.KeyF9)},
+		},
+	}
+}
+
+// LoadInputMap reads bindings from a file. Actions missing from the file keep their defaults.
+func LoadInputMap(path string) (*InputMap, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := &InputMap{}
+	if err := json.Unmarshal(data, loaded); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	m := DefaultInputMap()
+	m.Gamepad = loaded.Gamepad
+	if loaded.AxisDeadzone > 0 {
+		m.AxisDeadzone = loaded.AxisDeadzone
+	}
+	for action, bindings := range loaded.Bindings {
+		m.Bindings[action] = bindings
+	}
+	return m, nil
+}
+
+// Save writes the bindings to a file
+func (m *InputMap) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// LoadControls loads the player's bindings, writing the defaults on first run
+func LoadControls(path string) *InputMap {
+	m, err := LoadInputMap(path)
+	if os.IsNotExist(err) {
+		m = DefaultInputMap()
+		if err := m.Save(path); err != nil {
+			fmt.Println("Warning: Could not save controls:", err)
+		}
+	} else if err != nil {
+		fmt.Println("Warning: Could not load controls, using defaults:", err)
+		m = DefaultInputMap()
+	}
+
+	for _, conflict := range m.Conflicts() {
+		fmt.Println("Warning: Conflicting controls:", conflict)
+	}
+	return m
+}
+
+// Bind adds a binding to an action, refusing bindings another action already uses
+func (m *InputMap) Bind(action Action, binding Binding) error {
+	for other, bindings := range m.Bindings {
+		if other == action {
+			continue
+		}
+		for _, existing := range bindings {
+			if existing == binding {
+				return Conflict{Binding: binding, Actions: []Action{other, action}}
+			}
+		}
+	}
+
+	for _, existing := range m.Bindings[action] {
+		if existing == binding {
+			return nil
+		}
+	}
+	m.Bindings[action] = append(m.Bindings[action], binding)
+	return nil
+}
+
+// Unbind removes a binding from an action
+func (m *InputMap) Unbind(action Action, binding Binding) {
+	var remaining []Binding
+	for _, existing := range m.Bindings[action] {
+		if existing != binding {
+			remaining = append(remaining, existing)
+		}
+	}
+	m.Bindings[action] = remaining
+}
+
+// Conflicts lists every binding shared by more than one action
+func (m *InputMap) Conflicts() []Conflict {
+	users := make(map[Binding][]Action)
+	for action := Action(0); action < actionCount; action++ {
+		for _, binding := range m.Bindings[action] {
+			users[binding] = append(users[binding], action)
+		}
+	}
+
+	var conflicts []Conflict
+	for binding, actions := range users {
+		if len(actions) > 1 {
+			conflicts = append(conflicts, Conflict{Binding: binding, Actions: actions})
+		}
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		if conflicts[i].Actions[0] != conflicts[j].Actions[0] {
+			return conflicts[i].Actions[0] < conflicts[j].Actions[0]
+		}
+		return conflicts[i].Binding.String() < conflicts[j].Binding.String()
+	})
+	return conflicts
+}
+
+// KeyLabel returns the letter or digit of the first key bound to an action, empty when it has none
+func (m *InputMap) KeyLabel(action Action) string {
+	for _, binding := range m.Bindings[action] {
+		if binding.Device != DeviceKeyboard {
+			continue
+		}
+		if (binding.Code >= r.KeyA && binding.Code <= r.KeyZ) || (binding.Code >= r.KeyZero && binding.Code <= r.KeyNine) {
+			return string(rune(binding.Code))
+		}
+	}
+	return ""
+}
+
+// IsDown reports whether any binding of the action is held
+func (m *InputMap) IsDown(input Input, action Action) bool {
+	for _, binding := range m.Bindings[action] {
+		switch binding.Device {
+		case DeviceKeyboard:
+			if input.IsKeyDown(binding.Code) {
+				return true
+			}
+		case DeviceMouse:
+			if input.IsMouseButtonDown(binding.Code) {
+				return true
+			}
+		case DeviceGamepadButton:
+			if input.IsGamepadAvailable(m.Gamepad) && input.IsGamepadButtonDown(m.Gamepad, binding.Code) {
+				return true
+			}
+		case DeviceGamepadAxis:
+			if input.IsGamepadAvailable(m.Gamepad) &&
+				input.GetGamepadAxisMovement(m.Gamepad, binding.Code)*binding.Direction > m.AxisDeadzone {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsPressed reports whether any binding of the action went down this frame.
+// Stick axes have no press edge, so they only count towards IsDown.
+func (m *InputMap) IsPressed(input Input, action Action) bool {
+	for _, binding := range m.Bindings[action] {
+		switch binding.Device {
+		case DeviceKeyboard:
+			if input.IsKeyPressed(binding.Code) {
+				return true
+			}
+		case DeviceMouse:
+			if input.IsMouseButtonPressed(binding.Code) {
+				return true
+			}
+		case DeviceGamepadButton:
+			if input.IsGamepadAvailable(m.Gamepad) && input.IsGamepadButtonPressed(m.Gamepad, binding.Code) {
+				return true
+			}
+		}
+	}
+	return false
+}
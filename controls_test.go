@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+func TestDefaultControlsHaveNoConflicts(t *testing.T) {
+	if conflicts := DefaultInputMap().Conflicts(); len(conflicts) != 0 {
+		t.Fatalf("default controls conflict: %v", conflicts)
+	}
+}
+
+func TestBindRefusesTakenBinding(t *testing.T) {
+	m := DefaultInputMap()
+	err := m.Bind(ActionDash, Binding{Device: DeviceKeyboard, Code: r.KeyE})
+	if _, ok := err.(Conflict); !ok {
+		t.Fatalf("binding the inventory key to dash: got %v, want a conflict", err)
+	}
+	if len(m.Bindings[ActionDash]) != 2 {
+		t.Fatalf("refused binding was added: %v", m.Bindings[ActionDash])
+	}
+}
+
+func TestControlsRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "controls.json")
+	m := DefaultInputMap()
+	m.Bindings[ActionDash] = []Binding{{Device: DeviceKeyboard, Code: r.KeyQ}}
+	delete(m.Bindings, ActionToggleDebug)
+	if err := m.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadInputMap(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dash := loaded.Bindings[ActionDash]; len(dash) != 1 || dash[0].Code != r.KeyQ {
+		t.Fatalf("dash bindings = %v, want only Q", dash)
+	}
+	// Actions missing from the file fall back to their defaults
+	if debug := loaded.Bindings[ActionToggleDebug]; len(debug) != 1 || debug[0].Code != r.KeyF1 {
+		t.Fatalf("debug bindings = %v, want the default", debug)
+	}
+}
+
+func TestLoadInputMapRejectsBadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "controls.json")
+	if err := os.WriteFile(path, []byte(`{"bindings": {"fly": []}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadInputMap(path); err == nil {
+		t.Fatal("unknown action loaded without an error")
+	}
+}
+
+func TestGamepadStickMovesPlayer(t *testing.T) {
+	input := NewScriptedInput()
+	input.GamepadConnected = true
+	g := NewHeadlessGame(input, 42)
+	defer g.Cleanup()
+
+	x := g.player.X
+	input.SetPadAxis(gamepadAxisLeftX, -1)
+	g.Step(1.0 / 60)
+	if g.player.X >= x {
+		t.Fatalf("player did not walk left: %v -> %v", x, g.player.X)
+	}
+
+	// Inside the deadzone the stick does nothing
+	x = g.player.X
+	input.SetPadAxis(gamepadAxisLeftX, -0.1)
+	g.Step(1.0 / 60)
+	if g.player.X != x {
+		t.Fatalf("player moved inside the deadzone: %v -> %v", x, g.player.X)
+	}
+}
@@ -7,38 +7,73 @@ import (
 	r "github.com/gen2brain/raylib-go/raylib"
 )
 
-// Recipe represents a crafting recipe
-type Recipe struct {
-	Result     string
-	ResultIcon r.Texture2D
-	Materials  map[string]int
-}
-
 // CraftingSystem represents the crafting interface
 type CraftingSystem struct {
-	IsOpen  bool
-	Recipes []Recipe
+	IsOpen       bool
+	Recipes      []Recipe
+	NearStations map[string]bool // Stations the player stands near, set by the game every frame
+	Dimension    int             // Index of the dimension the player is in, set by the game every frame
+	watcher      recipeWatcher
+}
+
+// NewCraftingSystem creates a crafting system with the recipes of a file
+func NewCraftingSystem(path string) *CraftingSystem {
+	cs := &CraftingSystem{
+		IsOpen:       false,
+		NearStations: make(map[string]bool),
+		watcher:      recipeWatcher{path: path},
+	}
+	// Note the file's current time so the first Update does not load it again
+	cs.watcher.changed(0)
+	cs.Reload()
+	return cs
+}
+
+// Reload reads the recipe file again, keeping the current recipes if it is broken
+func (cs *CraftingSystem) Reload() {
+	recipes, err := LoadRecipes(cs.watcher.path)
+	if err != nil {
+		fmt.Println("Warning: Could not load recipes:", err)
+		return
+	}
+	cs.Recipes = recipes
+}
+
+// craftingCloseButton closes the crafting window
+var craftingCloseButton = r.Rectangle{X: 350, Y: 460, Width: 100, Height: 30}
+
+// craftButton returns where the craft button of the row-th listed recipe is
+func craftButton(row int) r.Rectangle {
+	return r.Rectangle{X: 550, Y: float32(160 + row*40), Width: 70, Height: 20}
 }
 
-// NewCraftingSystem creates a new crafting system
-func NewCraftingSystem() *CraftingSystem {
-	return &CraftingSystem{
-		IsOpen: false,
-		Recipes: []Recipe{
-			{
-				Result: "Pickaxe",
-				Materials: map[string]int{
-					"Strange Log":    2,
-					"Stone Fragment": 3,
-				},
-			},
-			{
-				Result: "Gold Coin",
-				Materials: map[string]int{
-					"Golden Nugget": 3,
-				},
-			},
-		},
+// Update reloads the recipes when their file changes, so crafting can be balanced while playing,
+// and handles clicks on the crafting window while it is open
+func (cs *CraftingSystem) Update(deltaTime float32, input Input, inventory *Inventory) {
+	if cs.watcher.changed(deltaTime) {
+		cs.Reload()
+	}
+	if !cs.IsOpen || !input.IsMouseButtonPressed(0) {
+		return
+	}
+
+	mousePoint := input.GetMousePosition()
+	row := 0
+	for _, recipe := range cs.Recipes {
+		if !cs.IsUnlocked(recipe, inventory) {
+			continue
+		}
+		if r.CheckCollisionPointRec(mousePoint, craftButton(row)) {
+			if cs.CanCraft(recipe, inventory) {
+				cs.CraftItem(recipe, inventory)
+			}
+			return
+		}
+		row++
+	}
+
+	if r.CheckCollisionPointRec(mousePoint, craftingCloseButton) {
+		cs.IsOpen = false
 	}
 }
 
@@ -57,8 +92,13 @@ func (cs *CraftingSystem) Draw(gameFont r.Font, inventory *Inventory) {
 
 	// Draw recipes
 	y := 160
+	row := 0
 	iconSize := int32(20)
 	for _, recipe := range cs.Recipes {
+		if !cs.IsUnlocked(recipe, inventory) {
+			continue
+		}
+
 		// Draw result item icon and name
 		if texture, exists := inventory.ItemIcons[recipe.Result]; exists {
 			r.DrawTexturePro(
@@ -70,7 +110,11 @@ func (cs *CraftingSystem) Draw(gameFont r.Font, inventory *Inventory) {
 				r.White,
 			)
 		}
-		r.DrawTextEx(gameFont, recipe.Result, r.Vector2{X: 170 + float32(iconSize) + 5, Y: float32(y)}, 20, 1, r.White)
+		resultName := items.Get(recipe.Result).Name
+		if recipe.Quantity > 1 {
+			resultName = fmt.Sprintf("%s x%d", resultName, recipe.Quantity)
+		}
+		r.DrawTextEx(gameFont, resultName, r.Vector2{X: 170 + float32(iconSize) + 5, Y: float32(y)}, 20, 1, r.White)
 
 		// Get sorted material names
 		var materialNames []string
@@ -97,61 +141,77 @@ func (cs *CraftingSystem) Draw(gameFont r.Font, inventory *Inventory) {
 			}
 		}
 
-		// Draw craft button
-		craftBtn := r.Rectangle{X: 550, Y: float32(y), Width: 70, Height: 20}
-		canCraft := cs.CanCraft(recipe, inventory)
+		// Explain a missing tool or station
+		if missing := cs.missingRequirement(recipe, inventory); missing != "" {
+			r.DrawTextEx(gameFont, missing, r.Vector2{X: x, Y: float32(y)}, 16, 1, r.LightGray)
+		}
 
-		if canCraft {
+		// Draw craft button
+		craftBtn := craftButton(row)
+		if cs.CanCraft(recipe, inventory) {
 			r.DrawRectangleRec(craftBtn, r.Green)
 		} else {
 			r.DrawRectangleRec(craftBtn, r.Gray)
 		}
 		r.DrawTextEx(gameFont, "CRAFT", r.Vector2{X: craftBtn.X + 5, Y: craftBtn.Y}, 20, 1, r.White)
 
-		// Handle craft button click
-		if canCraft && r.IsMouseButtonPressed(0) {
-			mousePoint := r.GetMousePosition()
-			if r.CheckCollisionPointRec(mousePoint, craftBtn) {
-				cs.CraftItem(recipe, inventory)
-			}
-		}
-
 		y += 40
+		row++
 	}
 
 	// Draw close button
-	closeBtn := r.Rectangle{X: 350, Y: 460, Width: 100, Height: 30}
-	r.DrawRectangleRec(closeBtn, r.Gray)
+	r.DrawRectangleRec(craftingCloseButton, r.Gray)
 	r.DrawTextEx(gameFont, "Close", r.Vector2{X: 370, Y: 465}, 20, 1, r.White)
+}
 
-	if r.IsMouseButtonPressed(0) {
-		mousePoint := r.GetMousePosition()
-		if r.CheckCollisionPointRec(mousePoint, closeBtn) {
-			cs.IsOpen = false
+// IsUnlocked reports whether the player meets a recipe's unlock conditions
+func (cs *CraftingSystem) IsUnlocked(recipe Recipe, inventory *Inventory) bool {
+	if inventory.player != nil && inventory.player.Level < recipe.Unlock.Level {
+		return false
+	}
+	for _, id := range recipe.Unlock.Items {
+		if inventory.ItemCounts[id] <= 0 {
+			return false
 		}
 	}
+	if recipe.Unlock.Dimension != "" {
+		if index, exists := dimensionIndex(recipe.Unlock.Dimension); !exists || cs.Dimension < index {
+			return false
+		}
+	}
+	return true
+}
+
+// missingRequirement describes the tool or station a recipe still needs, if any
+func (cs *CraftingSystem) missingRequirement(recipe Recipe, inventory *Inventory) string {
+	if recipe.Tool != "" && inventory.ItemCounts[recipe.Tool] <= 0 {
+		return "needs " + items.Get(recipe.Tool).Name
+	}
+	if recipe.Station != "" && !cs.NearStations[recipe.Station] {
+		return "at " + recipe.Station
+	}
+	return ""
 }
 
 // CanCraft checks if a recipe can be crafted
 func (cs *CraftingSystem) CanCraft(recipe Recipe, inventory *Inventory) bool {
+	if !cs.IsUnlocked(recipe, inventory) || cs.missingRequirement(recipe, inventory) != "" {
+		return false
+	}
 	for item, needed := range recipe.Materials {
 		if inventory.ItemCounts[item] < needed {
 			return false
 		}
 	}
-	return true
+	return inventory.HasRoom(recipe.Result, recipe.Quantity)
 }
 
 // CraftItem performs the crafting operation
 func (cs *CraftingSystem) CraftItem(recipe Recipe, inventory *Inventory) {
 	// Remove materials
 	for item, count := range recipe.Materials {
-		inventory.ItemCounts[item] -= count
-		if inventory.ItemCounts[item] <= 0 {
-			delete(inventory.ItemCounts, item)
-		}
+		inventory.Remove(item, count)
 	}
-	// Add crafted item
-	inventory.Items = append(inventory.Items, recipe.Result)
-	inventory.ItemCounts[recipe.Result]++
+	// Add crafted items
+	inventory.Add(recipe.Result, recipe.Quantity)
 }
@@ -0,0 +1,72 @@
+package main
+
+import (
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+// DamageType says how damage was dealt. Targets may resist some types,
+// trees and stones only break from harvesting.
+type DamageType int
+
+const (
+	DamageEnergy  DamageType = iota // Ray gun beams
+	DamageSlash                     // Sword swings
+	DamagePierce                    // Bullets
+	DamageHarvest                   // Clicking or interacting with trees and stones
+	DamageSpell                     // Spells of every kind
+	DamageTrap                      // Traps snapping shut
+)
+
+// DamageEvent is one hit, reported by whatever dealt it and applied to its target
+type DamageEvent struct {
+	Source    interface{} // Weapon or player that dealt the hit
+	Target    Damageable
+	Amount    int32
+	Type      DamageType
+	Knockback r.Vector2 // Displacement pushed onto targets that can move
+	Crit      bool      // Set by sources that land critical hits, shown with the damage number
+	Cooldown  float32   // How long the target ignores further hits
+	Chill     float32   // How long targets that can move are slowed down
+	Unblocked bool      // Lands even during the target's cooldown, like a trap snapping shut
+}
+
+// newHit creates the event for a weapon hitting a target. Knockback pushes the
+// target away from the player.
+func newHit(source interface{}, player *Player, target Damageable, amount int32, damageType DamageType, knockback, cooldown float32) DamageEvent {
+	event := DamageEvent{
+		Source:   source,
+		Target:   target,
+		Amount:   amount,
+		Type:     damageType,
+		Cooldown: cooldown,
+	}
+
+	if knockback > 0 {
+		bounds := target.GetBounds()
+		direction := r.Vector2Normalize(r.Vector2{
+			X: bounds.X + bounds.Width/2 - (player.X + float32(player.Width)/2),
+			Y: bounds.Y + bounds.Height/2 - (player.Y + float32(player.Height)/2),
+		})
+		event.Knockback = r.Vector2Scale(direction, knockback)
+	}
+	return event
+}
+
+// damageEffect is the feedback shown when a hit of some type lands
+type damageEffect struct {
+	Color      r.Color
+	Particles  int
+	Shake      float32
+	ShakeTime  float32
+	ShowNumber bool // Float the damage above targets that do not show their own
+}
+
+// damageEffects keeps every weapon of a damage type feeling the same
+var damageEffects = map[DamageType]damageEffect{
+	DamageEnergy:  {Color: r.Yellow, Particles: 10, Shake: 3.0, ShakeTime: 0.1},
+	DamageSlash:   {Color: r.White, Particles: 10, Shake: 3.0, ShakeTime: 0.1},
+	DamagePierce:  {Color: r.Yellow, Particles: 5, Shake: 2.0, ShakeTime: 0.05},
+	DamageHarvest: {ShowNumber: true},
+	DamageSpell:   {Color: r.Purple, Particles: 8, Shake: 2.0, ShakeTime: 0.1},
+	DamageTrap:    {Color: r.Gray, Particles: 6, Shake: 1.5, ShakeTime: 0.05},
+}
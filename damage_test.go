@@ -0,0 +1,107 @@
+package main
+
+import (
+	"testing"
+
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+func TestHarvestBreaksTree(t *testing.T) {
+	g := NewHeadlessGame(NewScriptedInput(), 6)
+	defer g.Cleanup()
+	for _, item := range Query[*DroppedItem](g.world) {
+		g.world.Remove(item)
+	}
+
+	tree := NewTreeAt(g.player.X, g.player.Y, 2)
+	g.world.Add(tree)
+	g.harvest(tree)
+	g.harvest(tree)
+	for _, other := range Query[*Tree](g.world) {
+		if other == tree {
+			t.Fatalf("tree still standing with %d health", tree.Health)
+		}
+	}
+
+	logs := 0
+	for _, item := range Query[*DroppedItem](g.world) {
+		if item.Item == "strange_log" {
+			logs++
+		}
+	}
+	if logs != 1 {
+		t.Fatalf("tree dropped %d logs, want 1", logs)
+	}
+}
+
+func TestTreesOnlyBreakFromHarvesting(t *testing.T) {
+	headless = true
+	tree := NewTreeAt(0, 0, 5)
+	defer tree.Unload()
+	for _, damageType := range []DamageType{DamagePierce, DamageSlash, DamageEnergy} {
+		if tree.TakeDamage(DamageEvent{Target: tree, Amount: 1, Type: damageType}) || tree.Health != 5 {
+			t.Fatalf("damage type %d hurt a tree, health %d", damageType, tree.Health)
+		}
+	}
+	if !tree.TakeDamage(DamageEvent{Target: tree, Amount: 1, Type: DamageHarvest}) || tree.Health != 4 {
+		t.Fatalf("harvesting did not hurt a tree, health %d", tree.Health)
+	}
+}
+
+func TestDamageCooldownSwallowsHits(t *testing.T) {
+	g := NewHeadlessGame(NewScriptedInput(), 6)
+	defer g.Cleanup()
+
+	enemy := NewEnemy(0, 0, g.player, g.rng.Derive("test", 1))
+	g.world.Add(enemy)
+	health := enemy.CurrentHealth
+	g.dealDamage(DamageEvent{Target: enemy, Amount: 1, Type: DamagePierce, Cooldown: 1})
+	g.dealDamage(DamageEvent{Target: enemy, Amount: 1, Type: DamagePierce, Cooldown: 1})
+	if enemy.CurrentHealth != health-1 {
+		t.Fatalf("health %d after two quick hits, want %d", enemy.CurrentHealth, health-1)
+	}
+}
+
+func TestNewHitKnocksBack(t *testing.T) {
+	g := NewHeadlessGame(NewScriptedInput(), 6)
+	defer g.Cleanup()
+
+	player := g.player
+	center := r.Vector2{X: player.X + float32(player.Width)/2, Y: player.Y + float32(player.Height)/2}
+	target := NewDummy(center.X+50, center.Y-16)
+	defer target.Unload()
+
+	event := newHit(player, player, target, 3, DamageSlash, 10, 0.2)
+	if event.Crit || event.Amount != 3 {
+		t.Fatalf("plain hit = %+v", event)
+	}
+	if event.Knockback.X <= 0 || event.Knockback.Y != 0 {
+		t.Fatalf("knockback %v does not push away from the player", event.Knockback)
+	}
+
+	// Dashing does not change the hit
+	player.IsDashing = true
+	event = newHit(player, player, target, 3, DamageSlash, 0, 0.2)
+	if event.Crit || event.Amount != 3 {
+		t.Fatalf("dashing hit = %+v, want a plain hit", event)
+	}
+}
+
+func TestDamageTextFollowsTheClock(t *testing.T) {
+	g := NewHeadlessGame(NewScriptedInput(), 7)
+	defer g.Cleanup()
+
+	dummy := NewDummy(100, 100)
+	defer dummy.Unload()
+	dummy.TakeDamage(DamageEvent{Target: dummy, Amount: 3, Type: DamageSlash})
+	y := dummy.DamageText.Position.Y
+	for i := 0; i < 30; i++ {
+		dummy.Update(1.0 / 60)
+	}
+	if timer := dummy.DamageText.Timer; timer < 0.49 || timer > 0.51 {
+		t.Fatalf("damage text has %v left after half a second, want 0.5", timer)
+	}
+	if dummy.DamageText.Position.Y != y-30 {
+		t.Fatalf("damage text rose %v in 30 frames, want 30", y-dummy.DamageText.Position.Y)
+	}
+}
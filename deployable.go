@@ -0,0 +1,247 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+// DeployablesFile holds the definition of everything the player can place in the world
+const DeployablesFile = "assets/deployables.json"
+
+// deployables is the deployable registry shared by every subsystem
+var deployables = NewDeployableRegistry()
+
+// Deployable kinds, each goes off in its own way
+const (
+	DeployTrap = "trap" // Snaps shut on the first enemy that steps on it and holds it in place
+)
+
+// deployKinds lists the kinds deployable files may use, with the clips their sheet needs
+var deployKinds = map[string][]string{
+	DeployTrap: {"armed", "snap", "shut"},
+}
+
+// DeployableDef describes one kind of object the player places from inventory charges
+type DeployableDef struct {
+	ID      string  `json:"id"`
+	Name    string  `json:"name"`
+	Kind    string  `json:"kind"`
+	Item    string  `json:"item"`  // Item a charge is placed from, and picked back up as
+	Sheet   string  `json:"sheet"` // Animation sheet, drawn over the bounds
+	Width   float32 `json:"width"`
+	Height  float32 `json:"height"`
+	ArmTime float32 `json:"arm_time"` // Time after placing before it can go off
+	Damage  int32   `json:"damage"`
+	Root    float32 `json:"root"` // How long traps hold what they catch
+}
+
+// DeployableRegistry looks deployable definitions up by ID
+type DeployableRegistry struct {
+	defs  map[string]*DeployableDef
+	order []*DeployableDef // In file order
+}
+
+// NewDeployableRegistry creates an empty registry
+func NewDeployableRegistry() *DeployableRegistry {
+	return &DeployableRegistry{defs: make(map[string]*DeployableDef)}
+}
+
+// LoadDeployables reads and validates a deployable definition file
+func LoadDeployables(path string) (*DeployableRegistry, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var defs []*DeployableDef
+	if err := json.Unmarshal(raw, &defs); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	reg := NewDeployableRegistry()
+	for _, def := range defs {
+		if def.ID == "" {
+			return nil, fmt.Errorf("%s: deployable %q has no id", path, def.Name)
+		}
+		if _, exists := reg.defs[def.ID]; exists {
+			return nil, fmt.Errorf("%s: deployable %q is defined twice", path, def.ID)
+		}
+		if def.Name == "" {
+			def.Name = def.ID
+		}
+		if err := validateDeployable(def); err != nil {
+			return nil, fmt.Errorf("%s: deployable %q: %w", path, def.ID, err)
+		}
+		reg.defs[def.ID] = def
+		reg.order = append(reg.order, def)
+	}
+	return reg, nil
+}
+
+// validateDeployable checks a deployable's kind is known and it names a real item and sheet
+func validateDeployable(def *DeployableDef) error {
+	clips, known := deployKinds[def.Kind]
+	if !known {
+		return fmt.Errorf("unknown kind %q", def.Kind)
+	}
+	if _, exists := items.Lookup(def.Item); !exists {
+		return fmt.Errorf("placed from unknown item %q", def.Item)
+	}
+	sheet, exists := animations.Lookup(def.Sheet)
+	if !exists {
+		return fmt.Errorf("unknown sheet %q", def.Sheet)
+	}
+	for _, clip := range clips {
+		if _, exists := sheet.Clips[clip]; !exists {
+			return fmt.Errorf("sheet %q has no %q clip", def.Sheet, clip)
+		}
+	}
+	if def.Width <= 0 || def.Height <= 0 {
+		return fmt.Errorf("size must be positive")
+	}
+	if def.ArmTime < 0 {
+		return fmt.Errorf("arm time cannot be negative")
+	}
+	if def.Kind == DeployTrap && (def.Damage <= 0 || def.Root <= 0) {
+		return fmt.Errorf("traps need a positive damage and root time")
+	}
+	return nil
+}
+
+// Lookup returns the definition of a deployable, if it exists
+func (reg *DeployableRegistry) Lookup(id string) (*DeployableDef, bool) {
+	def, exists := reg.defs[id]
+	return def, exists
+}
+
+// All returns every deployable in file order
+func (reg *DeployableRegistry) All() []*DeployableDef {
+	return reg.order
+}
+
+// loadDeployables replaces the registry with the deployable file, keeping the old one if it is broken
+func loadDeployables(path string) {
+	loaded, err := LoadDeployables(path)
+	if err != nil {
+		fmt.Println("Warning: Could not load deployables:", err)
+		return
+	}
+	deployables = loaded
+}
+
+// DeployState is where a placed deployable is in its life
+type DeployState int
+
+const (
+	DeployArming DeployState = iota // Just placed, nothing sets it off yet
+	DeployArmed                     // Goes off on the next enemy that touches it
+	DeploySprung                    // Went off, and stays shut until picked back up
+)
+
+// Deployable is an object the player placed in the world
+type Deployable struct {
+	Def      *DeployableDef
+	X        float32
+	Y        float32
+	State    DeployState
+	ArmTimer float32 // Time left arming
+	Caught   *Enemy  // Enemy a sprung trap holds, until it breaks free or dies
+	Anim     *Animator
+}
+
+// NewDeployable places a deployable with its top left corner at a point, starting to arm
+func NewDeployable(def *DeployableDef, x, y float32) *Deployable {
+	d := &Deployable{
+		Def:      def,
+		X:        x,
+		Y:        y,
+		ArmTimer: def.ArmTime,
+		Anim:     NewAnimator(def.Sheet),
+	}
+	d.Anim.Restart("armed")
+	if d.ArmTimer <= 0 {
+		d.State = DeployArmed
+	}
+	return d
+}
+
+// Update counts down the arming and lets go of caught enemies that broke free
+func (d *Deployable) Update(deltaTime float32) {
+	switch d.State {
+	case DeployArming:
+		d.ArmTimer -= deltaTime
+		if d.ArmTimer <= 0 {
+			d.State = DeployArmed
+		}
+	case DeploySprung:
+		if d.Caught != nil && (d.Caught.IsDead() || !d.Caught.Rooted()) {
+			d.Caught = nil
+		}
+	}
+	d.Anim.Update(deltaTime)
+}
+
+// Catches reports whether an enemy sets the deployable off. Ghosts drift over traps.
+func (d *Deployable) Catches(enemy *Enemy) bool {
+	if d.State != DeployArmed || enemy.IsDead() {
+		return false
+	}
+	return enemy.Ghost == nil || !enemy.Ghost.Resists(DamageTrap)
+}
+
+// Spring snaps the trap shut on an enemy, rooting it, and returns the hit it deals.
+// The hit lands even if a weapon struck the enemy a moment before.
+func (d *Deployable) Spring(enemy *Enemy) DamageEvent {
+	d.State = DeploySprung
+	d.Caught = enemy
+	enemy.Snare(d.Def.Root)
+	d.Anim.Restart("snap")
+	return DamageEvent{
+		Source:    d,
+		Target:    enemy,
+		Amount:    d.Def.Damage,
+		Type:      DamageTrap,
+		Unblocked: true,
+	}
+}
+
+// CanPickUp reports whether the player may take the deployable back, traps holding an enemy stay put
+func (d *Deployable) CanPickUp() bool {
+	return d.Caught == nil
+}
+
+// Draw renders the deployable, faint while it arms
+func (d *Deployable) Draw(debug bool) {
+	tint := r.White
+	if d.State == DeployArming {
+		tint = r.ColorAlpha(tint, 0.5)
+	}
+	d.Anim.Draw(d.GetBounds(), r.Vector2{}, 0, tint)
+
+	if debug {
+		color := r.Green
+		if d.State == DeployArmed {
+			color = r.Red
+		}
+		bounds := d.GetBounds()
+		r.DrawRectangleLines(int32(bounds.X), int32(bounds.Y), int32(bounds.Width), int32(bounds.Height), color)
+	}
+}
+
+// Unload frees the deployable's texture
+func (d *Deployable) Unload() {
+	d.Anim.Unload()
+}
+
+// GetBounds returns the deployable's bounding rectangle
+func (d *Deployable) GetBounds() r.Rectangle {
+	return r.Rectangle{X: d.X, Y: d.Y, Width: d.Def.Width, Height: d.Def.Height}
+}
+
+// DrawLayer puts deployables on the ground, under whatever steps on them
+func (d *Deployable) DrawLayer() Layer {
+	return LayerGround
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+// armedTrap places a bear trap that is ready to go off
+func armedTrap(g *Game, x, y float32) *Deployable {
+	def, _ := deployables.Lookup("bear_trap")
+	trap := NewDeployable(def, x, y)
+	trap.ArmTimer = 0
+	trap.State = DeployArmed
+	g.world.Add(trap)
+	return trap
+}
+
+func TestDeployableFileLoads(t *testing.T) {
+	loadDataFiles()
+	reg, err := LoadDeployables(DeployablesFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trap, exists := reg.Lookup("bear_trap")
+	if !exists || trap.Kind != DeployTrap || trap.Item != "bear_trap" {
+		t.Fatalf("bear trap = %+v", trap)
+	}
+}
+
+func TestLoadDeployablesRejectsBadFiles(t *testing.T) {
+	loadDataFiles()
+	files := map[string]string{
+		"missing id":      `[{"kind": "trap", "item": "bear_trap", "sheet": "bear_trap", "width": 16, "height": 16, "damage": 1, "root": 1}]`,
+		"unknown kind":    `[{"id": "a", "kind": "mine", "item": "bear_trap", "sheet": "bear_trap", "width": 16, "height": 16, "damage": 1, "root": 1}]`,
+		"unknown item":    `[{"id": "a", "kind": "trap", "item": "nope", "sheet": "bear_trap", "width": 16, "height": 16, "damage": 1, "root": 1}]`,
+		"unknown sheet":   `[{"id": "a", "kind": "trap", "item": "bear_trap", "sheet": "nope", "width": 16, "height": 16, "damage": 1, "root": 1}]`,
+		"missing clips":   `[{"id": "a", "kind": "trap", "item": "bear_trap", "sheet": "dummy", "width": 16, "height": 16, "damage": 1, "root": 1}]`,
+		"no size":         `[{"id": "a", "kind": "trap", "item": "bear_trap", "sheet": "bear_trap", "damage": 1, "root": 1}]`,
+		"negative arm":    `[{"id": "a", "kind": "trap", "item": "bear_trap", "sheet": "bear_trap", "width": 16, "height": 16, "arm_time": -1, "damage": 1, "root": 1}]`,
+		"lets go at once": `[{"id": "a", "kind": "trap", "item": "bear_trap", "sheet": "bear_trap", "width": 16, "height": 16, "damage": 1}]`,
+		"duplicate": `[{"id": "a", "kind": "trap", "item": "bear_trap", "sheet": "bear_trap", "width": 16, "height": 16, "damage": 1, "root": 1},
+			{"id": "a", "kind": "trap", "item": "bear_trap", "sheet": "bear_trap", "width": 16, "height": 16, "damage": 1, "root": 1}]`,
+	}
+	for name, content := range files {
+		if _, err := LoadDeployables(writeTestFile(t, "deployables.json", content)); err == nil {
+			t.Errorf("%s: loaded without an error", name)
+		}
+	}
+}
+
+func TestPlacingAndPickingUpTraps(t *testing.T) {
+	input := NewScriptedInput()
+	g := NewHeadlessGame(input, 21)
+	defer g.Cleanup()
+	clearObstacles(g)
+	g.player.X, g.player.Y = 300, 300
+
+	press := func(key int32) {
+		input.PressKey(key)
+		g.Step(1.0 / 60)
+		input.ReleaseKey(key)
+	}
+
+	// Nothing is placed without a charge
+	press(r.KeyT)
+	if len(Query[*Deployable](g.world)) != 0 {
+		t.Fatal("trap placed without a charge")
+	}
+
+	g.inventory.Add("bear_trap", 2)
+	press(r.KeyT)
+	traps := Query[*Deployable](g.world)
+	if len(traps) != 1 || g.inventory.ItemCounts["bear_trap"] != 1 || traps[0].State != DeployArming {
+		t.Fatalf("placed %d traps, %d charges left", len(traps), g.inventory.ItemCounts["bear_trap"])
+	}
+
+	// Traps do not stack on one spot
+	press(r.KeyT)
+	if len(Query[*Deployable](g.world)) != 1 || g.inventory.ItemCounts["bear_trap"] != 1 {
+		t.Fatal("second trap placed on top of the first")
+	}
+
+	press(r.KeyF)
+	if len(Query[*Deployable](g.world)) != 0 || g.inventory.ItemCounts["bear_trap"] != 2 {
+		t.Fatal("trap was not picked back up")
+	}
+
+	// Traps left behind come along to the next dimension
+	press(r.KeyT)
+	g.enterDimension(1)
+	if g.inventory.ItemCounts["bear_trap"] != 2 {
+		t.Fatalf("%d charges after moving on, want 2", g.inventory.ItemCounts["bear_trap"])
+	}
+}
+
+func TestInteractPicksUpOneTrapAtATime(t *testing.T) {
+	input := NewScriptedInput()
+	g := NewHeadlessGame(input, 26)
+	defer g.Cleanup()
+	clearObstacles(g)
+	g.player.X, g.player.Y = 300, 300
+
+	press := func(key int32) {
+		input.PressKey(key)
+		g.Step(1.0 / 60)
+		input.ReleaseKey(key)
+	}
+
+	bounds := g.player.GetBounds()
+	armedTrap(g, bounds.X, bounds.Y+bounds.Height-16)
+	far := armedTrap(g, bounds.X+bounds.Width+doorReach-8, bounds.Y)
+	door := NewDoor(bounds.X-doorReach, bounds.Y, 8, bounds.Height, false)
+	g.world.Add(door)
+
+	// Opening a door leaves the traps in reach alone
+	press(r.KeyF)
+	if !door.Open || len(Query[*Deployable](g.world)) != 2 {
+		t.Fatalf("door open %v with %d traps left", door.Open, len(Query[*Deployable](g.world)))
+	}
+	g.world.Remove(door)
+
+	press(r.KeyF)
+	traps := Query[*Deployable](g.world)
+	if len(traps) != 1 || traps[0] != far || g.inventory.ItemCounts["bear_trap"] != 1 {
+		t.Fatalf("%d traps left after one press, want only the far one", len(traps))
+	}
+	press(r.KeyF)
+	if len(Query[*Deployable](g.world)) != 0 || g.inventory.ItemCounts["bear_trap"] != 2 {
+		t.Fatal("second press did not pick up the other trap")
+	}
+}
+
+func TestBearTrapSnaresFirstEnemy(t *testing.T) {
+	g := NewHeadlessGame(NewScriptedInput(), 22)
+	defer g.Cleanup()
+	clearObstacles(g)
+	g.player.X, g.player.Y = 300, 300
+
+	// Enemies walk over traps that are still arming
+	def, _ := deployables.Lookup("bear_trap")
+	trap := NewDeployable(def, 500, 300)
+	g.world.Add(trap)
+	first := g.spawnEnemy(r.Vector2{X: 500, Y: 290}, Spawn{Kind: "grunt"}).(*Enemy)
+	g.Step(1.0 / 60)
+	if trap.State != DeployArming || first.Rooted() {
+		t.Fatal("arming trap went off")
+	}
+
+	for i := 0; i < 60; i++ {
+		g.Step(1.0 / 60)
+	}
+	if trap.State != DeployArmed {
+		t.Fatal("trap did not arm")
+	}
+
+	// Only one of two enemies stepping in is caught
+	first.X, first.Y = 500, 290
+	g.world.Moved(first)
+	second := g.spawnEnemy(r.Vector2{X: 502, Y: 290}, Spawn{Kind: "grunt"}).(*Enemy)
+	g.Step(1.0 / 60)
+	if trap.State != DeploySprung || trap.Caught == nil {
+		t.Fatal("trap did not spring")
+	}
+	caught, free := first, second
+	if trap.Caught == second {
+		caught, free = second, first
+	}
+	if !caught.Rooted() || free.Rooted() || caught.CurrentHealth == caught.MaxHealth {
+		t.Fatalf("caught enemy rooted %v at %d health, free enemy rooted %v", caught.Rooted(), caught.CurrentHealth, free.Rooted())
+	}
+
+	// The caught enemy holds still and cannot be taken back with the trap
+	x, y := caught.X, caught.Y
+	for i := 0; i < 30; i++ {
+		g.Step(1.0 / 60)
+	}
+	if caught.X != x || caught.Y != y {
+		t.Fatalf("rooted enemy moved from %v,%v to %v,%v", x, y, caught.X, caught.Y)
+	}
+	if trap.CanPickUp() {
+		t.Fatal("trap holding an enemy can be picked up")
+	}
+
+	for i := 0; i < int(def.Root*60); i++ {
+		g.Step(1.0 / 60)
+	}
+	if caught.Rooted() || trap.Caught != nil || caught.X == x && caught.Y == y {
+		t.Fatal("enemy did not break free once the root ran out")
+	}
+}
+
+func TestTrapsHurtEnemiesHitMomentsBefore(t *testing.T) {
+	g := NewHeadlessGame(NewScriptedInput(), 25)
+	defer g.Cleanup()
+	clearObstacles(g)
+
+	trap := armedTrap(g, 500, 300)
+	enemy := g.spawnEnemy(r.Vector2{X: 500, Y: 290}, Spawn{Kind: "grunt"}).(*Enemy)
+	enemy.DamageCooldown = 0.3
+	g.Step(1.0 / 60)
+	if trap.Caught != enemy || enemy.CurrentHealth == enemy.MaxHealth {
+		t.Fatalf("trap caught %v, enemy at %d of %d health", trap.Caught, enemy.CurrentHealth, enemy.MaxHealth)
+	}
+	if enemy.DamageCooldown <= 0 {
+		t.Fatal("trap cut the enemy's hit cooldown short")
+	}
+}
+
+func TestGhostsDriftOverTraps(t *testing.T) {
+	g := NewHeadlessGame(NewScriptedInput(), 23)
+	defer g.Cleanup()
+	clearObstacles(g)
+
+	trap := armedTrap(g, 500, 300)
+	ghost := g.spawnEnemy(r.Vector2{X: 500, Y: 290}, Spawn{Kind: "ghost"}).(*Enemy)
+	g.Step(1.0 / 60)
+	if trap.State != DeployArmed || ghost.Rooted() {
+		t.Fatal("ghost set the trap off")
+	}
+}
+
+func TestDeployablesSurviveSaving(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "savegame.json")
+	g := NewHeadlessGame(NewScriptedInput(), 24)
+	defer g.Cleanup()
+
+	def, _ := deployables.Lookup("bear_trap")
+	g.world.Add(NewDeployable(def, 100, 100))
+	sprung := armedTrap(g, 200, 100)
+	sprung.State = DeploySprung
+	if err := g.SaveGame(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := NewHeadlessGame(NewScriptedInput(), 1)
+	defer loaded.Cleanup()
+	if err := loaded.LoadGame(path); err != nil {
+		t.Fatal(err)
+	}
+	traps := Query[*Deployable](loaded.world)
+	if len(traps) != 2 {
+		t.Fatalf("got %d traps back, want 2", len(traps))
+	}
+	if traps[0].State != DeployArming || traps[0].ArmTimer != def.ArmTime || traps[1].State != DeploySprung {
+		t.Fatalf("traps came back %v with %v left to arm, and %v", traps[0].State, traps[0].ArmTimer, traps[1].State)
+	}
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+// DimensionsFile lists the pocket dimensions in the order the player ascends through them
+const DimensionsFile = "assets/dimensions.json"
+
+// dimensions is the ascent shared by every run, the first one is where runs start
+var dimensions = defaultDimensions()
+
+// Goal types
+const (
+	GoalKills   = "kills"   // Defeat Amount enemies
+	GoalSurvive = "survive" // Stay alive for Amount seconds
+	GoalCollect = "collect" // Hold Amount of Item
+)
+
+// DimensionGoal is what the player must do before the exit to the next dimension opens
+type DimensionGoal struct {
+	Type   string `json:"type"`
+	Amount int    `json:"amount"`
+	Item   string `json:"item,omitempty"`
+}
+
+// RosterEntry is one kind of enemy a dimension's portals spawn, picked by weight
+type RosterEntry struct {
+	Kind   string `json:"kind"`
+	Weight int    `json:"weight"`
+}
+
+// Dimension describes how one pocket dimension is generated and completed
+type Dimension struct {
+	ID             string        `json:"id"`
+	Name           string        `json:"name"`
+	Width          int32         `json:"width"`
+	Height         int32         `json:"height"`
+	Background     [3]uint8      `json:"background"`
+	Grass          int           `json:"grass"`
+	Trees          int           `json:"trees"`
+	Stones         int           `json:"stones"`
+	Rooms          int           `json:"rooms"`           // Walled rooms with loot behind their door
+	Map            string        `json:"map,omitempty"`   // Tiled map to use instead of a generated one, its size replaces width and height
+	GoldenChance   float32       `json:"golden_chance"`   // Chance for each stone to be golden
+	PortalInterval float32       `json:"portal_interval"` // Seconds between the portals of a wave
+	EnemyHealth    float32       `json:"enemy_health"`    // Multiplies the health of every enemy
+	EnemySpeed     float32       `json:"enemy_speed"`     // Multiplies the speed of every enemy
+	EliteChance    float32       `json:"elite_chance"`    // Chance for an enemy of the first wave to be an elite
+	Enemies        []RosterEntry `json:"enemies"`
+	Goal           DimensionGoal `json:"goal"`
+	Boss           *BossDef      `json:"boss,omitempty"` // Fought once the goal is met, before the exit opens
+	tilemap        *Tilemap      // Loaded from Map
+}
+
+// enemyKind is an enemy a roster may name
+type enemyKind struct {
+	Cost int // Threat budget a wave spends on one
+	New  func(x, y float32, target *Player, rng *rand.Rand) Damageable
+}
+
+// enemyKinds lists the enemies rosters may name
+var enemyKinds = map[string]enemyKind{
+	"grunt": {Cost: 1, New: func(x, y float32, target *Player, rng *rand.Rand) Damageable {
+		return NewEnemy(x, y, target, rng)
+	}},
+	"shooter": {Cost: 2, New: func(x, y float32, target *Player, rng *rand.Rand) Damageable {
+		return NewShooter(x, y, target, rng)
+	}},
+	"ghost": {Cost: 2, New: func(x, y float32, target *Player, rng *rand.Rand) Damageable {
+		return NewGhost(x, y, target, rng)
+	}},
+}
+
+// defaultDimensions is the single field the game had before dimensions were loaded from a file
+func defaultDimensions() []*Dimension {
+	return []*Dimension{{
+		ID:             "field",
+		Name:           "Field",
+		Width:          GameWidth,
+		Height:         GameHeight,
+		Background:     [3]uint8{83, 114, 133},
+		Grass:          20,
+		Trees:          15,
+		Stones:         25,
+		GoldenChance:   0.2,
+		PortalInterval: 10,
+		EnemyHealth:    1,
+		EnemySpeed:     1,
+		Enemies:        []RosterEntry{{Kind: "grunt", Weight: 1}},
+		Goal:           DimensionGoal{Type: GoalKills, Amount: 20},
+	}}
+}
+
+// LoadDimensions reads and validates a dimension file
+func LoadDimensions(path string) ([]*Dimension, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var loaded []*Dimension
+	if err := json.Unmarshal(raw, &loaded); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if len(loaded) == 0 {
+		return nil, fmt.Errorf("%s: no dimensions", path)
+	}
+
+	seen := make(map[string]bool)
+	for _, dimension := range loaded {
+		if dimension.ID == "" {
+			return nil, fmt.Errorf("%s: dimension %q has no id", path, dimension.Name)
+		}
+		if seen[dimension.ID] {
+			return nil, fmt.Errorf("%s: dimension %q is defined twice", path, dimension.ID)
+		}
+		seen[dimension.ID] = true
+
+		if dimension.Name == "" {
+			dimension.Name = dimension.ID
+		}
+		if dimension.Map != "" {
+			tilemap, err := LoadTilemap(dimension.Map)
+			if err != nil {
+				return nil, fmt.Errorf("%s: dimension %q: %w", path, dimension.ID, err)
+			}
+			dimension.tilemap = tilemap
+			dimension.Width = tilemap.Width
+			dimension.Height = tilemap.Height
+		}
+		if dimension.Width == 0 {
+			dimension.Width = GameWidth
+		}
+		if dimension.Height == 0 {
+			dimension.Height = GameHeight
+		}
+		if dimension.PortalInterval == 0 {
+			dimension.PortalInterval = 10
+		}
+		if dimension.EnemyHealth == 0 {
+			dimension.EnemyHealth = 1
+		}
+		if dimension.EnemySpeed == 0 {
+			dimension.EnemySpeed = 1
+		}
+		if dimension.Boss != nil && dimension.Boss.Speed == 0 {
+			dimension.Boss.Speed = 60
+		}
+		if err := validateDimension(dimension); err != nil {
+			return nil, fmt.Errorf("%s: dimension %q: %w", path, dimension.ID, err)
+		}
+	}
+	return loaded, nil
+}
+
+// validateDimension checks a dimension can be generated and completed
+func validateDimension(dimension *Dimension) error {
+	if dimension.Width < 400 || dimension.Height < 300 {
+		return fmt.Errorf("must be at least 400x300, the area the camera shows")
+	}
+	if dimension.Grass < 0 || dimension.Trees < 0 || dimension.Stones < 0 || dimension.Rooms < 0 {
+		return fmt.Errorf("resource counts must not be negative")
+	}
+	if dimension.GoldenChance < 0 || dimension.GoldenChance > 1 {
+		return fmt.Errorf("golden chance must be between 0 and 1")
+	}
+	if dimension.EliteChance < 0 || dimension.EliteChance > 1 {
+		return fmt.Errorf("elite chance must be between 0 and 1")
+	}
+	if dimension.PortalInterval < 0 || dimension.EnemyHealth < 0 || dimension.EnemySpeed < 0 {
+		return fmt.Errorf("portal interval and enemy scaling must be positive")
+	}
+	if len(dimension.Enemies) == 0 {
+		return fmt.Errorf("enemy roster is empty")
+	}
+	for _, entry := range dimension.Enemies {
+		if _, exists := enemyKinds[entry.Kind]; !exists {
+			return fmt.Errorf("unknown enemy kind %q", entry.Kind)
+		}
+		if entry.Weight <= 0 {
+			return fmt.Errorf("enemy %q needs a positive weight", entry.Kind)
+		}
+	}
+
+	switch dimension.Goal.Type {
+	case GoalKills, GoalSurvive:
+	case GoalCollect:
+		if _, exists := items.Lookup(dimension.Goal.Item); !exists {
+			return fmt.Errorf("goal collects unknown item %q", dimension.Goal.Item)
+		}
+	default:
+		return fmt.Errorf("unknown goal %q", dimension.Goal.Type)
+	}
+	if dimension.Goal.Amount <= 0 {
+		return fmt.Errorf("goal amount must be positive")
+	}
+
+	if dimension.Boss != nil {
+		if err := validateBoss(dimension.Boss); err != nil {
+			return fmt.Errorf("boss: %w", err)
+		}
+	}
+	return nil
+}
+
+// validateBoss checks a boss can be fought and only drops known items
+func validateBoss(boss *BossDef) error {
+	if boss.Name == "" {
+		return fmt.Errorf("has no name")
+	}
+	if boss.Health <= 0 {
+		return fmt.Errorf("health must be positive")
+	}
+	if boss.Speed < 0 {
+		return fmt.Errorf("speed must not be negative")
+	}
+	if len(boss.Loot) == 0 {
+		return fmt.Errorf("loot table is empty")
+	}
+	for _, drop := range boss.Loot {
+		if _, exists := items.Lookup(drop.Item); !exists {
+			return fmt.Errorf("loot names unknown item %q", drop.Item)
+		}
+		if drop.Min < 1 || drop.Max < drop.Min {
+			return fmt.Errorf("loot %q needs 1 <= min <= max", drop.Item)
+		}
+	}
+	return nil
+}
+
+// loadDimensions replaces the ascent with the dimension file, keeping the old one if it is broken
+func loadDimensions(path string) {
+	loaded, err := LoadDimensions(path)
+	if err != nil {
+		fmt.Println("Warning: Could not load dimensions:", err)
+		return
+	}
+	dimensions = loaded
+}
+
+// dimensionIndex finds a dimension by ID
+func dimensionIndex(id string) (int, bool) {
+	for i, dimension := range dimensions {
+		if dimension.ID == id {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// BackgroundColor returns the color the dimension is cleared with
+func (d *Dimension) BackgroundColor() r.Color {
+	return r.Color{R: d.Background[0], G: d.Background[1], B: d.Background[2], A: 255}
+}
+
+// pickWeighted chooses a kind from roster entries by weight
+func pickWeighted(entries []RosterEntry, rng *rand.Rand) string {
+	total := 0
+	for _, entry := range entries {
+		total += entry.Weight
+	}
+	roll := rng.Intn(total)
+	for _, entry := range entries {
+		if roll < entry.Weight {
+			return entry.Kind
+		}
+		roll -= entry.Weight
+	}
+	return entries[0].Kind
+}
+
+// DimensionProgress tracks how far the player got towards a dimension's goal
+type DimensionProgress struct {
+	Kills     int     `json:"kills"`
+	Time      float32 `json:"time"`
+	Complete  bool    `json:"complete"`
+	BossFight bool    `json:"boss_fight,omitempty"` // The goal is met and the boss is still alive
+}
+
+// GoalProgress returns how much of the goal is done and how much is needed
+func (d *Dimension) GoalProgress(progress DimensionProgress, inventory *Inventory) (int, int) {
+	done := 0
+	switch d.Goal.Type {
+	case GoalKills:
+		done = progress.Kills
+	case GoalSurvive:
+		done = int(progress.Time)
+	case GoalCollect:
+		done = inventory.ItemCounts[d.Goal.Item]
+	}
+	if done > d.Goal.Amount {
+		done = d.Goal.Amount
+	}
+	return done, d.Goal.Amount
+}
+
+// GoalText describes the goal and how far along it is for the UI
+func (d *Dimension) GoalText(progress DimensionProgress, inventory *Inventory) string {
+	done, needed := d.GoalProgress(progress, inventory)
+	switch d.Goal.Type {
+	case GoalKills:
+		return fmt.Sprintf("Defeat enemies %d/%d", done, needed)
+	case GoalSurvive:
+		return fmt.Sprintf("Survive %ds/%ds", done, needed)
+	case GoalCollect:
+		return fmt.Sprintf("Collect %s %d/%d", items.Get(d.Goal.Item).Name, done, needed)
+	}
+	return ""
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDimensionFileLoads(t *testing.T) {
+	loadDataFiles()
+	loaded, err := LoadDimensions(DimensionsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 6 || loaded[0].ID != "tree" {
+		t.Fatalf("got %d dimensions starting with %q", len(loaded), loaded[0].ID)
+	}
+}
+
+func TestLoadDimensionsRejectsUnknownEnemy(t *testing.T) {
+	loadDataFiles()
+	path := writeTestFile(t, "dimensions.json",
+		`[{"id": "a", "enemies": [{"kind": "dragon", "weight": 1}], "goal": {"type": "kills", "amount": 1}}]`)
+	if _, err := LoadDimensions(path); err == nil {
+		t.Fatal("unknown enemy kind loaded without an error")
+	}
+}
+
+func TestDimensionProgression(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "savegame.json")
+	g := NewHeadlessGame(NewScriptedInput(), 9)
+	defer g.Cleanup()
+	if g.currentDimension().ID != "tree" || len(Query[*Tree](g.world)) != g.currentDimension().Trees {
+		t.Fatalf("started in %q with %d trees", g.currentDimension().ID, len(Query[*Tree](g.world)))
+	}
+
+	// Meeting the goal brings out the boss, beating it opens the exit
+	g.inventory.Add("strange_log", 5)
+	g.Step(1.0 / 60)
+	if g.boss == nil || len(Query[*ExitPortal](g.world)) != 0 {
+		t.Fatal("goal met without a boss fight")
+	}
+	drops := len(Query[*DroppedItem](g.world))
+	g.boss.Health = 0
+	g.destroy(g.boss)
+	if len(Query[*DroppedItem](g.world)) < drops+6 || g.boss != nil {
+		t.Fatal("boss dropped no loot")
+	}
+	g.Step(1.0 / 60)
+	exits := Query[*ExitPortal](g.world)
+	if !g.progress.Complete || len(exits) != 1 {
+		t.Fatal("exit did not open")
+	}
+	if err := g.SaveGame(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// Stepping through carries the inventory over into a fresh dimension
+	g.inventory.Add("pickaxe", 1)
+	g.player.X, g.player.Y = exits[0].X, exits[0].Y
+	g.Step(1.0 / 60)
+	if g.currentDimension().ID != "stone" || g.player.GameWidth != g.currentDimension().Width {
+		t.Fatalf("moved on to %q", g.currentDimension().ID)
+	}
+	if g.inventory.ItemCounts["pickaxe"] != 1 {
+		t.Fatal("inventory not carried over")
+	}
+	if len(Query[*ExitPortal](g.world)) != 0 || g.progress.Complete {
+		t.Fatal("progress carried over")
+	}
+
+	// The stone dimension counts kills
+	for i := 0; i < 15; i++ {
+		enemy := NewEnemy(0, 0, g.player, g.rng.Derive("test", i))
+		g.world.Add(enemy)
+		g.destroy(enemy)
+	}
+	g.Step(1.0 / 60)
+	if !g.progress.Complete || g.boss == nil {
+		t.Fatalf("goal not met after %d kills", g.progress.Kills)
+	}
+
+	// Loading goes back to the dimension the save was made in
+	if err := g.LoadGame(path); err != nil {
+		t.Fatal(err)
+	}
+	if g.currentDimension().ID != "tree" || len(Query[*ExitPortal](g.world)) != 1 {
+		t.Fatalf("loaded into %q", g.currentDimension().ID)
+	}
+}
+
+func TestCrowdedDimensionLeavesOutWhatDoesNotFit(t *testing.T) {
+	g := NewHeadlessGame(NewScriptedInput(), 11)
+	defer g.Cleanup()
+	defer func(loaded []*Dimension) { dimensions = loaded }(dimensions)
+
+	crowded := *dimensions[0]
+	crowded.Width, crowded.Height = 400, 400
+	crowded.Trees, crowded.Stones, crowded.Rooms = 500, 500, 0
+	dimensions = []*Dimension{&crowded}
+	g.enterDimension(0)
+
+	trees, stones := len(Query[*Tree](g.world)), len(Query[*Stone](g.world))
+	if trees == 0 || trees >= crowded.Trees || stones >= crowded.Stones {
+		t.Fatalf("placed %d trees and %d stones on a map with room for a few", trees, stones)
+	}
+}
@@ -8,25 +8,23 @@ import (
 
 // DroppedItem represents an item that can be picked up in the game
 type DroppedItem struct {
-	X         float32
-	Y         float32
-	Width     int32
-	Height    int32
-	Texture   r.Texture2D
-	Name      string
-	ImagePath string
+	X       float32
+	Y       float32
+	Width   int32
+	Height  int32
+	Texture r.Texture2D
+	Item    string // ID in the item registry
 }
 
 // NewDroppedItem creates a new dropped item instance
-func NewDroppedItem(x, y float32, imagePath, name string) *DroppedItem {
+func NewDroppedItem(x, y float32, item string) *DroppedItem {
 	return &DroppedItem{
-		X:         x,
-		Y:         y,
-		Width:     16,
-		Height:    16,
-		Texture:   r.LoadTexture(imagePath),
-		Name:      name,
-		ImagePath: imagePath,
+		X:       x,
+		Y:       y,
+		Width:   16,
+		Height:  16,
+		Texture: loadTexture(items.Get(item).Icon),
+		Item:    item,
 	}
 }
 
@@ -65,5 +63,15 @@ func (d *DroppedItem) CheckCollision(playerBounds r.Rectangle) bool {
 
 // Unload frees the texture from memory
 func (d *DroppedItem) Unload() {
-	r.UnloadTexture(d.Texture)
+	unloadTexture(d.Texture)
+}
+
+// GetBounds returns the item's bounding rectangle
+func (d *DroppedItem) GetBounds() r.Rectangle {
+	return r.Rectangle{
+		X:      d.X,
+		Y:      d.Y,
+		Width:  float32(d.Width),
+		Height: float32(d.Height),
+	}
 }
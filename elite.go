@@ -0,0 +1,226 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Affix is a special trait an elite enemy rolls
+type Affix string
+
+const (
+	AffixShielded  Affix = "shielded"   // A shield soaks up damage before health
+	AffixFast      Affix = "fast"       // Moves much faster
+	AffixSplitting Affix = "splitting"  // Splits into two weaker enemies on death
+	AffixFireTrail Affix = "fire_trail" // Leaves burning ground behind
+)
+
+// allAffixes lists every affix in the order they are rolled from
+var allAffixes = []Affix{AffixShielded, AffixFast, AffixSplitting, AffixFireTrail}
+
+// affixColors are the aura colors of elites, by their first affix
+var affixColors = map[Affix]r.Color{
+	AffixShielded:  r.SkyBlue,
+	AffixFast:      r.Lime,
+	AffixSplitting: r.Magenta,
+	AffixFireTrail: r.Orange,
+}
+
+// Elite tuning
+const (
+	eliteHealthMultiplier = 2.5
+	eliteSpeedMultiplier  = 1.2
+	eliteFastMultiplier   = 1.5
+	eliteScale            = 1.25
+	eliteShieldShare      = 0.5 // Shield as a share of the elite's max health
+	eliteExperience       = 30
+	affixExperience       = 10 // Extra experience per affix
+	enemyExperience       = 10
+	splitCount            = 2
+	fireTrailInterval     = 0.3
+	fireLife              = 3.0
+	fireRadius            = 8
+	fireDamage            = 5
+)
+
+// eliteLoot is dropped by every elite on top of the usual drops
+var eliteLoot = []LootDrop{
+	{Item: "goodie_bag", Min: 1, Max: 2},
+	{Item: "golden_nugget", Min: 1, Max: 3},
+}
+
+// EliteTraits is the state of an elite enemy
+type EliteTraits struct {
+	Affixes    []Affix
+	Shield     int32
+	MaxShield  int32
+	TrailTimer float32
+	Aura       r.Color
+	fires      []r.Vector2 // Burning spots waiting for the game to place them
+}
+
+// RollAffixes picks count different affixes
+func RollAffixes(count int, rng *rand.Rand) []Affix {
+	if count > len(allAffixes) {
+		count = len(allAffixes)
+	}
+	order := rng.Perm(len(allAffixes))
+	affixes := make([]Affix, 0, count)
+	for _, i := range order[:count] {
+		affixes = append(affixes, allAffixes[i])
+	}
+	return affixes
+}
+
+// MakeElite turns an enemy into an elite with the given affixes
+func MakeElite(e *Enemy, affixes []Affix) {
+	e.MaxHealth = int32(math.Ceil(float64(e.MaxHealth) * eliteHealthMultiplier))
+	e.CurrentHealth = e.MaxHealth
+	e.Speed *= eliteSpeedMultiplier
+	e.Scale = eliteScale
+	e.Width = int32(float32(e.Width) * eliteScale)
+	e.Height = int32(float32(e.Height) * eliteScale)
+	e.DropChance = 1.0
+	e.Steering.Radius *= eliteScale
+
+	elite := &EliteTraits{Affixes: affixes, Aura: affixColors[affixes[0]]}
+	if elite.Has(AffixShielded) {
+		elite.MaxShield = int32(math.Ceil(float64(e.MaxHealth) * eliteShieldShare))
+		elite.Shield = elite.MaxShield
+	}
+	if elite.Has(AffixFast) {
+		e.Speed *= eliteFastMultiplier
+	}
+	e.Elite = elite
+}
+
+// Has reports whether the elite rolled an affix
+func (elite *EliteTraits) Has(affix Affix) bool {
+	for _, a := range elite.Affixes {
+		if a == affix {
+			return true
+		}
+	}
+	return false
+}
+
+// Absorb takes as much of the damage as the shield can and returns what is left
+func (elite *EliteTraits) Absorb(damage int32) int32 {
+	if elite.Shield >= damage {
+		elite.Shield -= damage
+		return 0
+	}
+	damage -= elite.Shield
+	elite.Shield = 0
+	return damage
+}
+
+// updateElite drops fire behind elites with a fire trail
+func (e *Enemy) updateElite(deltaTime float32) {
+	elite := e.Elite
+	if !elite.Has(AffixFireTrail) {
+		return
+	}
+	elite.TrailTimer -= deltaTime
+	if elite.TrailTimer <= 0 {
+		elite.TrailTimer = fireTrailInterval
+		elite.fires = append(elite.fires, r.Vector2{
+			X: e.X + float32(e.Width)/2,
+			Y: e.Y + float32(e.Height),
+		})
+	}
+}
+
+// TakeFires returns the burning spots the elite left since the last call
+func (elite *EliteTraits) TakeFires() []r.Vector2 {
+	fires := elite.fires
+	elite.fires = nil
+	return fires
+}
+
+// Experience is what defeating the enemy is worth
+func (e *Enemy) Experience() int {
+	if e.Elite == nil {
+		return enemyExperience
+	}
+	return eliteExperience + affixExperience*len(e.Elite.Affixes)
+}
+
+// drawAura draws the glow under an elite and its shield bar
+func (e *Enemy) drawAura() {
+	elite := e.Elite
+	center := r.Vector2{X: e.X + float32(e.Width)/2, Y: e.Y + float32(e.Height)/2}
+	pulse := float32(0.25 + 0.1*math.Sin(r.GetTime()*4))
+	r.DrawCircleV(center, float32(e.Height)/2+4, r.ColorAlpha(elite.Aura, pulse))
+
+	if elite.MaxShield > 0 && elite.Shield > 0 {
+		barWidth := float32(e.Width) * float32(elite.Shield) / float32(elite.MaxShield)
+		r.DrawRectangleV(
+			r.Vector2{X: e.X, Y: e.Y - 12},
+			r.Vector2{X: barWidth, Y: 3},
+			r.SkyBlue,
+		)
+	}
+}
+
+// FirePatch is burning ground left by elites, it hurts the player standing in it
+type FirePatch struct {
+	X        float32
+	Y        float32
+	Radius   float32
+	Life     float32
+	MaxLife  float32
+	IsBurned bool
+}
+
+// NewFirePatch creates burning ground centered on a point
+func NewFirePatch(x, y float32) *FirePatch {
+	return &FirePatch{
+		X:       x,
+		Y:       y,
+		Radius:  fireRadius,
+		Life:    fireLife,
+		MaxLife: fireLife,
+	}
+}
+
+func (f *FirePatch) Update(deltaTime float32) {
+	f.Life -= deltaTime
+	if f.Life <= 0 {
+		f.IsBurned = true
+	}
+}
+
+func (f *FirePatch) Draw(debug bool) {
+	fade := f.Life / f.MaxLife
+	r.DrawCircleV(r.Vector2{X: f.X, Y: f.Y}, f.Radius, r.ColorAlpha(r.Orange, 0.6*fade))
+	r.DrawCircleV(r.Vector2{X: f.X, Y: f.Y}, f.Radius/2, r.ColorAlpha(r.Yellow, 0.6*fade))
+
+	if debug {
+		r.DrawCircleLines(int32(f.X), int32(f.Y), f.Radius, r.Red)
+	}
+}
+
+func (f *FirePatch) Unload() {}
+
+// GetBounds returns the square around the fire
+func (f *FirePatch) GetBounds() r.Rectangle {
+	return r.Rectangle{
+		X:      f.X - f.Radius,
+		Y:      f.Y - f.Radius,
+		Width:  f.Radius * 2,
+		Height: f.Radius * 2,
+	}
+}
+
+// DrawLayer puts fire on the ground
+func (f *FirePatch) DrawLayer() Layer {
+	return LayerGround
+}
+
+// Touches reports whether the fire reaches into bounds
+func (f *FirePatch) Touches(bounds r.Rectangle) bool {
+	return r.CheckCollisionCircleRec(r.Vector2{X: f.X, Y: f.Y}, f.Radius, bounds)
+}
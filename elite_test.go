@@ -0,0 +1,121 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+func TestRollAffixesAreDistinct(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		affixes := RollAffixes(3, rng)
+		seen := make(map[Affix]bool)
+		for _, affix := range affixes {
+			if seen[affix] {
+				t.Fatalf("rolled %v twice in %v", affix, affixes)
+			}
+			seen[affix] = true
+		}
+		if len(affixes) != 3 {
+			t.Fatalf("rolled %d affixes, want 3", len(affixes))
+		}
+	}
+	if affixes := RollAffixes(10, rng); len(affixes) != len(allAffixes) {
+		t.Fatalf("rolled %d affixes, want every one", len(affixes))
+	}
+}
+
+func TestShieldAbsorbsDamage(t *testing.T) {
+	elite := &EliteTraits{Shield: 5}
+	if left := elite.Absorb(3); left != 0 || elite.Shield != 2 {
+		t.Fatalf("absorbing 3: %d left, shield %d", left, elite.Shield)
+	}
+	if left := elite.Absorb(4); left != 2 || elite.Shield != 0 {
+		t.Fatalf("absorbing 4: %d left, shield %d", left, elite.Shield)
+	}
+}
+
+func TestEliteAffixes(t *testing.T) {
+	g := NewHeadlessGame(NewScriptedInput(), 14)
+	defer g.Cleanup()
+	g.player.MaxHealth, g.player.CurrentHealth = 1e9, 1e9
+
+	enemy := NewEnemy(100, 100, g.player, g.rng.Derive("test", 0))
+	health := enemy.MaxHealth
+	MakeElite(enemy, []Affix{AffixShielded, AffixSplitting, AffixFireTrail})
+	g.world.Add(enemy)
+	if enemy.MaxHealth <= health || enemy.Elite.Shield == 0 || enemy.Experience() != eliteExperience+3*affixExperience {
+		t.Fatalf("elite has %d health, %d shield", enemy.MaxHealth, enemy.Elite.Shield)
+	}
+
+	// The shield takes the first hits
+	enemy.TakeDamage(DamageEvent{Amount: 2})
+	if enemy.CurrentHealth != enemy.MaxHealth {
+		t.Fatal("hit went through the shield")
+	}
+
+	for i := 0; i < 60; i++ {
+		g.Step(1.0 / 60)
+	}
+	if len(Query[*FirePatch](g.world)) == 0 {
+		t.Fatal("fire trail left no fire")
+	}
+
+	// Splitting elites leave two enemies and drop elite loot
+	enemies := len(Query[*Enemy](g.world))
+	drops := len(Query[*DroppedItem](g.world))
+	enemy.CurrentHealth = 0
+	g.destroy(enemy)
+	if got := len(Query[*Enemy](g.world)); got != enemies-1+splitCount {
+		t.Fatalf("%d enemies after the split, want %d", got, enemies-1+splitCount)
+	}
+	if got := len(Query[*DroppedItem](g.world)); got < drops+len(eliteLoot) {
+		t.Fatalf("elite dropped %d items", got-drops)
+	}
+}
+
+func TestFirePatchBurnsNearby(t *testing.T) {
+	fire := NewFirePatch(100, 100)
+	if !fire.Touches(r.Rectangle{X: 95, Y: 95, Width: 10, Height: 10}) {
+		t.Fatal("fire does not touch what stands on it")
+	}
+	if fire.Touches(r.Rectangle{X: 200, Y: 200, Width: 10, Height: 10}) {
+		t.Fatal("fire touches what stands far away")
+	}
+}
+
+func TestElitesCostMore(t *testing.T) {
+	dim := &Dimension{Enemies: []RosterEntry{{Kind: "grunt", Weight: 1}}, EliteChance: 1}
+	w := &WaveDirector{Wave: 1}
+	cost := enemyKinds["grunt"].Cost
+
+	groups := w.plan(dim, cost*eliteCostMultiplier, 1, NewRandomStreams(14))
+	if len(groups) != 1 || len(groups[0]) != 1 || !groups[0][0].Elite {
+		t.Fatalf("plan = %v, want one elite grunt", groups)
+	}
+	groups = w.plan(dim, cost, 1, NewRandomStreams(14))
+	if len(groups) != 1 || len(groups[0]) != 1 || groups[0][0].Elite {
+		t.Fatalf("plan = %v, want one plain grunt the budget affords", groups)
+	}
+}
+
+func TestMigrateSpawnsToObjects(t *testing.T) {
+	fields := map[string]interface{}{
+		"version": 6.0,
+		"portals": []interface{}{map[string]interface{}{"spawns": []interface{}{"grunt"}}},
+		"waves":   map[string]interface{}{"pending": []interface{}{[]interface{}{"shooter"}}},
+	}
+	if err := migrateSave(fields); err != nil {
+		t.Fatal(err)
+	}
+	spawn := fields["portals"].([]interface{})[0].(map[string]interface{})["spawns"].([]interface{})[0]
+	if spawn.(map[string]interface{})["kind"] != "grunt" {
+		t.Fatalf("portal spawn = %v", spawn)
+	}
+	pending := fields["waves"].(map[string]interface{})["pending"].([]interface{})[0].([]interface{})[0]
+	if pending.(map[string]interface{})["kind"] != "shooter" {
+		t.Fatalf("pending spawn = %v", pending)
+	}
+}
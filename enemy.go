@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"math"
+	"math/rand"
 
 	r "github.com/gen2brain/raylib-go/raylib"
 )
@@ -15,12 +16,13 @@ type Vector2 struct {
 
 // Enemy represents an enemy entity in the game
 type Enemy struct {
+	Kind          string // The enemyKinds entry it was made from
 	X             float32
 	Y             float32
 	Width         int32
 	Height        int32
 	Speed         float32
-	Texture       r.Texture2D
+	Anim          *Animator
 	Scale         float32
 	Player        *Player
 	Direction     Vector2
@@ -35,17 +37,39 @@ type Enemy struct {
 	}
 	DropChance     float32 // Chance to drop goodie bag (0.0 to 1.0)
 	FlashTimer     float32
-	DamageCooldown float32 // Add this field for rate-limiting damage
+	DamageCooldown float32       // Add this field for rate-limiting damage
+	Rand           *rand.Rand    // Drives this enemy's drops
+	Tint           r.Color       // Tells kinds sharing a sprite apart
+	Ranged         *RangedAttack // Set for enemies that shoot instead of walking into the player
+	Elite          *EliteTraits  // Set for elites
+	Ghost          *GhostTraits  // Set for ghosts
+	Nav            *NavGrid      // Leads chasers around obstacles, they walk straight without one
+	State          AIState
+	StateTimer     float32   // Time left in the current state, where it is timed
+	AttackCooldown float32   // Time until the next lunge
+	HasFled        bool      // Enemies only flee once
+	WanderTarget   r.Vector2 // Where a wandering enemy is headed
+	LungeDirection r.Vector2
+	Steering       Steering
+	Crowd          r.Vector2 // Steering from the enemies around it, worked out by the game each frame
+	ChillTimer     float32   // Time left slowed down by frost
+	RootTimer      float32   // Time left snared in place by a trap
 }
 
-// NewEnemy creates a new enemy instance
-func NewEnemy(x, y float32, target *Player) *Enemy {
+// Chilled enemies keep this share of their speed and are tinted this color
+const chillSpeed = 0.5
+
+var chillColor = r.Color{R: 150, G: 200, B: 255, A: 255}
+
+// NewEnemy creates a new enemy instance with its own loot generator
+func NewEnemy(x, y float32, target *Player, rng *rand.Rand) *Enemy {
 	return &Enemy{
+		Kind:           "grunt",
 		X:              x,
 		Y:              y,
 		Width:          16,
 		Height:         32,
-		Texture:        r.LoadTexture("assets/enemy.png"),
+		Anim:           NewAnimator("enemy"),
 		Speed:          50.0,
 		Player:         target,
 		MaxHealth:      3,
@@ -53,43 +77,60 @@ func NewEnemy(x, y float32, target *Player) *Enemy {
 		DropChance:     0.4,
 		Scale:          1.0,
 		DamageCooldown: 0,
+		Rand:           rng,
+		Tint:           r.White,
+		State:          AIChase, // Enemies come out of portals knowing where the player is
+		Steering:       gruntSteering,
 	}
 }
 
 // Update updates the enemy's position and behavior
-func (e *Enemy) Update() {
-	deltaTime := r.GetFrameTime()
-
+func (e *Enemy) Update(deltaTime float32) {
 	// Update damage cooldown
 	if e.DamageCooldown > 0 {
 		e.DamageCooldown -= deltaTime
 	}
-
-	// Calculate direction vector towards player
-	e.Direction = Vector2{
-		X: e.Player.X - e.X,
-		Y: e.Player.Y - e.Y,
+	if e.ChillTimer > 0 {
+		e.ChillTimer -= deltaTime
 	}
-
-	// Update facing direction based on movement
-	if e.Direction.X != 0 {
-		e.FacingLeft = e.Direction.X < 0
+	if e.RootTimer > 0 {
+		e.RootTimer -= deltaTime
 	}
 
-	// Normalize direction vector
-	length := float32(Sqrt(float64(e.Direction.X*e.Direction.X + e.Direction.Y*e.Direction.Y)))
-	if length > 0 {
-		e.Direction.X /= length
-		e.Direction.Y /= length
+	if e.Elite != nil {
+		e.updateElite(deltaTime)
+	}
+	if e.Ghost != nil {
+		e.updateGhost(deltaTime)
 	}
 
-	// Update position
-	e.X += e.Direction.X * e.Speed * deltaTime
-	e.Y += e.Direction.Y * e.Speed * deltaTime
+	e.think(deltaTime)
+	e.act(deltaTime)
+	e.animate(deltaTime)
 
-	// Update damage text
+	e.updateDamageText(deltaTime)
+}
+
+// animate plays the clip for the enemy's state, letting hits and lunges play out first
+func (e *Enemy) animate(deltaTime float32) {
+	e.Anim.FacingLeft = e.FacingLeft
+	if !e.Anim.Busy() {
+		switch e.State {
+		case AIIdle:
+			e.Anim.Play("idle")
+		case AIAttack:
+			e.Anim.Play("attack")
+		default:
+			e.Anim.Play("walk")
+		}
+	}
+	e.Anim.Update(deltaTime)
+}
+
+// updateDamageText floats the last damage taken upwards while it fades
+func (e *Enemy) updateDamageText(deltaTime float32) {
 	if e.DamageText.Timer > 0 {
-		e.DamageText.Timer -= r.GetFrameTime()
+		e.DamageText.Timer -= deltaTime
 		e.DamageText.Position.Y -= 1
 		e.DamageText.Alpha = e.DamageText.Timer
 	}
@@ -97,37 +138,24 @@ func (e *Enemy) Update() {
 
 // Draw renders the enemy
 func (e *Enemy) Draw(debug bool) {
-	// Calculate sprite dimensions
-	spriteWidth := float32(e.Texture.Width)
-	spriteHeight := float32(e.Texture.Height)
+	// Elites glow
+	if e.Elite != nil {
+		e.drawAura()
+	}
 
-	// Set up source rectangle with flipping
-	srcRec := r.Rectangle{
-		X:      0,
-		Y:      0,
-		Width:  spriteWidth * float32(map[bool]int{true: 1, false: -1}[e.FacingLeft]),
-		Height: spriteHeight,
+	// Draw enemy, frosted over while chilled
+	tint := e.Tint
+	if e.ChillTimer > 0 {
+		tint = r.ColorTint(tint, chillColor)
 	}
+	e.Anim.DrawAt(r.Vector2{X: e.X, Y: e.Y}, e.Scale, tint)
 
-	// Set up destination rectangle
-	destRec := r.Rectangle{
-		X:      e.X,
-		Y:      e.Y,
-		Width:  spriteWidth * e.Scale,
-		Height: spriteHeight * e.Scale,
-	}
-
-	// Draw enemy
-	r.DrawTexturePro(
-		e.Texture,
-		srcRec,
-		destRec,
-		r.Vector2{X: 0, Y: 0},
-		0,
-		r.White,
-	)
+	// Draw the aim line and shots of shooters
+	if e.Ranged != nil {
+		e.drawRanged()
+	}
 
-	// Debug collision box
+	// Debug collision box, state and path
 	if debug {
 		r.DrawRectangleLines(
 			int32(e.X),
@@ -136,6 +164,7 @@ func (e *Enemy) Draw(debug bool) {
 			e.Height,
 			r.Green,
 		)
+		e.drawAI()
 	}
 
 	// Draw health bar
@@ -158,17 +187,17 @@ func (e *Enemy) Draw(debug bool) {
 			14,
 			r.ColorAlpha(r.Red, e.DamageText.Alpha),
 		)
-
-		// Update position and alpha
-		e.DamageText.Position.Y -= 1
-		e.DamageText.Timer -= r.GetFrameTime()
-		e.DamageText.Alpha = e.DamageText.Timer
 	}
 }
 
 // Unload frees the texture from memory
 func (e *Enemy) Unload() {
-	r.UnloadTexture(e.Texture)
+	e.Anim.Unload()
+}
+
+// DeathEffect plays the enemy's death where it fell
+func (e *Enemy) DeathEffect() *AnimEffect {
+	return NewAnimEffect(e.Anim.Sheet.ID, "death", e.X, e.Y, e.Scale, e.FacingLeft, e.Tint)
 }
 
 // GetBounds returns the enemy's bounding rectangle
@@ -181,6 +210,27 @@ func (e *Enemy) GetBounds() r.Rectangle {
 	}
 }
 
+// slowed returns a speed of the enemy, cut down while it is chilled and nothing while it is rooted
+func (e *Enemy) slowed(speed float32) float32 {
+	if e.Rooted() {
+		return 0
+	}
+	if e.ChillTimer > 0 {
+		return speed * chillSpeed
+	}
+	return speed
+}
+
+// Snare roots the enemy in place for a while
+func (e *Enemy) Snare(duration float32) {
+	e.RootTimer = max(e.RootTimer, duration)
+}
+
+// Rooted reports whether the enemy is held in place and cannot move
+func (e *Enemy) Rooted() bool {
+	return e.RootTimer > 0
+}
+
 // CheckCollision checks if the enemy collides with the player
 func (e *Enemy) CheckCollision(player *Player) bool {
 	return r.CheckCollisionRecs(e.GetBounds(), player.GetBounds())
@@ -191,13 +241,35 @@ func Sqrt(x float64) float64 {
 	return math.Sqrt(x)
 }
 
-// TakeDamage is a method to handle enemy damage
-func (e *Enemy) TakeDamage(damage int32) {
+// TakeDamage applies a hit unless the enemy was hit too recently
+func (e *Enemy) TakeDamage(event DamageEvent) bool {
+	if e.DamageCooldown > 0 && !event.Unblocked {
+		return false
+	}
+	if e.Ghost != nil && e.Ghost.Resists(event.Type) {
+		return false
+	}
+	e.DamageCooldown = max(e.DamageCooldown, event.Cooldown)
+	damage := event.Amount
+
+	// Shields soak up damage before health
+	if e.Elite != nil {
+		damage = e.Elite.Absorb(damage)
+	}
+
 	e.CurrentHealth -= damage
 	if e.CurrentHealth < 0 {
 		e.CurrentHealth = 0
 	}
 
+	// Get pushed back and slowed down by the hit, unless a trap holds it
+	if !e.Rooted() {
+		e.X += event.Knockback.X
+		e.Y += event.Knockback.Y
+	}
+	e.ChillTimer = max(e.ChillTimer, event.Chill)
+	e.Anim.Restart("hit")
+
 	// Update damage text
 	e.DamageText = struct {
 		Value    int32
@@ -213,6 +285,7 @@ func (e *Enemy) TakeDamage(damage int32) {
 		Alpha: 1.0,
 		Timer: 1.0,
 	}
+	return true
 }
 
 // Add IsDead method
@@ -227,3 +300,8 @@ func (e *Enemy) GetDropPosition() r.Vector2 {
 		Y: e.Y + float32(e.Height)/2,
 	}
 }
+
+// DrawLayer draws enemies over the player
+func (e *Enemy) DrawLayer() Layer {
+	return LayerActors
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Entity is any object that lives in the world. The World draws, updates and
+// unloads entities through this interface and the optional ones below.
+type Entity interface {
+	GetBounds() r.Rectangle
+	Draw(debug bool)
+	Unload()
+}
+
+// Updater is an entity that changes over time
+type Updater interface {
+	Update(deltaTime float32)
+}
+
+// Damageable is an entity weapons can hurt. TakeDamage reports whether the hit
+// landed, targets ignore hits they resist or that arrive during their cooldown.
+type Damageable interface {
+	Entity
+	TakeDamage(event DamageEvent) bool
+	IsDead() bool
+}
+
+// Obstacle is an entity new objects are never placed on top of
+type Obstacle interface {
+	Entity
+	BlocksPlacement() bool
+}
+
+// BulletBlocker is an entity bullets cannot pass through
+type BulletBlocker interface {
+	Entity
+	BlocksBullets() bool
+}
+
+// Solid is an entity the player and enemies collide with
+type Solid interface {
+	Entity
+	IsSolid() bool
+}
+
+// MovementBlocker is an entity enemies have to walk around
+type MovementBlocker interface {
+	Entity
+	BlocksMovement() bool
+}
+
+// Station is an entity recipes can require the player to stand near
+type Station interface {
+	Entity
+	StationName() string
+}
+
+// Layer orders the drawing of entities, lower layers are drawn first
+type Layer int
+
+const (
+	LayerGround  Layer = iota // Grass and portals, under everything else
+	LayerObjects              // Trees, stones and dropped items, under the player
+	LayerActors               // Enemies, dummies and the merchant, over the player
+)
+
+// Layered is an entity that is not drawn on the objects layer
+type Layered interface {
+	DrawLayer() Layer
+}
+
+// layerOf returns the layer an entity is drawn on
+func layerOf(e Entity) Layer {
+	if layered, ok := e.(Layered); ok {
+		return layered.DrawLayer()
+	}
+	return LayerObjects
+}
@@ -4,11 +4,11 @@ import (
 	"fmt"
 	"math"
 	"math/rand"
-	"time"
 
 	r "github.com/gen2brain/raylib-go/raylib"
 )
 
+// Size of a dimension that does not set its own
 const (
 	GameWidth  = 900
 	GameHeight = 800
@@ -33,34 +33,53 @@ type Camera struct {
 
 // Game represents the main game state and objects
 type Game struct {
-	state            GameState
-	menu             *MainMenu
-	camera           Camera
-	player           *Player
-	enemies          []*Enemy
-	sprites          []*Sprite
-	trees            []*Tree
-	droppedItems     []*DroppedItem
-	inventory        *Inventory
-	debug            bool
-	cursorTex        r.Texture2D
-	stones           []*Stone
-	gameFont         r.Font
-	toolbarSlots     []r.Rectangle
-	gameTimer        float32
-	crafting         *CraftingSystem
-	particles        *ParticleSystem
-	portals          []*Portal
-	portalSpawnTimer float32
-	shakeAmount      float32
-	shakeTimer       float32
-	merchant         *Merchant
-	isPaused         bool
-	dummies          []*Dummy
+	state          GameState
+	menu           *MainMenu
+	camera         Camera
+	player         *Player
+	world          *World
+	inventory      *Inventory
+	debug          bool
+	cursorTex      r.Texture2D
+	gameFont       r.Font
+	toolbarSlots   []r.Rectangle
+	gameTimer      float32
+	crafting       *CraftingSystem
+	grimoire       *Grimoire
+	particles      *ParticleSystem
+	waves          *WaveDirector
+	shakeAmount    float32
+	shakeTimer     float32
+	merchant       *Merchant // Also in the world, kept here for the shop UI
+	isPaused       bool
+	input          Input
+	clock          Clock
+	controls       *InputMap
+	rng            *RandomStreams
+	portalsSpawned int
+	enemiesSpawned int
+	dimension      int // Index into dimensions
+	progress       DimensionProgress
+	boss           *Boss    // Also in the world while a boss fight is on, kept here for the health bar
+	nav            *NavGrid // Flow field enemies follow to the player
+	tilemap        *Tilemap // Ground of the current dimension, its colliders are in the world
+}
+
+// loadDataFiles reads the definition files into their registries. Each file is
+// checked against the ones before it, so dimensions come after the items they name.
+func loadDataFiles() {
+	loadAnimations(AnimationsFile)
+	loadSpells(SpellsFile)
+	loadItems(ItemsFile)
+	loadDeployables(DeployablesFile)
+	loadDimensions(DimensionsFile)
+	loadModifiers(ModifiersFile)
 }
 
 // NewGame creates a new game instance
 func NewGame() *Game {
+	loadDataFiles()
+
 	game := &Game{
 		state: StateMenu,
 		camera: Camera{
@@ -69,11 +88,15 @@ func NewGame() *Game {
 			Rotation: 0,
 			Zoom:     3.0,
 		},
-		debug:            false,
-		crafting:         NewCraftingSystem(),
-		particles:        NewParticleSystem(),
-		portals:          make([]*Portal, 0),
-		portalSpawnTimer: 10.0,
+		debug:     false,
+		crafting:  NewCraftingSystem(RecipesFile),
+		grimoire:  NewGrimoire(),
+		particles: NewParticleSystem(),
+		world:     NewWorld(),
+		waves:     NewWaveDirector(),
+		input:     RaylibInput{},
+		clock:     RaylibClock{},
+		controls:  DefaultInputMap(),
 	}
 
 	// Create menu after font is loaded
@@ -96,11 +119,14 @@ func (g *Game) Initialize() {
 	r.HideCursor()
 
 	// Load cursor texture
-	g.cursorTex = r.LoadTexture("assets/cursor.png")
+	g.cursorTex = loadTexture("assets/cursor.png")
 
 	// Use default font
 	g.gameFont = r.GetFontDefault()
 
+	// Load the player's key bindings
+	g.controls = LoadControls(ControlsFile)
+
 	// Initialize toolbar slots
 	slotSize := int32(50)
 	spacing := int32(10)
@@ -122,80 +148,288 @@ func (g *Game) Initialize() {
 	g.menu.Initialize()
 }
 
-// InitializeGameObjects creates game objects when starting to play
-func (g *Game) InitializeGameObjects() {
+// InitializeGameObjects creates game objects for a new run of the given world seed
+func (g *Game) InitializeGameObjects(seed int64) {
+	g.unloadWorld()
+
+	g.rng = NewRandomStreams(seed)
+	g.portalsSpawned = 0
+	g.enemiesSpawned = 0
+	g.gameTimer = 0
+	g.waves = NewWaveDirector()
+
 	g.player = NewPlayer(400, 300, GameWidth, GameHeight)
-	g.inventory = NewInventory(g.player)
-	g.enemies = make([]*Enemy, 0)
-
-	// Create sprites
-	g.sprites = make([]*Sprite, 20)
-	for i := range g.sprites {
-		g.sprites[i] = NewSprite("assets/grass.png")
-	}
-
-	// Create trees with collision check
-	g.trees = make([]*Tree, 15)
-	for i := range g.trees {
-		var tree *Tree
-		for {
-			tree = NewTree(GameWidth, GameHeight)
-			bounds := r.Rectangle{
-				X:      tree.X,
-				Y:      tree.Y,
-				Width:  float32(tree.Width),
-				Height: float32(tree.Height),
+	g.inventory = NewInventory(g.player, g.rng)
+	g.inventory.LoadDefaultIcons()
+
+	g.generateDimension(0)
+}
+
+// currentDimension returns the dimension the player is in
+func (g *Game) currentDimension() *Dimension {
+	return dimensions[g.dimension]
+}
+
+// generateDimension fills the empty world with a fresh layout of a dimension
+// and puts the player in the middle of it
+func (g *Game) generateDimension(index int) {
+	g.dimension = index
+	g.progress = DimensionProgress{}
+	g.rng.EnterDimension(index)
+
+	dim := g.currentDimension()
+	g.buildTilemap()
+	g.nav = NewNavGrid(dim.Width, dim.Height)
+	g.player.GameWidth = dim.Width
+	g.player.GameHeight = dim.Height
+	g.player.X = float32(dim.Width)/2 - float32(g.player.Width)/2
+	g.player.Y = float32(dim.Height)/2 - float32(g.player.Height)/2
+
+	// Keep the spot the player arrives on clear
+	arrival := r.Rectangle{X: g.player.X - 50, Y: g.player.Y - 50, Width: 100, Height: 100}
+	isFree := func(bounds r.Rectangle) bool {
+		return !g.world.IsOccupied(bounds, 20) && !r.CheckCollisionRecs(bounds, arrival)
+	}
+
+	// Rooms go down first so trees and stones grow around them. They also stay
+	// clear of the merchant and dummy next to the arrival.
+	camp := r.Rectangle{X: g.player.X - 160, Y: g.player.Y - 160, Width: 320, Height: 320}
+	for i := 0; i < dim.Rooms; i++ {
+		for attempt := 0; attempt < 100; attempt++ {
+			columns := 9 + g.rng.World.Intn(4)
+			rows := 8 + g.rng.World.Intn(3)
+			x := float32(wallSize * (1 + g.rng.World.Intn(int(dim.Width)/wallSize-columns-1)))
+			y := float32(wallSize * (1 + g.rng.World.Intn(int(dim.Height)/wallSize-rows-1)))
+			side := roomSides[g.rng.World.Intn(len(roomSides))]
+			room := NewRoom(x, y, columns, rows, side, g.rng.World.Float32() < lockedRoomChance)
+			if !isFree(room.GetBounds()) || r.CheckCollisionRecs(room.GetBounds(), camp) {
+				continue
 			}
-			if !g.IsPositionOccupied(bounds, 20) {
-				break
+			room.Build(g.world)
+			loot := roomLoot
+			if room.Door.Locked {
+				loot = lockedRoomLoot
 			}
+			g.dropLoot(loot, room.Center(), g.rng.World)
+			break
 		}
-		g.trees[i] = tree
 	}
 
-	// Create stones with collision check
-	g.stones = make([]*Stone, 25)
-	for i := range g.stones {
-		var stone *Stone
-		for {
-			stone = NewStone(GameWidth, GameHeight)
-			bounds := r.Rectangle{
-				X:      stone.X,
-				Y:      stone.Y,
-				Width:  float32(stone.Width),
-				Height: float32(stone.Height),
-			}
-			if !g.IsPositionOccupied(bounds, 20) {
+	// Create trees with collision check, leaving out the ones no free spot is found for
+	for i := 0; i < dim.Trees; i++ {
+		for attempt := 0; attempt < 100; attempt++ {
+			tree := NewTree(dim.Width, dim.Height, g.rng.World)
+			if isFree(tree.GetBounds()) {
+				g.world.Add(tree)
 				break
 			}
+			tree.Unload()
 		}
-		g.stones[i] = stone
 	}
 
-	g.loadItemIcon("Goodie Bag", "assets/goodie-bag.png")
+	// Create stones with collision check, leaving out the ones no free spot is found for
+	for i := 0; i < dim.Stones; i++ {
+		for attempt := 0; attempt < 100; attempt++ {
+			stone := NewStone(dim.Width, dim.Height, dim.GoldenChance, g.rng.World)
+			if isFree(stone.GetBounds()) {
+				g.world.Add(stone)
+				break
+			}
+			stone.Unload()
+		}
+	}
 
-	// Create merchant at a fixed position
-	g.merchant = NewMerchant(500, 200)
+	// Every dimension has a merchant and a dummy next to where the player arrives
+	g.merchant = NewMerchant(g.player.X+100, g.player.Y-100)
 	g.merchant.LoadIcons()
+	g.world.Add(g.merchant)
+	g.world.Add(NewDummy(float32(dim.Width)/2, float32(dim.Height)/2+100))
+}
+
+// buildTilemap loads the current dimension's map, or generates one, and adds its colliders to the world.
+// Generated maps come from their own stream, so a loaded run gets the same ground back.
+func (g *Game) buildTilemap() {
+	if g.tilemap != nil {
+		g.tilemap.Unload()
+	}
+
+	dim := g.currentDimension()
+	if dim.tilemap != nil {
+		g.tilemap = dim.tilemap
+	} else {
+		g.tilemap = GenerateTilemap(dim.Width, dim.Height, dim.Grass, g.rng.Derive("tilemap", g.dimension))
+	}
+	g.tilemap.Load()
+	for _, collider := range g.tilemap.Colliders() {
+		g.world.Add(collider)
+	}
+}
+
+// enterDimension carries the player, inventory and weapons into another dimension
+func (g *Game) enterDimension(index int) {
+	g.packUpDeployables()
+	g.world.Unload()
+	g.merchant = nil
+	g.boss = nil
+	g.generateDimension(index)
+	g.waves.Rest()
+
+	g.particles.SpawnDamageNumber(g.currentDimension().Name, g.player.X, g.player.Y-10)
+}
+
+// updateDimension tracks the dimension's goal, opens the exit once it is met
+// and takes the player through it
+func (g *Game) updateDimension(deltaTime float32) {
+	if g.player == nil {
+		return
+	}
+
+	dim := g.currentDimension()
+	g.progress.Time += deltaTime
+
+	if !g.progress.Complete {
+		if done, needed := dim.GoalProgress(g.progress, g.inventory); done >= needed {
+			g.progress.Complete = true
+			if dim.Boss != nil {
+				g.startBossFight()
+			} else {
+				g.openExit()
+			}
+		}
+		return
+	}
 
-	// Create a dummy
-	g.dummies = append(g.dummies, NewDummy(400, 400))
+	if g.boss == nil && len(QueryRect[*ExitPortal](g.world, g.player.GetBounds())) > 0 {
+		g.enterDimension(g.dimension + 1)
+	}
+}
+
+// bossArenaSize is the area a boss fight locks the player into
+var bossArenaSize = r.Vector2{X: 400, Y: 300}
+
+// startBossFight locks the player into an arena around them with the dimension's boss
+func (g *Game) startBossFight() {
+	dim := g.currentDimension()
+	g.progress.BossFight = true
+
+	// Center the arena on the player, keeping it inside the dimension
+	center := r.Vector2{X: g.player.X + float32(g.player.Width)/2, Y: g.player.Y + float32(g.player.Height)/2}
+	arena := r.Rectangle{
+		X:      float32(math.Max(0, math.Min(float64(center.X-bossArenaSize.X/2), float64(float32(dim.Width)-bossArenaSize.X)))),
+		Y:      float32(math.Max(0, math.Min(float64(center.Y-bossArenaSize.Y/2), float64(float32(dim.Height)-bossArenaSize.Y)))),
+		Width:  bossArenaSize.X,
+		Height: bossArenaSize.Y,
+	}
 
-	// Add icon loading here
-	g.inventory.LoadIcon("Pickaxe", "assets/pickaxe.png")
-	g.inventory.LoadIcon("Strange Log", "assets/tree-pickup.png")
-	g.inventory.LoadIcon("Stone Fragment", "assets/stone-pickup.png")
-	g.inventory.LoadIcon("Golden Nugget", "assets/gold-nugget.png")
-	g.inventory.LoadIcon("Gold Coin", "assets/gold_coin.png")
+	g.boss = NewBoss(arena.X+arena.Width/2-23, arena.Y+10, g.player, dim.Boss, arena)
+	g.world.Add(g.boss)
+	g.particles.SpawnDamageNumber(dim.Boss.Name+" appears!", g.player.X, g.player.Y-10)
 }
 
-// Update handles game logic updates
+// updateBossFight keeps the player in the arena and lets the boss's attacks land
+func (g *Game) updateBossFight() {
+	if g.boss == nil || g.player == nil {
+		return
+	}
+
+	arena := g.boss.Arena
+	g.player.X = float32(math.Max(float64(arena.X), math.Min(float64(g.player.X), float64(arena.X+arena.Width-float32(g.player.Width)))))
+	g.player.Y = float32(math.Max(float64(arena.Y), math.Min(float64(g.player.Y), float64(arena.Y+arena.Height-float32(g.player.Height)))))
+
+	playerBounds := g.player.GetBounds()
+	if r.CheckCollisionRecs(g.boss.GetBounds(), playerBounds) {
+		g.player.TakeDamage(g.boss.ContactDamage())
+		g.shakeAmount = 4.0
+		g.shakeTimer = 0.15
+	}
+	g.boss.BlockProjectiles(g.world)
+	if hits := g.boss.ProjectileHits(playerBounds); hits > 0 {
+		g.player.TakeDamage(bossRingDamage)
+		g.particles.SpawnExplosion(r.Purple, 10, g.player.X, g.player.Y)
+	}
+
+	// Minions come through a portal next to the boss
+	if summons := g.boss.TakeSummons(); summons != nil {
+		portal := NewPortalAt(g.boss.X+float32(g.boss.Width)+8, g.boss.Y+float32(g.boss.Height)/2, summons, g.rng.Derive("portal", g.portalsSpawned))
+		portal.SpawnRate = 0.5
+		portal.SpawnTimer = portal.SpawnRate
+		g.world.Add(portal)
+		g.portalsSpawned++
+	}
+}
+
+// dropLoot drops everything in a loot table around a point
+func (g *Game) dropLoot(loot []LootDrop, center r.Vector2, rng *rand.Rand) {
+	for _, drop := range loot {
+		count := drop.Min + rng.Intn(drop.Max-drop.Min+1)
+		for i := 0; i < count; i++ {
+			angle := rng.Float64() * 2 * math.Pi
+			distance := 10 + rng.Float64()*30
+			x := center.X + float32(math.Cos(angle)*distance) - 8
+			y := center.Y + float32(math.Sin(angle)*distance) - 8
+			g.world.Add(NewDroppedItem(x, y, drop.Item))
+		}
+	}
+}
+
+// openExit places the portal to the next dimension, the last dimension has none
+func (g *Game) openExit() {
+	if g.dimension+1 >= len(dimensions) {
+		g.particles.SpawnDamageNumber("Ascended!", g.player.X, g.player.Y-10)
+		return
+	}
+
+	dim := g.currentDimension()
+	spot := freePortalSpot(dim.Width, dim.Height, g.world, g.rng.Derive("exit", g.dimension))
+	g.world.Add(NewExitPortal(spot.X, spot.Y))
+	g.particles.SpawnDamageNumber("Exit opened", g.player.X, g.player.Y-10)
+}
+
+// spawnEnemy creates one enemy, scaled to the dimension's difficulty.
+// Elites roll more affixes in higher dimensions.
+func (g *Game) spawnEnemy(position r.Vector2, spawn Spawn) Damageable {
+	rng := g.rng.Derive("enemy", g.enemiesSpawned)
+	g.enemiesSpawned++
+
+	dim := g.currentDimension()
+	enemy := enemyKinds[spawn.Kind].New(position.X, position.Y, g.player, rng)
+	if e, ok := enemy.(*Enemy); ok {
+		e.Nav = g.nav
+		e.MaxHealth = int32(math.Max(1, math.Round(float64(float32(e.MaxHealth)*dim.EnemyHealth))))
+		e.CurrentHealth = e.MaxHealth
+		e.Speed *= dim.EnemySpeed
+		if spawn.Elite {
+			MakeElite(e, RollAffixes(1+rng.Intn(1+g.dimension/2), rng))
+		}
+	}
+	g.world.Add(enemy)
+	return enemy
+}
+
+// threats counts the enemies and portals the current wave still has alive
+func (g *Game) threats() int {
+	return len(Query[*Portal](g.world)) + len(Query[*Enemy](g.world)) + len(Query[*Boss](g.world))
+}
+
+// Update handles game logic updates for the frame raylib just measured
 func (g *Game) Update() {
+	g.Step(g.clock.GetFrameTime())
+}
+
+// Step advances the game by one frame lasting deltaTime seconds
+func (g *Game) Step(deltaTime float32) {
 	switch g.state {
 	case StateMenu:
-		if g.menu.Update() {
-			g.InitializeGameObjects()
+		switch g.menu.Update(g.input) {
+		case MenuPlay:
+			g.InitializeGameObjects(g.menu.Seed())
 			g.state = StatePlaying
+		case MenuContinue:
+			if err := g.LoadGame(SaveFile); err != nil {
+				fmt.Println("Warning: Could not load game:", err)
+				g.InitializeGameObjects(g.menu.Seed())
+				g.state = StatePlaying
+			}
 		}
 
 	case StatePlaying:
@@ -203,11 +437,11 @@ func (g *Game) Update() {
 		if g.player != nil && g.player.IsDead() {
 			g.state = StateDead
 		}
-		g.UpdatePlaying()
+		g.UpdatePlaying(deltaTime)
 
 	case StateDead:
 		// Click anywhere to return to menu
-		if r.IsMouseButtonPressed(0) {
+		if g.input.IsMouseButtonPressed(0) {
 			g.state = StateMenu
 			g.menu.Initialize()
 		}
@@ -217,35 +451,48 @@ func (g *Game) Update() {
 	if g.player != nil && g.inventory != nil {
 		g.player.UpdateHarvestDamage(g.inventory)
 	}
+
+	g.input.EndFrame()
 }
 
 // UpdatePlaying handles updates during gameplay
-func (g *Game) UpdatePlaying() {
+func (g *Game) UpdatePlaying(deltaTime float32) {
 	// Handle window toggles and pausing
-	if r.IsKeyPressed(r.KeyE) {
+	if g.controls.IsPressed(g.input, ActionOpenInventory) {
 		g.inventory.IsOpen = !g.inventory.IsOpen
 		g.crafting.IsOpen = false
+		g.grimoire.IsOpen = false
 		g.merchant.IsOpen = false
 		g.isPaused = g.inventory.IsOpen
 	}
 
-	if r.IsKeyPressed(r.KeyC) {
+	if g.controls.IsPressed(g.input, ActionOpenCrafting) {
 		g.crafting.IsOpen = !g.crafting.IsOpen
 		g.inventory.IsOpen = false
+		g.grimoire.IsOpen = false
 		g.merchant.IsOpen = false
 		g.isPaused = g.crafting.IsOpen
 	}
 
+	if g.controls.IsPressed(g.input, ActionOpenGrimoire) {
+		g.grimoire.IsOpen = !g.grimoire.IsOpen
+		g.inventory.IsOpen = false
+		g.crafting.IsOpen = false
+		g.merchant.IsOpen = false
+		g.isPaused = g.grimoire.IsOpen
+	}
+
 	// Update merchant interaction
 	if g.merchant != nil && g.merchant.IsOpen {
 		g.inventory.IsOpen = false
 		g.crafting.IsOpen = false
+		g.grimoire.IsOpen = false
 		g.isPaused = true
 	}
 
 	// Handle merchant clicking
-	if r.IsMouseButtonPressed(0) {
-		screenPos := r.GetMousePosition()
+	if g.input.IsMouseButtonPressed(0) {
+		screenPos := g.input.GetMousePosition()
 		worldPos := r.GetScreenToWorld2D(screenPos, r.Camera2D{
 			Target:   g.camera.Target,
 			Offset:   g.camera.Offset,
@@ -257,10 +504,16 @@ func (g *Game) UpdatePlaying() {
 			g.merchant.IsOpen = true
 			g.inventory.IsOpen = false
 			g.crafting.IsOpen = false
+			g.grimoire.IsOpen = false
 			g.isPaused = true
 		}
 	}
 
+	// Click through the open windows
+	g.inventory.Update(g.input)
+	g.merchant.UpdateShop(g.input, g.inventory)
+	g.grimoire.Update(g.input, g.player.Spells)
+
 	// Fix merchant close handling
 	if g.merchant != nil {
 		if !g.merchant.IsOpen { // Merchant was just closed
@@ -268,141 +521,195 @@ func (g *Game) UpdatePlaying() {
 		}
 	}
 
+	// Reload edited recipes and note the stations in reach, even while crafting is open
+	g.crafting.Update(deltaTime, g.input, g.inventory)
+	g.updateNearStations()
+
 	// Only update game logic if not paused
 	if !g.isPaused {
-		g.UpdateGameLogic()
+		g.UpdateGameLogic(deltaTime)
 	}
 
-	// Always update UI-related things
-	g.UpdateUI()
-
-	// Add weapon switching with number keys
-	if r.IsKeyPressed(r.KeyOne) {
-		g.player.SwitchWeapon(0)
+	// Quick save and quick load
+	if g.controls.IsPressed(g.input, ActionQuickSave) {
+		if err := g.SaveGame(SaveFile); err != nil {
+			fmt.Println("Warning: Could not save game:", err)
+		} else {
+			g.particles.SpawnDamageNumber("Saved", g.player.X, g.player.Y-10)
+		}
 	}
-	if r.IsKeyPressed(r.KeyTwo) {
-		g.player.SwitchWeapon(1)
+	if g.controls.IsPressed(g.input, ActionQuickLoad) {
+		if err := g.LoadGame(SaveFile); err != nil {
+			fmt.Println("Warning: Could not load game:", err)
+		}
+		return
 	}
-	if r.IsKeyPressed(r.KeyThree) {
-		g.player.SwitchWeapon(2)
+
+	// Switch weapons with the toolbar slot actions
+	for slot := 0; slot < 5; slot++ {
+		if g.controls.IsPressed(g.input, SwitchWeaponAction(slot)) {
+			g.player.SwitchWeapon(slot)
+		}
 	}
 }
 
 // UpdateGameLogic moves all the non-UI game update logic here
-func (g *Game) UpdateGameLogic() {
+func (g *Game) UpdateGameLogic(deltaTime float32) {
 	if g.player != nil {
-		g.player.Update(r.Camera2D{
+		g.player.Update(deltaTime, g.input, g.controls, r.Camera2D{
 			Target:   g.camera.Target,
 			Offset:   g.camera.Offset,
 			Rotation: g.camera.Rotation,
 			Zoom:     g.camera.Zoom,
-		})
+		}, g.world)
+
+		// Doors seal shut while a wave or boss fight is on and open again after.
+		// Rooms with the player or an enemy inside stay open, so nobody gets shut in.
+		sealed := g.waves.Phase == WaveActive || g.boss != nil
+		for _, room := range Query[*Room](g.world) {
+			bounds := room.GetBounds()
+			inside := r.CheckCollisionRecs(bounds, g.player.GetBounds()) || len(QueryRect[*Enemy](g.world, bounds)) > 0
+			room.Door.Seal(sealed && !inside)
+		}
+
+		// Set a trap down at the player's feet
+		if g.controls.IsPressed(g.input, ActionDeploy) {
+			g.deploy()
+		}
 	}
 
-	// Update portal spawn timer
-	g.portalSpawnTimer -= r.GetFrameTime()
-	if g.portalSpawnTimer <= 0 {
-		g.portalSpawnTimer = 10.0 // Reset timer for next portal
-		// Pass game instance to NewPortal
-		g.portals = append(g.portals, NewPortal(GameWidth, GameHeight, g))
+	// Let the wave director open portals, bosses summon their own minions instead
+	dim := g.currentDimension()
+	if g.boss != nil {
+		g.updateBossFight()
+	} else if spawns := g.waves.Update(deltaTime, g.threats(), dim, g.dimension, g.gameTimer, g.rng); spawns != nil {
+		// Each portal draws from its own spawn generator
+		portal := NewPortal(dim.Width, dim.Height, g.world, spawns, g.rng.Derive("portal", g.portalsSpawned))
+		portal.SpawnRate = g.waves.SpawnRate()
+		portal.SpawnTimer = portal.SpawnRate
+		g.world.Add(portal)
+		g.portalsSpawned++
 	}
 
 	// Update all portals and spawn enemies
-	var remainingPortals []*Portal
-	for _, portal := range g.portals {
-		if portal.Update(r.GetFrameTime()) {
-			spawnPos := portal.GetSpawnPosition()
-			newEnemy := NewEnemy(spawnPos.X, spawnPos.Y, g.player)
-			g.enemies = append(g.enemies, newEnemy)
+	for _, portal := range Query[*Portal](g.world) {
+		if portal.Update(deltaTime) {
+			g.spawnEnemy(portal.GetSpawnPosition(), portal.NextSpawn())
 		}
 
-		// Keep portal if not done
-		if !portal.IsDone {
-			remainingPortals = append(remainingPortals, portal)
-		} else {
-			portal.Unload()
+		if portal.IsDone {
+			g.world.Remove(portal)
 		}
 	}
-	g.portals = remainingPortals
 
-	// Update all enemies
-	var remainingEnemies []*Enemy
-	for _, enemy := range g.enemies {
-		enemy.Update()
+	// Point the enemies' flow field at the player before they move
+	if g.nav != nil && g.player != nil {
+		g.nav.Update(deltaTime, g.world, g.player.center())
+	}
+
+	// Remember where enemies stand, so walls can stop them once they moved
+	enemies := Query[*Enemy](g.world)
+	starts := make([]r.Rectangle, len(enemies))
+	for i, enemy := range enemies {
+		starts[i] = enemy.GetBounds()
+	}
+
+	// Enemies spread out around the player instead of stacking on top of each other
+	for _, enemy := range enemies {
+		enemy.steer(QueryRadius[*Enemy](g.world, enemy.center(), enemy.Steering.Radius))
+		g.world.Moved(enemy)
+	}
 
-		// Check for enemy-player collision and damage
-		if g.player != nil && enemy.CheckCollision(g.player) {
+	// Update enemies, dummies and everything else that moves on its own
+	g.world.Update(deltaTime)
+
+	for i, enemy := range enemies {
+		delta := r.Vector2{X: enemy.X - starts[i].X, Y: enemy.Y - starts[i].Y}
+		position := moveAndSlide(g.world, starts[i], delta)
+		if position.X != enemy.X || position.Y != enemy.Y {
+			enemy.X, enemy.Y = position.X, position.Y
+			g.world.Moved(enemy)
+		}
+	}
+
+	// Armed traps snap shut on the first enemy to step on them
+	for _, trap := range Query[*Deployable](g.world) {
+		for _, enemy := range QueryRect[*Enemy](g.world, trap.GetBounds()) {
+			if trap.Catches(enemy) {
+				g.dealDamage(trap.Spring(enemy))
+				break
+			}
+		}
+	}
+
+	// Death animations leave once they played out
+	for _, effect := range Query[*AnimEffect](g.world) {
+		if effect.IsDone {
+			g.world.Remove(effect)
+		}
+	}
+
+	if g.player != nil {
+		// Enemies touching the player hurt them
+		for range QueryRect[*Enemy](g.world, g.player.GetBounds()) {
 			g.player.TakeDamage(10)
 			g.particles.SpawnExplosion(r.Red, 10, g.player.X, g.player.Y)
 			g.shakeAmount = 3.0
 			g.shakeTimer = 0.1
 		}
 
-		// Check for weapon damage
-		if g.player != nil {
-			if raygun, ok := g.player.CurrentWeapon.(*RayGun); ok && raygun.Active && !raygun.IsOverheated {
-				if raygun.CheckRayCollision(g.player, enemy.GetBounds()) {
-					if enemy.DamageCooldown <= 0 {
-						damagePerSecond := int32(3)
-						enemy.TakeDamage(1)
-						enemy.DamageCooldown = 1.0 / float32(damagePerSecond)
-						g.particles.SpawnExplosion(r.Yellow, 10, enemy.X, enemy.Y)
-						g.shakeAmount = 3.0
-						g.shakeTimer = 0.1
-					}
-				}
-			} else if sword, ok := g.player.CurrentWeapon.(*Sword); ok {
-				if sword.CheckSlashCollision(enemy.GetBounds()) {
-					if enemy.DamageCooldown <= 0 {
-						enemy.TakeDamage(2)        // Reduced from 5 to 2 damage
-						enemy.DamageCooldown = 0.3 // Slightly faster than before
-						g.particles.SpawnExplosion(r.White, 10, enemy.X, enemy.Y)
-						g.shakeAmount = 3.0
-						g.shakeTimer = 0.1
-					}
-				}
-			} else if pistol, ok := g.player.CurrentWeapon.(*Pistol); ok {
-				if pistol.CheckBulletCollision(enemy.GetBounds()) {
-					if enemy.DamageCooldown <= 0 {
-						enemy.TakeDamage(2)
-						enemy.DamageCooldown = 0.2
-						g.particles.SpawnExplosion(r.Yellow, 5, enemy.X, enemy.Y)
-						g.shakeAmount = 2.0
-						g.shakeTimer = 0.05
-					}
+		// Ghosts are phased while they drift through trees and stones
+		for _, enemy := range Query[*Enemy](g.world) {
+			if enemy.Ghost != nil {
+				enemy.Ghost.InObstacle = len(QueryRect[BulletBlocker](g.world, enemy.GetBounds())) > 0
+			}
+		}
+
+		// Elites with a fire trail set the ground on fire, and burning ground hurts
+		for _, enemy := range Query[*Enemy](g.world) {
+			if enemy.Elite != nil {
+				for _, spot := range enemy.Elite.TakeFires() {
+					g.world.Add(NewFirePatch(spot.X, spot.Y))
 				}
 			}
 		}
+		for _, fire := range Query[*FirePatch](g.world) {
+			if fire.IsBurned {
+				g.world.Remove(fire)
+			} else if fire.Touches(g.player.GetBounds()) {
+				g.player.TakeDamage(fireDamage)
+			}
+		}
 
-		// Check if enemy is dead
-		if enemy.IsDead() {
-			g.particles.SpawnExplosion(r.Red, 15, enemy.X, enemy.Y)
-			if rand.Float32() < enemy.DropChance {
-				dropPos := enemy.GetDropPosition()
-				g.droppedItems = append(g.droppedItems, NewDroppedItem(
-					dropPos.X-8,
-					dropPos.Y-8,
-					"assets/goodie-bag.png",
-					"Goodie Bag",
-				))
+		// Shots of ranged enemies stop at trees and stones like the pistol's
+		for _, enemy := range Query[*Enemy](g.world) {
+			if enemy.Ranged == nil {
+				continue
 			}
-			enemy.Unload()
+			bullets, hits := enemy.Ranged.Bullets.Blocked(g.world).Hit(g.player.GetBounds())
+			enemy.Ranged.Bullets = bullets
+			if hits > 0 {
+				g.player.TakeDamage(shooterDamage)
+				g.particles.SpawnExplosion(r.Orange, 8, g.player.X, g.player.Y)
+			}
+		}
 
-			// Give player experience
-			g.player.GainExperience(10) // Adjust experience amount as needed
-		} else {
-			remainingEnemies = append(remainingEnemies, enemy)
+		// The current weapon and the spells going off hurt everything they touch
+		for _, event := range g.player.CurrentWeapon.Hits(g.world, g.player) {
+			g.dealDamage(event)
+		}
+		for _, event := range g.player.Spells.Hits(g.world, g.player) {
+			g.dealDamage(event)
 		}
 	}
-	g.enemies = remainingEnemies
 
 	// Toggle debug with F1
-	if r.IsKeyPressed(r.KeyF1) {
+	if g.controls.IsPressed(g.input, ActionToggleDebug) {
 		g.debug = !g.debug
 	}
 
 	// Handle zoom
-	wheel := r.GetMouseWheelMove()
+	wheel := g.input.GetMouseWheelMove()
 	if wheel != 0 {
 		g.camera.Zoom = float32(math.Max(1.0, math.Min(3.0, float64(g.camera.Zoom+wheel*0.1))))
 	}
@@ -411,8 +718,8 @@ func (g *Game) UpdateGameLogic() {
 	g.UpdateCamera()
 
 	// Handle tree clicking
-	if r.IsMouseButtonPressed(0) {
-		screenPos := r.GetMousePosition()
+	if g.input.IsMouseButtonPressed(0) {
+		screenPos := g.input.GetMousePosition()
 		worldPos := r.GetScreenToWorld2D(screenPos, r.Camera2D{
 			Target:   g.camera.Target,
 			Offset:   g.camera.Offset,
@@ -424,8 +731,8 @@ func (g *Game) UpdateGameLogic() {
 	}
 
 	// Handle stone clicking
-	if r.IsMouseButtonPressed(0) {
-		screenPos := r.GetMousePosition()
+	if g.input.IsMouseButtonPressed(0) {
+		screenPos := g.input.GetMousePosition()
 		worldPos := r.GetScreenToWorld2D(screenPos, r.Camera2D{
 			Target:   g.camera.Target,
 			Offset:   g.camera.Offset,
@@ -439,14 +746,17 @@ func (g *Game) UpdateGameLogic() {
 	// Check for item pickups
 	g.CheckItemPickups()
 
+	// Track the dimension's goal and move on through its exit
+	g.updateDimension(deltaTime)
+
 	// Update timer
-	g.gameTimer += r.GetFrameTime()
+	g.gameTimer += deltaTime
 
 	// Update particles
-	g.particles.Update()
+	g.particles.Update(deltaTime)
 
 	// Spawn explosion on F2
-	if r.IsKeyPressed(r.KeyF2) && g.player != nil {
+	if g.controls.IsPressed(g.input, ActionDebugExplosion) && g.player != nil {
 		centerX := g.player.X + float32(g.player.Width)/2
 		centerY := g.player.Y + float32(g.player.Height)/2
 		g.particles.SpawnExplosion(r.Red, 25, centerX, centerY)
@@ -454,26 +764,166 @@ func (g *Game) UpdateGameLogic() {
 
 	// Update the shake timer
 	if g.shakeTimer > 0 {
-		g.shakeTimer -= r.GetFrameTime()
+		g.shakeTimer -= deltaTime
 		if g.shakeTimer <= 0 {
 			g.shakeAmount = 0
 		}
 	}
+}
 
-	// Check for ray collision with dummies
-	if g.player != nil {
-		for _, dummy := range g.dummies {
-			dummy.Update()
-			if raygun, ok := g.player.CurrentWeapon.(*RayGun); ok {
-				if raygun.CheckRayCollision(g.player, dummy.GetBounds()) {
-					if raygun.Active && !raygun.IsOverheated && dummy.DamageCooldown <= 0 {
-						damagePerSecond := int32(3)
-						dummy.TakeDamage(1)
-						dummy.DamageCooldown = 1.0 / float32(damagePerSecond)
-					}
-				}
+// updateNearStations tells crafting which stations the player can reach and how far they ascended
+func (g *Game) updateNearStations() {
+	g.crafting.Dimension = g.dimension
+	g.grimoire.Dimension = g.dimension
+	for name := range g.crafting.NearStations {
+		delete(g.crafting.NearStations, name)
+	}
+	if g.player == nil {
+		return
+	}
+
+	center := r.Vector2{
+		X: g.player.X + float32(g.player.Width)/2,
+		Y: g.player.Y + float32(g.player.Height)/2,
+	}
+	for _, station := range QueryRadius[Station](g.world, center, StationRange) {
+		g.crafting.NearStations[station.StationName()] = true
+	}
+}
+
+// dealDamage applies a hit, shows its effect and destroys the target if it ran out of health
+func (g *Game) dealDamage(event DamageEvent) {
+	target := event.Target
+	if target.IsDead() || !target.TakeDamage(event) {
+		return
+	}
+	g.world.Moved(target)
+
+	bounds := target.GetBounds()
+	effect := damageEffects[event.Type]
+	if effect.Particles > 0 {
+		g.particles.SpawnExplosion(effect.Color, effect.Particles, bounds.X, bounds.Y)
+	}
+	if effect.Shake > 0 {
+		g.shakeAmount = effect.Shake
+		g.shakeTimer = effect.ShakeTime
+	}
+	if effect.ShowNumber || event.Crit {
+		text := fmt.Sprintf("-%d", event.Amount)
+		if event.Crit {
+			text += "!"
+		}
+		g.particles.SpawnDamageNumber(text, bounds.X+bounds.Width/2, bounds.Y-10)
+	}
+
+	if target.IsDead() {
+		g.destroy(target)
+	}
+}
+
+// destroy removes a broken target from the world, dropping its loot
+func (g *Game) destroy(target Damageable) {
+	bounds := target.GetBounds()
+	center := r.Vector2{X: bounds.X + bounds.Width/2, Y: bounds.Y + bounds.Height/2}
+
+	switch t := target.(type) {
+	case *Enemy:
+		g.particles.SpawnExplosion(r.Red, 15, t.X, t.Y)
+		g.world.Add(t.DeathEffect())
+		if t.Rand.Float32() < t.DropChance {
+			g.world.Add(NewDroppedItem(center.X-8, center.Y-8, "goodie_bag"))
+		}
+		if t.Elite != nil {
+			g.dropLoot(eliteLoot, center, t.Rand)
+			if t.Elite.Has(AffixSplitting) {
+				g.split(t)
 			}
 		}
+		g.player.GainExperience(t.Experience())
+		g.progress.Kills++
+	case *Boss:
+		g.particles.SpawnExplosion(r.Red, 40, center.X, center.Y)
+		g.world.Add(t.DeathEffect())
+		g.dropLoot(t.Loot, t.GetDropPosition(), g.rng.Derive("boss-loot", g.dimension))
+		g.player.GainExperience(50)
+		g.progress.Kills++
+		g.boss = nil
+		g.progress.BossFight = false
+		g.openExit()
+	case *Tree:
+		g.particles.SpawnExplosion(r.Yellow, 20, center.X, center.Y)
+		g.world.Add(NewDroppedItem(center.X-8, center.Y-8, "strange_log"))
+	case *Stone:
+		g.particles.SpawnExplosion(r.Yellow, 20, center.X, center.Y)
+		if t.IsGolden {
+			g.world.Add(NewDroppedItem(center.X-8, center.Y-8, "golden_nugget"))
+		} else {
+			g.world.Add(NewDroppedItem(center.X-8, center.Y-8, "stone_fragment"))
+		}
+	}
+	g.world.Remove(target)
+}
+
+// split replaces a splitting elite with weaker copies of its kind
+func (g *Game) split(elite *Enemy) {
+	for i := 0; i < splitCount; i++ {
+		offset := float32(i*2-1) * float32(elite.Width) / 2
+		spawned := g.spawnEnemy(r.Vector2{X: elite.X + offset, Y: elite.Y}, Spawn{Kind: elite.Kind})
+		if e, ok := spawned.(*Enemy); ok {
+			e.MaxHealth = int32(math.Max(1, float64(e.MaxHealth/2)))
+			e.CurrentHealth = e.MaxHealth
+			e.Scale = 0.8
+		}
+	}
+}
+
+// harvest hits a tree or stone with the player's harvest damage
+func (g *Game) harvest(target Damageable) {
+	g.dealDamage(DamageEvent{
+		Source: g.player,
+		Target: target,
+		Amount: g.player.HarvestDamage,
+		Type:   DamageHarvest,
+	})
+}
+
+// readyDeployable returns the first deployable the player holds a charge of, or nil
+func (g *Game) readyDeployable() *DeployableDef {
+	for _, def := range deployables.All() {
+		if g.inventory.ItemCounts[def.Item] > 0 {
+			return def
+		}
+	}
+	return nil
+}
+
+// deploy places a charge of the ready deployable centered under the player's feet
+func (g *Game) deploy() {
+	def := g.readyDeployable()
+	if def == nil {
+		return
+	}
+
+	bounds := g.player.GetBounds()
+	spot := r.Rectangle{
+		X:      bounds.X + bounds.Width/2 - def.Width/2,
+		Y:      bounds.Y + bounds.Height - def.Height,
+		Width:  def.Width,
+		Height: def.Height,
+	}
+	if len(solidsIn(g.world, spot)) > 0 || len(QueryRect[*Deployable](g.world, spot)) > 0 {
+		g.particles.SpawnDamageNumber("No room", g.player.X, g.player.Y-10)
+		return
+	}
+
+	g.inventory.Remove(def.Item, 1)
+	g.world.Add(NewDeployable(def, spot.X, spot.Y))
+}
+
+// packUpDeployables puts everything the player placed back into the inventory, before the world is left behind
+func (g *Game) packUpDeployables() {
+	for _, deployable := range Query[*Deployable](g.world) {
+		g.inventory.Add(deployable.Def.Item, 1)
 	}
 }
 
@@ -483,12 +933,13 @@ func (g *Game) UpdateCamera() {
 		return
 	}
 
+	// Keep the view on the map
 	viewWidth := 800 / g.camera.Zoom
 	viewHeight := 600 / g.camera.Zoom
 	minX := viewWidth / 2
 	minY := viewHeight / 2
-	maxX := float32(GameWidth) - (viewWidth / 2)
-	maxY := float32(GameHeight) - (viewHeight / 2)
+	maxX := float32(g.tilemap.Width) - (viewWidth / 2)
+	maxY := float32(g.tilemap.Height) - (viewHeight / 2)
 
 	// Calculate base camera position
 	targetX := float32(math.Max(float64(minX),
@@ -527,7 +978,7 @@ func (g *Game) IsPlayerInRange(targetX, targetY float32, targetWidth, targetHeig
 
 	// Only return true if player is in range AND mouse is over the object
 	return distSquared <= range_*range_ && r.CheckCollisionPointRec(
-		r.GetScreenToWorld2D(r.GetMousePosition(), r.Camera2D{
+		r.GetScreenToWorld2D(g.input.GetMousePosition(), r.Camera2D{
 			Target:   g.camera.Target,
 			Offset:   g.camera.Offset,
 			Rotation: g.camera.Rotation,
@@ -544,79 +995,24 @@ func (g *Game) IsPlayerInRange(targetX, targetY float32, targetWidth, targetHeig
 
 // Update HandleTreeClicks method
 func (g *Game) HandleTreeClicks(worldPos r.Vector2) {
-	var remainingTrees []*Tree
 	interactionRange := float32(50)
 
-	for _, tree := range g.trees {
+	for _, tree := range QueryPoint[*Tree](g.world, worldPos) {
 		if g.IsPlayerInRange(tree.X, tree.Y, tree.Width, tree.Height, interactionRange) {
-			if tree.OnClick(worldPos, g.player.HarvestDamage) {
-				explosionX := tree.X + float32(tree.Width)/2
-				explosionY := tree.Y + float32(tree.Height)/2
-				g.particles.SpawnExplosion(r.Yellow, 20, explosionX, explosionY)
-				// Add dropped item
-				g.droppedItems = append(g.droppedItems, NewDroppedItem(
-					tree.X+float32(tree.Width-16)/2,
-					tree.Y+float32(tree.Height-16)/2,
-					"assets/tree-pickup.png",
-					"Strange Log",
-				))
-				tree.Unload()
-			} else {
-				remainingTrees = append(remainingTrees, tree)
-				g.particles.SpawnDamageNumber(
-					fmt.Sprintf("-%d", g.player.HarvestDamage),
-					tree.X+float32(tree.Width)/2,
-					tree.Y-10,
-				)
-			}
-		} else {
-			remainingTrees = append(remainingTrees, tree)
+			g.harvest(tree)
 		}
 	}
-	g.trees = remainingTrees
 }
 
 // Update HandleStoneClicks method
 func (g *Game) HandleStoneClicks(worldPos r.Vector2) {
-	var remainingStones []*Stone
 	interactionRange := float32(50)
 
-	for _, stone := range g.stones {
+	for _, stone := range QueryPoint[*Stone](g.world, worldPos) {
 		if g.IsPlayerInRange(stone.X, stone.Y, stone.Width, stone.Height, interactionRange) {
-			if stone.OnClick(worldPos, g.player.HarvestDamage) {
-				explosionX := stone.X + float32(stone.Width)/2
-				explosionY := stone.Y + float32(stone.Height)/2
-				g.particles.SpawnExplosion(r.Yellow, 20, explosionX, explosionY)
-				// Add appropriate dropped item based on stone type
-				if stone.IsGolden {
-					g.droppedItems = append(g.droppedItems, NewDroppedItem(
-						stone.X+float32(stone.Width-16)/2,
-						stone.Y+float32(stone.Height-16)/2,
-						"assets/gold-nugget.png",
-						"Golden Nugget",
-					))
-				} else {
-					g.droppedItems = append(g.droppedItems, NewDroppedItem(
-						stone.X+float32(stone.Width-16)/2,
-						stone.Y+float32(stone.Height-16)/2,
-						"assets/stone-pickup.png",
-						"Stone Fragment",
-					))
-				}
-				stone.Unload()
-			} else {
-				remainingStones = append(remainingStones, stone)
-				g.particles.SpawnDamageNumber(
-					fmt.Sprintf("-%d", g.player.HarvestDamage),
-					stone.X+float32(stone.Width)/2,
-					stone.Y-10,
-				)
-			}
-		} else {
-			remainingStones = append(remainingStones, stone)
+			g.harvest(stone)
 		}
 	}
-	g.stones = remainingStones
 }
 
 // CheckItemPickups handles item collection
@@ -625,46 +1021,61 @@ func (g *Game) CheckItemPickups() {
 		return
 	}
 
-	var remainingItems []*DroppedItem
-	for _, item := range g.droppedItems {
-		if item.CheckCollision(g.player.GetBounds()) {
-			g.inventory.Items = append(g.inventory.Items, item.Name)
-			g.inventory.ItemCounts[item.Name]++
-			g.loadItemIcon(item.Name, item.ImagePath)
-			item.Unload()
-		} else {
-			remainingItems = append(remainingItems, item)
+	playerBounds := g.player.GetBounds()
+	for _, item := range QueryRect[*DroppedItem](g.world, playerBounds) {
+		// Full stacks stay on the ground
+		if g.inventory.Add(item.Item, 1) > 0 {
+			g.world.Remove(item)
 		}
 	}
-	g.droppedItems = remainingItems
 
-	// When harvesting trees
-	for i, tree := range g.trees {
-		if tree != nil && r.CheckCollisionRecs(g.player.GetBounds(), tree.GetBounds()) {
-			if r.IsKeyPressed(r.KeyE) {
-				tree.Health -= int32(g.player.HarvestDamage)
-				if tree.Health <= 0 {
-					g.particles.SpawnExplosion(r.Red, 15, tree.X, tree.Y)
-					tree.Unload()
-					g.trees = append(g.trees[:i], g.trees[i+1:]...)
-				}
-			}
+	if !g.controls.IsPressed(g.input, ActionInteract) {
+		return
+	}
+
+	// When harvesting trees and stones
+	acted := false
+	for _, tree := range QueryRect[*Tree](g.world, playerBounds) {
+		g.harvest(tree)
+		acted = true
+	}
+	for _, stone := range QueryRect[*Stone](g.world, playerBounds) {
+		g.harvest(stone)
+		acted = true
+	}
+
+	// Open and close the doors in reach
+	reach := r.Rectangle{
+		X:      playerBounds.X - doorReach,
+		Y:      playerBounds.Y - doorReach,
+		Width:  playerBounds.Width + doorReach*2,
+		Height: playerBounds.Height + doorReach*2,
+	}
+	for _, door := range QueryRect[*Door](g.world, reach) {
+		if message := door.Interact(g.inventory, playerBounds); message != "" {
+			g.particles.SpawnDamageNumber(message, door.X, door.Y-10)
 		}
+		acted = true
+	}
+	if acted {
+		return
 	}
 
-	// When harvesting stones
-	for i, stone := range g.stones {
-		if stone != nil && r.CheckCollisionRecs(g.player.GetBounds(), stone.GetBounds()) {
-			if r.IsKeyPressed(r.KeyE) {
-				stone.Health -= int32(g.player.HarvestDamage)
-				if stone.Health <= 0 {
-					g.particles.SpawnExplosion(r.Red, 15, stone.X, stone.Y)
-					stone.Unload()
-					g.stones = append(g.stones[:i], g.stones[i+1:]...)
-				}
-			}
+	// Otherwise pick up the closest trap in reach
+	var closest *Deployable
+	closestDistance := float32(math.MaxFloat32)
+	center := boundsCenter(playerBounds)
+	for _, deployable := range QueryRect[*Deployable](g.world, reach) {
+		if !deployable.CanPickUp() {
+			continue
+		}
+		if distance := r.Vector2Distance(boundsCenter(deployable.GetBounds()), center); distance < closestDistance {
+			closest, closestDistance = deployable, distance
 		}
 	}
+	if closest != nil && g.inventory.Add(closest.Def.Item, 1) > 0 {
+		g.world.Remove(closest)
+	}
 }
 
 // Draw renders the game
@@ -679,6 +1090,7 @@ func (g *Game) Draw() {
 
 	case StatePlaying:
 		r.HideCursor()
+		r.ClearBackground(g.currentDimension().BackgroundColor())
 
 		camera := r.Camera2D{
 			Target:   g.camera.Target,
@@ -689,48 +1101,49 @@ func (g *Game) Draw() {
 
 		r.BeginMode2D(camera)
 
-		// Draw game world border
-		r.DrawRectangleLines(0, 0, GameWidth, GameHeight, r.DarkGray)
-
-		// Draw all game objects
-		for _, portal := range g.portals {
-			portal.Draw(g.debug)
+		// Draw the tiles the camera sees, then the game world border
+		view := r.Rectangle{
+			X:      camera.Target.X - camera.Offset.X/camera.Zoom,
+			Y:      camera.Target.Y - camera.Offset.Y/camera.Zoom,
+			Width:  float32(r.GetScreenWidth()) / camera.Zoom,
+			Height: float32(r.GetScreenHeight()) / camera.Zoom,
 		}
-		for _, sprite := range g.sprites {
-			sprite.Draw()
+		for layer := 0; layer < tileLayerCount; layer++ {
+			g.tilemap.Draw(layer, view)
 		}
-		for _, tree := range g.trees {
-			tree.Draw(g.debug)
-		}
-		for _, stone := range g.stones {
-			stone.Draw(g.debug)
+		r.DrawRectangleLines(0, 0, g.tilemap.Width, g.tilemap.Height, r.DarkGray)
+
+		// Draw all game objects, with the player between the objects and the actors
+		g.world.DrawLayer(LayerGround, g.debug)
+
+		// Show where enemies cannot walk
+		if g.debug && g.nav != nil {
+			g.nav.Draw()
 		}
-		for _, item := range g.droppedItems {
-			item.Draw(g.debug)
+
+		// Draw the walls of a boss arena
+		if g.boss != nil {
+			r.DrawRectangleLinesEx(g.boss.Arena, 2, r.Maroon)
 		}
+		g.world.DrawLayer(LayerObjects, g.debug)
 		if g.player != nil {
 			g.player.Draw(g.debug, camera)
 			if sword, ok := g.player.CurrentWeapon.(*Sword); ok {
 				sword.Draw(g.player, camera, g.debug)
 			}
 		}
-		for _, enemy := range g.enemies {
-			enemy.Draw(g.debug)
-		}
-		if g.merchant != nil {
-			g.merchant.Draw(g.gameFont, g.inventory, camera, g.debug)
-		}
+		g.world.DrawLayer(LayerActors, g.debug)
 
 		// Draw particles
 		g.particles.Draw()
 
-		// Draw dummies
-		for _, dummy := range g.dummies {
-			dummy.Draw(g.debug)
-		}
-
 		r.EndMode2D()
 
+		// Draw the shop window over the world
+		if g.merchant != nil {
+			g.merchant.DrawShop(g.gameFont, g.inventory)
+		}
+
 		// Draw UI elements
 		g.DrawUI()
 
@@ -759,7 +1172,7 @@ func (g *Game) Draw() {
 	}
 
 	// Draw cursor
-	mousePos := r.GetMousePosition()
+	mousePos := g.input.GetMousePosition()
 	r.DrawTextureEx(g.cursorTex, mousePos, 0, 1, r.White)
 
 	r.EndDrawing()
@@ -787,6 +1200,14 @@ func (g *Game) DrawUI() {
 		healthText := fmt.Sprintf("%d/%d", g.player.CurrentHealth, g.player.MaxHealth)
 		textWidth := r.MeasureText(healthText, 20)
 		r.DrawText(healthText, barX+barWidth/2-textWidth/2, barY+2, 20, r.White)
+
+		// Draw the mana bar over the health bar
+		manaY := barY - 10
+		r.DrawRectangle(barX, manaY, barWidth, 6, r.DarkGray)
+		r.DrawRectangle(barX, manaY, int32(g.player.Mana/g.player.MaxMana*float32(barWidth)), 6, r.Blue)
+
+		g.drawSpellSlots()
+		g.drawDeploySlot()
 	}
 
 	// Draw timer
@@ -796,12 +1217,40 @@ func (g *Game) DrawUI() {
 	textWidth := r.MeasureText(timerText, 30)
 	r.DrawText(timerText, 400-textWidth/2, 10, 30, r.White)
 
+	// Draw the wave and its countdown
+	waveText := g.waves.Status(g.threats())
+	waveWidth := r.MeasureText(waveText, 20)
+	r.DrawText(waveText, 790-waveWidth, 10, 20, r.White)
+
+	// Draw the dimension and its goal
+	if g.inventory != nil {
+		dim := g.currentDimension()
+		goalText := dim.GoalText(g.progress, g.inventory)
+		if g.boss != nil {
+			goalText = "Defeat " + g.boss.Name
+		} else if g.progress.Complete && g.dimension+1 < len(dimensions) {
+			goalText = "Find the exit portal"
+		}
+		nameWidth := r.MeasureText(dim.Name, 20)
+		r.DrawText(dim.Name, 400-nameWidth/2, 45, 20, r.White)
+		goalWidth := r.MeasureText(goalText, 10)
+		r.DrawText(goalText, 400-goalWidth/2, 68, 10, r.LightGray)
+	}
+
+	// Draw the boss health bar
+	if g.boss != nil {
+		g.boss.DrawHealthBar()
+	}
+
 	// Draw debug info
 	g.DrawDebugInfo()
 
-	// Draw inventory and crafting
-	g.inventory.Draw(g.gameFont)
+	// Draw inventory, crafting and the grimoire
+	g.inventory.Draw(g.gameFont, g.input.GetMousePosition())
 	g.crafting.Draw(g.gameFont, g.inventory)
+	if g.player != nil {
+		g.grimoire.Draw(g.gameFont, g.player.Spells, g.input.GetMousePosition())
+	}
 
 	// Draw toolbar slots with weapons
 	for i := range g.toolbarSlots {
@@ -856,6 +1305,85 @@ func (g *Game) DrawUI() {
 	}
 }
 
+// drawSpellSlots draws the spell bound to each cast key next to the toolbar, dimmed
+// while it cools down and tinted blue while the player lacks the mana for it
+func (g *Game) drawSpellSlots() {
+	book := g.player.Spells
+	slotSize := float32(40)
+	toolbarEnd := g.toolbarSlots[len(g.toolbarSlots)-1]
+	for slot := 0; slot < spellSlotCount; slot++ {
+		slotRect := r.Rectangle{
+			X:      toolbarEnd.X + toolbarEnd.Width + 15 + float32(slot)*(slotSize+5),
+			Y:      toolbarEnd.Y + toolbarEnd.Height - slotSize,
+			Width:  slotSize,
+			Height: slotSize,
+		}
+		r.DrawRectangleRec(slotRect, r.Gray)
+
+		if def := book.Spell(slot); def != nil {
+			plan := book.Plan(def)
+			initials := def.Initials()
+			textWidth := r.MeasureText(initials, 20)
+			r.DrawText(initials, int32(slotRect.X+slotRect.Width/2)-textWidth/2, int32(slotRect.Y)+14, 20, r.White)
+
+			// A dot for every socketed modifier
+			for i := range book.Modifiers[def.ID] {
+				r.DrawCircle(int32(slotRect.X+slotRect.Width)-5-int32(i)*6, int32(slotRect.Y+slotRect.Height)-5, 2, r.Purple)
+			}
+
+			if g.player.Mana < plan.Spell.Mana {
+				r.DrawRectangleRec(slotRect, r.ColorAlpha(r.Blue, 0.4))
+			}
+			if cooldown := book.Cooldowns[def.ID]; cooldown > 0 && plan.Spell.Cooldown > 0 {
+				shade := slotRect
+				shade.Height *= min(1, cooldown/plan.Spell.Cooldown)
+				r.DrawRectangleRec(shade, r.ColorAlpha(r.Black, 0.6))
+			}
+		}
+
+		// Outline the slot being cast
+		if book.Casting != nil && book.Slots[slot] == book.Casting.Spell.ID {
+			r.DrawRectangleLinesEx(slotRect, 2, r.SkyBlue)
+		} else {
+			r.DrawRectangleLinesEx(slotRect, 1, r.DarkGray)
+		}
+		r.DrawText(g.controls.KeyLabel(CastSpellAction(slot)), int32(slotRect.X)+3, int32(slotRect.Y)+3, 10, r.White)
+	}
+}
+
+// drawDeploySlot draws the deployable the deploy key places left of the toolbar, with the charges left of it
+func (g *Game) drawDeploySlot() {
+	def := g.readyDeployable()
+	if def == nil {
+		return
+	}
+
+	slotSize := float32(40)
+	toolbarStart := g.toolbarSlots[0]
+	slotRect := r.Rectangle{
+		X:      toolbarStart.X - 15 - slotSize,
+		Y:      toolbarStart.Y + toolbarStart.Height - slotSize,
+		Width:  slotSize,
+		Height: slotSize,
+	}
+	r.DrawRectangleRec(slotRect, r.Gray)
+	r.DrawRectangleLinesEx(slotRect, 1, r.DarkGray)
+
+	icon := g.inventory.ItemIcons[def.Item]
+	r.DrawTexturePro(
+		icon,
+		r.Rectangle{X: 0, Y: 0, Width: float32(icon.Width), Height: float32(icon.Height)},
+		r.Rectangle{X: slotRect.X + 4, Y: slotRect.Y + 4, Width: slotSize - 8, Height: slotSize - 8},
+		r.Vector2{X: 0, Y: 0},
+		0,
+		r.White,
+	)
+
+	count := fmt.Sprintf("%d", g.inventory.ItemCounts[def.Item])
+	r.DrawText(count, int32(slotRect.X+slotRect.Width)-r.MeasureText(count, 10)-3, int32(slotRect.Y+slotRect.Height)-12, 10, r.White)
+	r.DrawText(g.controls.KeyLabel(ActionDeploy), int32(slotRect.X)+3, int32(slotRect.Y)+3, 10, r.White)
+}
+
 // DrawDebugInfo renders debug information
 func (g *Game) DrawDebugInfo() {
 	if !g.debug {
@@ -869,55 +1397,28 @@ func (g *Game) DrawDebugInfo() {
 		r.DrawTextEx(g.gameFont, fmt.Sprintf("Exp: %d/%d", g.player.Experience, g.player.NextLevelExp), r.Vector2{X: 10, Y: 70}, 20, 1, r.Green)
 	}
 	r.DrawTextEx(g.gameFont, fmt.Sprintf("Camera: %.0f, %.0f", g.camera.Target.X, g.camera.Target.Y), r.Vector2{X: 10, Y: 90}, 20, 1, r.Green)
-	r.DrawTextEx(g.gameFont, fmt.Sprintf("Trees: %d", len(g.trees)), r.Vector2{X: 10, Y: 110}, 20, 1, r.Green)
+	r.DrawTextEx(g.gameFont, fmt.Sprintf("Trees: %d", len(Query[*Tree](g.world))), r.Vector2{X: 10, Y: 110}, 20, 1, r.Green)
+	if g.rng != nil {
+		r.DrawTextEx(g.gameFont, fmt.Sprintf("Seed: %d", g.rng.Seed), r.Vector2{X: 10, Y: 130}, 20, 1, r.Green)
+	}
 }
 
 // Cleanup frees resources
 func (g *Game) Cleanup() {
-	for _, sprite := range g.sprites {
-		sprite.Unload()
-	}
-	for _, tree := range g.trees {
-		tree.Unload()
-	}
-	for _, item := range g.droppedItems {
-		item.Unload()
-	}
-	if g.player != nil {
-		g.player.Unload()
-	}
-	for _, enemy := range g.enemies {
-		enemy.Unload()
-	}
-	for _, stone := range g.stones {
-		stone.Unload()
-	}
-	r.UnloadTexture(g.cursorTex)
-	r.ShowCursor()
-	r.CloseWindow()
-	g.inventory.Cleanup()
+	g.unloadWorld()
+	unloadTexture(g.cursorTex)
 	if g.menu != nil {
 		g.menu.Cleanup()
 	}
-	for _, portal := range g.portals {
-		portal.Unload()
-	}
-	if g.merchant != nil {
-		g.merchant.Unload()
-	}
-	if raygun, ok := g.player.CurrentWeapon.(*RayGun); ok {
-		raygun.Unload()
-	}
-	for _, dummy := range g.dummies {
-		dummy.Unload()
-	}
 
-	// Show the system cursor again
-	r.ShowCursor()
-}
+	// Everything should have been released by now
+	assets.ReportLeaks()
 
-func init() {
-	rand.Seed(time.Now().UnixNano())
+	// Show the system cursor again and close the window last, once nothing needs the GPU
+	if !headless {
+		r.ShowCursor()
+		r.CloseWindow()
+	}
 }
 
 func main() {
@@ -929,76 +1430,32 @@ func main() {
 		game.Update()
 		game.Draw()
 	}
-}
-
-func (g *Game) loadItemIcon(name, imagePath string) {
-	if _, exists := g.inventory.ItemIcons[name]; !exists {
-		g.inventory.ItemIcons[name] = r.LoadTexture(imagePath)
-	}
-}
 
-// Add method to handle inventory item usage
-func (g *Game) UpdateUI() {
-	if g.inventory.IsOpen {
-		if g.inventory.LastUsedItem == "Health Potion" {
-			g.player.Heal(25)
-			g.inventory.LastUsedItem = "" // Clear the last used item
+	// Keep the run when the window is closed mid-game
+	if game.state == StatePlaying {
+		if err := game.SaveGame(SaveFile); err != nil {
+			fmt.Println("Warning: Could not save game:", err)
 		}
 	}
 }
 
-// Add this helper function to check for object overlaps
-func (g *Game) IsPositionOccupied(bounds r.Rectangle, padding float32) bool {
-	// Check trees if they exist
-	if g.trees != nil {
-		for _, tree := range g.trees {
-			if tree != nil { // Also check if individual tree is not nil
-				treeBounds := r.Rectangle{
-					X:      tree.X - padding,
-					Y:      tree.Y - padding,
-					Width:  float32(tree.Width) + padding*2,
-					Height: float32(tree.Height) + padding*2,
-				}
-				if r.CheckCollisionRecs(bounds, treeBounds) {
-					return true
-				}
-			}
-		}
+// unloadWorld frees every object of the current run so a new one can be created or loaded
+func (g *Game) unloadWorld() {
+	g.world.Unload()
+	if g.player != nil {
+		g.player.Unload()
 	}
-
-	// Check stones if they exist
-	if g.stones != nil {
-		for _, stone := range g.stones {
-			if stone != nil { // Also check if individual stone is not nil
-				stoneBounds := r.Rectangle{
-					X:      stone.X - padding,
-					Y:      stone.Y - padding,
-					Width:  float32(stone.Width) + padding*2,
-					Height: float32(stone.Height) + padding*2,
-				}
-				if r.CheckCollisionRecs(bounds, stoneBounds) {
-					return true
-				}
-			}
-		}
+	if g.inventory != nil {
+		g.inventory.Cleanup()
 	}
 
-	// Check portals if they exist
-	if g.portals != nil {
-		for _, portal := range g.portals {
-			if portal != nil { // Also check if individual portal is not nil
-				portalBounds := r.Rectangle{
-					X:      portal.X - padding,
-					Y:      portal.Y - padding,
-					Width:  float32(portal.Width) + padding*2,
-					Height: float32(portal.Height) + padding*2,
-				}
-				if r.CheckCollisionRecs(bounds, portalBounds) {
-					return true
-				}
-			}
-		}
+	if g.tilemap != nil {
+		g.tilemap.Unload()
 	}
 
-	return false
+	g.player = nil
+	g.inventory = nil
+	g.merchant = nil
+	g.boss = nil
+	g.tilemap = nil
 }
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Ghost tuning
+const (
+	ghostTeleportInterval = 3.5  // Seconds between teleports
+	ghostTeleportDistance = 50.0 // How far a teleport goes
+	ghostPhaseTime        = 0.8  // How long a ghost stays phased after teleporting
+	ghostAlpha            = 0.85
+	ghostPhasedAlpha      = 0.3
+)
+
+// ghostColor is the base tint of ghosts, made see-through while phased
+var ghostColor = r.Color{R: 200, G: 230, B: 255, A: 255}
+
+// GhostTraits is the state of an enemy that phases through obstacles
+type GhostTraits struct {
+	Vulnerable    map[DamageType]bool // The only damage types that hurt it
+	InObstacle    bool                // Set by the game while the ghost drifts through a tree or stone
+	PhaseTimer    float32             // Time left phased after a teleport
+	TeleportTimer float32
+}
+
+// NewGhost creates an enemy that drifts through obstacles and only fears energy and spells
+func NewGhost(x, y float32, target *Player, rng *rand.Rand) *Enemy {
+	enemy := NewEnemy(x, y, target, rng)
+	enemy.Kind = "ghost"
+	enemy.Speed = 35.0
+	enemy.MaxHealth = 3
+	enemy.CurrentHealth = 3
+	enemy.DropChance = 0.5
+	enemy.Tint = r.ColorAlpha(ghostColor, ghostAlpha)
+	enemy.Steering = ghostSteering
+	enemy.Ghost = &GhostTraits{
+		Vulnerable:    map[DamageType]bool{DamageEnergy: true, DamageSpell: true},
+		TeleportTimer: ghostTeleportInterval * (0.5 + rng.Float32()),
+	}
+	return enemy
+}
+
+// IsPhased reports whether the ghost is out of reach of every attack
+func (ghost *GhostTraits) IsPhased() bool {
+	return ghost.InObstacle || ghost.PhaseTimer > 0
+}
+
+// Resists reports whether a hit passes through the ghost
+func (ghost *GhostTraits) Resists(damageType DamageType) bool {
+	return ghost.IsPhased() || !ghost.Vulnerable[damageType]
+}
+
+// updateGhost counts down the phase and teleports the ghost a short way around the player
+func (e *Enemy) updateGhost(deltaTime float32) {
+	ghost := e.Ghost
+	if ghost.PhaseTimer > 0 {
+		ghost.PhaseTimer -= deltaTime
+	}
+
+	ghost.TeleportTimer -= deltaTime
+	if ghost.TeleportTimer <= 0 {
+		ghost.TeleportTimer = ghostTeleportInterval
+
+		// Blink roughly towards the player, never straight at them
+		toPlayer := math.Atan2(float64(e.Player.Y-e.Y), float64(e.Player.X-e.X))
+		angle := toPlayer + (e.Rand.Float64()-0.5)*math.Pi
+		e.X += float32(math.Cos(angle)) * ghostTeleportDistance
+		e.Y += float32(math.Sin(angle)) * ghostTeleportDistance
+		ghost.PhaseTimer = ghostPhaseTime
+	}
+
+	alpha := float32(ghostAlpha)
+	if ghost.IsPhased() {
+		alpha = ghostPhasedAlpha
+	}
+	e.Tint = r.ColorAlpha(ghostColor, alpha)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestGhostOnlyFearsEnergyAndSpells(t *testing.T) {
+	headless = true
+	player := &Player{X: 200, Y: 200, Width: 10, Height: 10}
+	ghost := NewGhost(100, 100, player, rand.New(rand.NewSource(1)))
+	defer ghost.Unload()
+
+	for _, damageType := range []DamageType{DamageSlash, DamagePierce, DamageHarvest} {
+		if ghost.TakeDamage(DamageEvent{Amount: 1, Type: damageType}) {
+			t.Errorf("damage type %d hurt the ghost", damageType)
+		}
+	}
+	if !ghost.TakeDamage(DamageEvent{Amount: 1, Type: DamageEnergy}) {
+		t.Fatal("energy did not hurt the ghost")
+	}
+
+	// Inside an obstacle nothing reaches it
+	ghost.DamageCooldown = 0
+	ghost.Ghost.InObstacle = true
+	if ghost.TakeDamage(DamageEvent{Amount: 1, Type: DamageSpell}) {
+		t.Fatal("phased ghost was hurt")
+	}
+}
+
+func TestGhostTeleportsAndPhases(t *testing.T) {
+	headless = true
+	player := &Player{X: 200, Y: 200, Width: 10, Height: 10}
+	ghost := NewGhost(100, 100, player, rand.New(rand.NewSource(1)))
+	defer ghost.Unload()
+
+	ghost.Ghost.TeleportTimer = 0
+	x, y := ghost.X, ghost.Y
+	ghost.updateGhost(1.0 / 60)
+	if ghost.X == x && ghost.Y == y {
+		t.Fatal("ghost did not teleport")
+	}
+	if !ghost.Ghost.IsPhased() {
+		t.Fatal("ghost is not phased after teleporting")
+	}
+	for i := 0; i < int(ghostPhaseTime*60)+1; i++ {
+		ghost.updateGhost(1.0 / 60)
+	}
+	if ghost.Ghost.IsPhased() {
+		t.Fatal("ghost stayed phased")
+	}
+}
+
+func TestGhostDriftsThroughTrees(t *testing.T) {
+	g := NewHeadlessGame(NewScriptedInput(), 15)
+	defer g.Cleanup()
+	clearObstacles(g)
+
+	// A wall of trees between the ghost and the player
+	for y := float32(200); y < 400; y += 20 {
+		g.world.Add(NewTreeAt(300, y, 5))
+	}
+	g.player.X, g.player.Y = 400, 300
+	ghost := NewGhost(200, 300, g.player, g.rng.Derive("test", 0))
+	ghost.Ghost.TeleportTimer = 1e9
+	g.world.Add(ghost)
+
+	crossed := false
+	for i := 0; i < 10*60 && ghost.X < 340; i++ {
+		g.player.X, g.player.Y = 400, 300
+		g.Step(1.0 / 60)
+		crossed = crossed || ghost.Ghost.InObstacle
+	}
+	if ghost.X < 340 {
+		t.Fatalf("ghost got stuck at %v,%v", ghost.X, ghost.Y)
+	}
+	if !crossed {
+		t.Fatal("ghost went around the trees instead of through them")
+	}
+}
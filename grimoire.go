@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Grimoire is the window where modifiers are socketed into spells
+type Grimoire struct {
+	IsOpen    bool
+	Selected  string // Spell whose sockets are shown
+	Dimension int    // Index of the dimension the player is in, set by the game every frame
+}
+
+// NewGrimoire creates a closed grimoire
+func NewGrimoire() *Grimoire {
+	return &Grimoire{}
+}
+
+// grimoireCloseButton closes the grimoire
+var grimoireCloseButton = r.Rectangle{X: 350, Y: 460, Width: 100, Height: 30}
+
+// grimoireSpellRow returns where the row-th known spell is listed
+func grimoireSpellRow(row int) r.Rectangle {
+	return r.Rectangle{X: 120, Y: 180 + float32(row)*30, Width: 160, Height: 24}
+}
+
+// grimoireSocket returns where a socket of the selected spell is drawn
+func grimoireSocket(socket int) r.Rectangle {
+	return r.Rectangle{X: 300, Y: 180 + float32(socket)*30, Width: 170, Height: 24}
+}
+
+// grimoireModifierRow returns where the row-th unlocked modifier is listed
+func grimoireModifierRow(row int) r.Rectangle {
+	return r.Rectangle{X: 490, Y: 180 + float32(row)*30, Width: 190, Height: 24}
+}
+
+// selected returns the spell whose sockets are shown, the first known one once the selection is forgotten
+func (gr *Grimoire) selected(book *Spellbook) string {
+	if !book.Knows(gr.Selected) && len(book.Known) > 0 {
+		return book.Known[0]
+	}
+	return gr.Selected
+}
+
+// Update handles clicks on the grimoire while it is open. Clicking a spell shows its
+// sockets, clicking a modifier sockets it into that spell and clicking a socket empties it.
+func (gr *Grimoire) Update(input Input, book *Spellbook) {
+	if !gr.IsOpen {
+		return
+	}
+	gr.Selected = gr.selected(book)
+	if !input.IsMouseButtonPressed(0) {
+		return
+	}
+
+	mousePoint := input.GetMousePosition()
+	row := 0
+	for _, id := range book.Known {
+		if _, exists := spells.Lookup(id); !exists {
+			continue
+		}
+		if r.CheckCollisionPointRec(mousePoint, grimoireSpellRow(row)) {
+			gr.Selected = id
+			return
+		}
+		row++
+	}
+
+	if spell, exists := spells.Lookup(gr.Selected); exists {
+		for socket := range book.Modifiers[spell.ID] {
+			if socket < modifierSockets && r.CheckCollisionPointRec(mousePoint, grimoireSocket(socket)) {
+				book.Unsocket(spell.ID, socket)
+				return
+			}
+		}
+
+		row = 0
+		for _, def := range modifiers.All() {
+			if !def.IsUnlocked(gr.Dimension) {
+				continue
+			}
+			if r.CheckCollisionPointRec(mousePoint, grimoireModifierRow(row)) {
+				if book.CanSocket(spell.ID, def.ID, gr.Dimension) {
+					book.Socket(spell.ID, def.ID, gr.Dimension)
+				}
+				return
+			}
+			row++
+		}
+	}
+
+	if r.CheckCollisionPointRec(mousePoint, grimoireCloseButton) {
+		gr.IsOpen = false
+	}
+}
+
+// Draw renders the grimoire, explaining the modifier under the mouse
+func (gr *Grimoire) Draw(gameFont r.Font, book *Spellbook, mousePoint r.Vector2) {
+	if !gr.IsOpen {
+		return
+	}
+
+	// Draw semi-transparent background
+	r.DrawRectangle(0, 0, 800, 600, r.ColorAlpha(r.Black, 0.5))
+
+	// Draw grimoire panel
+	r.DrawRectangle(100, 100, 600, 400, r.DarkGray)
+	r.DrawTextEx(gameFont, "Grimoire", r.Vector2{X: 120, Y: 110}, 30, 1, r.White)
+
+	selected := gr.selected(book)
+
+	// Draw the known spells
+	r.DrawTextEx(gameFont, "Spells", r.Vector2{X: 120, Y: 150}, 20, 1, r.White)
+	listed := 0
+	for _, id := range book.Known {
+		def, exists := spells.Lookup(id)
+		if !exists {
+			continue
+		}
+		row := grimoireSpellRow(listed)
+		if id == selected {
+			r.DrawRectangleRec(row, r.ColorAlpha(r.Purple, 0.5))
+		}
+		r.DrawTextEx(gameFont, def.Name, r.Vector2{X: row.X + 4, Y: row.Y + 4}, 16, 1, r.SkyBlue)
+		listed++
+	}
+
+	spell, exists := spells.Lookup(selected)
+	if !exists {
+		r.DrawTextEx(gameFont, "Learn a spell first", r.Vector2{X: 120, Y: 180}, 16, 1, r.LightGray)
+		drawGrimoireClose(gameFont)
+		return
+	}
+
+	// Draw the selected spell's sockets
+	var hovered *ModifierDef
+	r.DrawTextEx(gameFont, "Sockets", r.Vector2{X: 300, Y: 150}, 20, 1, r.White)
+	socketed := book.Modifiers[spell.ID]
+	for socket := 0; socket < modifierSockets; socket++ {
+		box := grimoireSocket(socket)
+		r.DrawRectangleLinesEx(box, 1, r.Purple)
+		if socket >= len(socketed) {
+			r.DrawTextEx(gameFont, "Empty", r.Vector2{X: box.X + 4, Y: box.Y + 4}, 16, 1, r.Gray)
+			continue
+		}
+		name := socketed[socket]
+		if def, exists := modifiers.Lookup(name); exists {
+			name = def.Name
+			if r.CheckCollisionPointRec(mousePoint, box) {
+				hovered = def
+			}
+		}
+		r.DrawTextEx(gameFont, name, r.Vector2{X: box.X + 4, Y: box.Y + 4}, 16, 1, r.White)
+	}
+
+	// Sum up what the sockets make of the spell
+	plan := book.Plan(spell)
+	summary := []string{
+		fmt.Sprintf("Mana %.0f  Damage %d", plan.Spell.Mana, plan.Spell.Damage),
+		fmt.Sprintf("Cooldown %.1fs", plan.Spell.Cooldown),
+		fmt.Sprintf("Load %d/%d", plan.Cost(), spellCostCap),
+	}
+	for i, line := range summary {
+		r.DrawTextEx(gameFont, line, r.Vector2{X: 300, Y: 280 + float32(i)*20}, 16, 1, r.LightGray)
+	}
+
+	// Draw the unlocked modifiers, the ones that fit the next socket are green
+	r.DrawTextEx(gameFont, "Modifiers", r.Vector2{X: 490, Y: 150}, 20, 1, r.White)
+	listed = 0
+	locked := 0
+	for _, def := range modifiers.All() {
+		if !def.IsUnlocked(gr.Dimension) {
+			locked++
+			continue
+		}
+		row := grimoireModifierRow(listed)
+		if book.CanSocket(spell.ID, def.ID, gr.Dimension) {
+			r.DrawRectangleRec(row, r.Green)
+		} else {
+			r.DrawRectangleRec(row, r.Gray)
+		}
+		r.DrawTextEx(gameFont, def.Name, r.Vector2{X: row.X + 4, Y: row.Y + 4}, 16, 1, r.White)
+		if r.CheckCollisionPointRec(mousePoint, row) {
+			hovered = def
+		}
+		listed++
+	}
+	if locked > 0 {
+		hint := fmt.Sprintf("%d more in higher dimensions", locked)
+		r.DrawTextEx(gameFont, hint, r.Vector2{X: 490, Y: grimoireModifierRow(listed).Y}, 16, 1, r.LightGray)
+	}
+
+	// Explain the modifier under the mouse
+	if hovered != nil {
+		r.DrawTextEx(gameFont, hovered.Description, r.Vector2{X: 120, Y: 420}, 16, 1, r.White)
+	}
+
+	drawGrimoireClose(gameFont)
+}
+
+// drawGrimoireClose draws the close button
+func drawGrimoireClose(gameFont r.Font) {
+	r.DrawRectangleRec(grimoireCloseButton, r.Gray)
+	r.DrawTextEx(gameFont, "Close", r.Vector2{X: 370, Y: 465}, 20, 1, r.White)
+}
@@ -0,0 +1,18 @@
+package main
+
+// headless is set when the game runs without a window, so nothing touches the GPU
+var headless bool
+
+// NewHeadlessGame creates a game that is already playing the given world seed and
+// never opens a window. Advance it with Step and drive it through the given input.
+func NewHeadlessGame(input Input, seed int64) *Game {
+	headless = true
+
+	game := NewGame()
+	game.input = input
+	game.clock = &FixedClock{Delta: 1.0 / 60.0}
+	game.InitializeGameObjects(seed)
+	game.state = StatePlaying
+
+	return game
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+func TestHeadlessPlayerWalks(t *testing.T) {
+	input := NewScriptedInput()
+	g := NewHeadlessGame(input, 42)
+	defer g.Cleanup()
+
+	x := g.player.X
+	input.PressKey(r.KeyD)
+	for i := 0; i < 60; i++ {
+		g.Step(1.0 / 60)
+	}
+	if g.player.X <= x {
+		t.Fatalf("player did not walk right: %v -> %v", x, g.player.X)
+	}
+	if g.state != StatePlaying {
+		t.Fatalf("state = %v, want playing", g.state)
+	}
+}
+
+func TestScriptedInputPressLastsOneFrame(t *testing.T) {
+	input := NewScriptedInput()
+	input.PressKey(r.KeyE)
+	if !input.IsKeyPressed(r.KeyE) || !input.IsKeyDown(r.KeyE) {
+		t.Fatal("key not pressed and held after PressKey")
+	}
+
+	input.EndFrame()
+	if input.IsKeyPressed(r.KeyE) {
+		t.Fatal("key still pressed after the frame ended")
+	}
+	if !input.IsKeyDown(r.KeyE) {
+		t.Fatal("key no longer held after the frame ended")
+	}
+
+	// Pressing a held key again is not a new press
+	input.PressKey(r.KeyE)
+	if input.IsKeyPressed(r.KeyE) {
+		t.Fatal("held key pressed again")
+	}
+	input.ReleaseKey(r.KeyE)
+	if input.IsKeyDown(r.KeyE) {
+		t.Fatal("key held after ReleaseKey")
+	}
+}
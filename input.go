@@ -0,0 +1,181 @@
+package main
+
+import (
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Input abstracts keyboard, mouse and gamepad state so the simulation can run without a window
+type Input interface {
+	IsKeyDown(key int32) bool
+	IsKeyPressed(key int32) bool
+	GetCharPressed() int32
+	IsMouseButtonDown(button int32) bool
+	IsMouseButtonPressed(button int32) bool
+	GetMousePosition() r.Vector2
+	GetMouseWheelMove() float32
+	IsGamepadAvailable(gamepad int32) bool
+	IsGamepadButtonDown(gamepad, button int32) bool
+	IsGamepadButtonPressed(gamepad, button int32) bool
+	GetGamepadAxisMovement(gamepad, axis int32) float32
+	EndFrame()
+}
+
+// RaylibInput reads input straight from the raylib window
+type RaylibInput struct{}
+
+func (RaylibInput) IsKeyDown(key int32) bool               { return r.IsKeyDown(key) }
+func (RaylibInput) IsKeyPressed(key int32) bool            { return r.IsKeyPressed(key) }
+func (RaylibInput) GetCharPressed() int32                  { return r.GetCharPressed() }
+func (RaylibInput) IsMouseButtonDown(button int32) bool    { return r.IsMouseButtonDown(button) }
+func (RaylibInput) IsMouseButtonPressed(button int32) bool { return r.IsMouseButtonPressed(button) }
+func (RaylibInput) GetMousePosition() r.Vector2            { return r.GetMousePosition() }
+func (RaylibInput) GetMouseWheelMove() float32             { return r.GetMouseWheelMove() }
+func (RaylibInput) IsGamepadAvailable(gamepad int32) bool  { return r.IsGamepadAvailable(gamepad) }
+func (RaylibInput) IsGamepadButtonDown(gamepad, button int32) bool {
+	return r.IsGamepadButtonDown(gamepad, button)
+}
+func (RaylibInput) IsGamepadButtonPressed(gamepad, button int32) bool {
+	return r.IsGamepadButtonPressed(gamepad, button)
+}
+func (RaylibInput) GetGamepadAxisMovement(gamepad, axis int32) float32 {
+	return r.GetGamepadAxisMovement(gamepad, axis)
+}
+
+// EndFrame is a no-op, raylib polls its own events in EndDrawing
+func (RaylibInput) EndFrame() {}
+
+// ScriptedInput is an Input whose state is set by code, for tests and tools.
+// It simulates a single gamepad, reported as gamepad 0 while GamepadConnected is set.
+type ScriptedInput struct {
+	KeysDown          map[int32]bool
+	KeysPressed       map[int32]bool
+	Chars             []int32 // Characters typed this frame, in order
+	ButtonsDown       map[int32]bool
+	ButtonsPressed    map[int32]bool
+	MousePosition     r.Vector2
+	WheelMove         float32
+	GamepadConnected  bool
+	PadButtonsDown    map[int32]bool
+	PadButtonsPressed map[int32]bool
+	PadAxes           map[int32]float32
+}
+
+// NewScriptedInput creates a scripted input with nothing held
+func NewScriptedInput() *ScriptedInput {
+	return &ScriptedInput{
+		KeysDown:          make(map[int32]bool),
+		KeysPressed:       make(map[int32]bool),
+		ButtonsDown:       make(map[int32]bool),
+		ButtonsPressed:    make(map[int32]bool),
+		PadButtonsDown:    make(map[int32]bool),
+		PadButtonsPressed: make(map[int32]bool),
+		PadAxes:           make(map[int32]float32),
+	}
+}
+
+func (s *ScriptedInput) IsKeyDown(key int32) bool               { return s.KeysDown[key] }
+func (s *ScriptedInput) IsKeyPressed(key int32) bool            { return s.KeysPressed[key] }
+func (s *ScriptedInput) IsMouseButtonDown(button int32) bool    { return s.ButtonsDown[button] }
+func (s *ScriptedInput) IsMouseButtonPressed(button int32) bool { return s.ButtonsPressed[button] }
+func (s *ScriptedInput) GetMousePosition() r.Vector2            { return s.MousePosition }
+func (s *ScriptedInput) GetMouseWheelMove() float32             { return s.WheelMove }
+
+// GetCharPressed returns the next character typed this frame, or 0 once there are none left
+func (s *ScriptedInput) GetCharPressed() int32 {
+	if len(s.Chars) == 0 {
+		return 0
+	}
+	char := s.Chars[0]
+	s.Chars = s.Chars[1:]
+	return char
+}
+
+func (s *ScriptedInput) IsGamepadAvailable(gamepad int32) bool {
+	return gamepad == 0 && s.GamepadConnected
+}
+func (s *ScriptedInput) IsGamepadButtonDown(gamepad, button int32) bool {
+	return s.IsGamepadAvailable(gamepad) && s.PadButtonsDown[button]
+}
+func (s *ScriptedInput) IsGamepadButtonPressed(gamepad, button int32) bool {
+	return s.IsGamepadAvailable(gamepad) && s.PadButtonsPressed[button]
+}
+func (s *ScriptedInput) GetGamepadAxisMovement(gamepad, axis int32) float32 {
+	if !s.IsGamepadAvailable(gamepad) {
+		return 0
+	}
+	return s.PadAxes[axis]
+}
+
+// PressKey starts holding a key, reporting it as pressed for the next frame only
+func (s *ScriptedInput) PressKey(key int32) {
+	if !s.KeysDown[key] {
+		s.KeysPressed[key] = true
+	}
+	s.KeysDown[key] = true
+}
+
+// ReleaseKey stops holding a key
+func (s *ScriptedInput) ReleaseKey(key int32) {
+	delete(s.KeysDown, key)
+	delete(s.KeysPressed, key)
+}
+
+// TypeText types characters for the next frame
+func (s *ScriptedInput) TypeText(text string) {
+	for _, char := range text {
+		s.Chars = append(s.Chars, char)
+	}
+}
+
+// PressButton starts holding a mouse button, reporting it as pressed for the next frame only
+func (s *ScriptedInput) PressButton(button int32) {
+	if !s.ButtonsDown[button] {
+		s.ButtonsPressed[button] = true
+	}
+	s.ButtonsDown[button] = true
+}
+
+// ReleaseButton stops holding a mouse button
+func (s *ScriptedInput) ReleaseButton(button int32) {
+	delete(s.ButtonsDown, button)
+	delete(s.ButtonsPressed, button)
+}
+
+// PressPadButton starts holding a gamepad button, reporting it as pressed for the next frame only
+func (s *ScriptedInput) PressPadButton(button int32) {
+	if !s.PadButtonsDown[button] {
+		s.PadButtonsPressed[button] = true
+	}
+	s.PadButtonsDown[button] = true
+}
+
+// ReleasePadButton stops holding a gamepad button
+func (s *ScriptedInput) ReleasePadButton(button int32) {
+	delete(s.PadButtonsDown, button)
+	delete(s.PadButtonsPressed, button)
+}
+
+// SetPadAxis tilts a gamepad stick axis, from -1 to 1
+func (s *ScriptedInput) SetPadAxis(axis int32, value float32) {
+	s.PadAxes[axis] = value
+}
+
+// MoveMouse places the cursor at a screen position
+func (s *ScriptedInput) MoveMouse(x, y float32) {
+	s.MousePosition = r.Vector2{X: x, Y: y}
+}
+
+// EndFrame clears the one-frame pressed states, typed characters and wheel movement
+func (s *ScriptedInput) EndFrame() {
+	s.Chars = nil
+	for key := range s.KeysPressed {
+		delete(s.KeysPressed, key)
+	}
+	for button := range s.ButtonsPressed {
+		delete(s.ButtonsPressed, button)
+	}
+	for button := range s.PadButtonsPressed {
+		delete(s.PadButtonsPressed, button)
+	}
+	s.WheelMove = 0
+}
@@ -2,35 +2,84 @@ package main
 
 import (
 	"fmt"
-	"math/rand"
 	"sort"
 
 	r "github.com/gen2brain/raylib-go/raylib"
 )
 
-// Inventory represents the player's inventory
+// Inventory represents the player's inventory. Items are keyed by their ID in the item registry.
 type Inventory struct {
 	IsOpen       bool
-	Items        []string
+	Items        []string // Item IDs in the order they were first picked up
 	ItemCounts   map[string]int
 	ItemIcons    map[string]r.Texture2D
-	LastUsedItem string
+	LastUsedItem string // Item the last use produced, such as the loot of a goodie bag
+	BagsOpened   int    // Number of goodie bags opened, picks the loot of the next one
 	player       *Player
+	rng          *RandomStreams
 }
 
 // NewInventory creates a new inventory instance
-func NewInventory(player *Player) *Inventory {
+func NewInventory(player *Player, rng *RandomStreams) *Inventory {
 	return &Inventory{
 		IsOpen:     false,
 		Items:      make([]string, 0),
 		ItemCounts: make(map[string]int),
 		ItemIcons:  make(map[string]r.Texture2D),
 		player:     player,
+		rng:        rng,
 	}
 }
 
-// Draw renders the inventory UI
-func (inv *Inventory) Draw(gameFont r.Font) {
+// inventoryCloseButton closes the inventory window
+var inventoryCloseButton = r.Rectangle{X: 350, Y: 450, Width: 100, Height: 30}
+
+// useButton returns where the use button of the row-th listed item is
+func useButton(row int) r.Rectangle {
+	return r.Rectangle{X: 420, Y: float32(160 + row*40), Width: 50, Height: 25}
+}
+
+// spellRow returns where the row-th known spell is listed, under the weapons
+func (inv *Inventory) spellRow(row int) r.Rectangle {
+	y := 160 + len(inv.player.Weapons)*40 + 40 + row*24
+	return r.Rectangle{X: 550, Y: float32(y), Width: 180, Height: 20}
+}
+
+// Update handles clicks on the inventory window while it is open. Clicking a
+// usable item uses it and clicking a known spell moves it to the next slot.
+func (inv *Inventory) Update(input Input) {
+	if !inv.IsOpen || !input.IsMouseButtonPressed(0) {
+		return
+	}
+
+	mousePoint := input.GetMousePosition()
+	for row, item := range inv.GetSortedItems() {
+		if items.Get(item).Use != nil && r.CheckCollisionPointRec(mousePoint, useButton(row)) {
+			inv.UseItem(item)
+			return
+		}
+	}
+
+	book := inv.player.Spells
+	row := 0
+	for _, id := range book.Known {
+		if _, exists := spells.Lookup(id); !exists {
+			continue
+		}
+		if r.CheckCollisionPointRec(mousePoint, inv.spellRow(row)) {
+			book.Bind((book.SlotOf(id)+1)%spellSlotCount, id)
+			return
+		}
+		row++
+	}
+
+	if r.CheckCollisionPointRec(mousePoint, inventoryCloseButton) {
+		inv.IsOpen = false
+	}
+}
+
+// Draw renders the inventory UI, describing the item under the mouse
+func (inv *Inventory) Draw(gameFont r.Font, mousePoint r.Vector2) {
 	if !inv.IsOpen {
 		return
 	}
@@ -52,8 +101,10 @@ func (inv *Inventory) Draw(gameFont r.Font) {
 	// Draw items in left column
 	y := 160
 	iconSize := int32(20)
-	for _, item := range inv.GetSortedItems() {
+	var hovered *ItemDef
+	for row, item := range inv.GetSortedItems() {
 		if count, exists := inv.ItemCounts[item]; exists && count > 0 {
+			def := items.Get(item)
 			if icon, hasIcon := inv.ItemIcons[item]; hasIcon {
 				r.DrawTexturePro(
 					icon,
@@ -64,11 +115,16 @@ func (inv *Inventory) Draw(gameFont r.Font) {
 					r.White,
 				)
 			}
-			r.DrawTextEx(gameFont, fmt.Sprintf("%s x%d", item, count), r.Vector2{X: leftX + float32(iconSize) + 5, Y: float32(y)}, 20, 1, r.White)
+			r.DrawTextEx(gameFont, fmt.Sprintf("%s x%d", def.Name, count), r.Vector2{X: leftX + float32(iconSize) + 5, Y: float32(y)}, 20, 1, r.White)
+
+			// Remember the hovered row to show its description
+			if r.CheckCollisionPointRec(mousePoint, r.Rectangle{X: leftX, Y: float32(y), Width: 340, Height: 25}) {
+				hovered = def
+			}
 
 			// Add USE button for usable items
-			if item == "Goodie Bag" || item == "Health Potion" {
-				useBtn := r.Rectangle{X: leftX + 350, Y: float32(y), Width: 50, Height: 25}
+			if def.Use != nil {
+				useBtn := useButton(row)
 				r.DrawRectangleRec(useBtn, r.Green)
 
 				// Center the USE text
@@ -76,19 +132,16 @@ func (inv *Inventory) Draw(gameFont r.Font) {
 				textX := useBtn.X + (useBtn.Width-textWidth)/2
 				textY := useBtn.Y + (useBtn.Height-20)/2 // 20 is the font size
 				r.DrawTextEx(gameFont, "USE", r.Vector2{X: textX, Y: textY}, 20, 1, r.White)
-
-				// Handle click
-				if r.IsMouseButtonPressed(0) {
-					mousePoint := r.GetMousePosition()
-					if r.CheckCollisionPointRec(mousePoint, useBtn) {
-						inv.UseItem(item)
-					}
-				}
 			}
 			y += 40
 		}
 	}
 
+	// Describe the hovered item at the bottom of the panel
+	if hovered != nil && hovered.Description != "" {
+		r.DrawTextEx(gameFont, hovered.Description, r.Vector2{X: 70, Y: 420}, 20, 1, r.LightGray)
+	}
+
 	// Update right column X positions
 	rightX := float32(550)
 	// Draw weapons in right column
@@ -125,107 +178,141 @@ func (inv *Inventory) Draw(gameFont r.Font) {
 		y += 40
 	}
 
-	// Draw close button with click handling
-	closeBtn := r.Rectangle{X: 350, Y: 450, Width: 100, Height: 30}
-	r.DrawRectangleRec(closeBtn, r.Gray)
-	r.DrawTextEx(gameFont, "Close", r.Vector2{X: 375, Y: 455}, 20, 1, r.White)
-
-	// Check close button click
-	if r.IsMouseButtonPressed(0) {
-		mousePoint := r.GetMousePosition()
-		if r.CheckCollisionPointRec(mousePoint, closeBtn) {
-			inv.IsOpen = false
+	// Draw the known spells under the weapons, clicking one moves it to the next slot
+	if book := inv.player.Spells; len(book.Known) > 0 {
+		r.DrawTextEx(gameFont, "Spells", r.Vector2{X: rightX, Y: float32(y)}, 30, 1, r.White)
+		row := 0
+		for _, id := range book.Known {
+			def, exists := spells.Lookup(id)
+			if !exists {
+				continue
+			}
+			slot := book.SlotOf(id)
+			label := def.Name
+			if slot >= 0 {
+				label = fmt.Sprintf("%d: %s", slot+1, def.Name)
+			}
+			spellRow := inv.spellRow(row)
+			r.DrawTextEx(gameFont, label, r.Vector2{X: spellRow.X, Y: spellRow.Y}, 16, 1, r.SkyBlue)
+			row++
 		}
 	}
+
+	// Draw close button
+	r.DrawRectangleRec(inventoryCloseButton, r.Gray)
+	r.DrawTextEx(gameFont, "Close", r.Vector2{X: 375, Y: 455}, 20, 1, r.White)
 }
 
-// LoadIcon loads an item icon if it doesn't exist
-func (inv *Inventory) LoadIcon(name, imagePath string) {
-	if _, exists := inv.ItemIcons[name]; !exists {
-		inv.ItemIcons[name] = r.LoadTexture(imagePath)
+// LoadIcon loads an item's icon if it isn't loaded yet
+func (inv *Inventory) LoadIcon(id string) {
+	if _, exists := inv.ItemIcons[id]; !exists {
+		inv.ItemIcons[id] = loadTexture(items.Get(id).Icon)
 	}
 }
 
 // Cleanup frees resources
 func (inv *Inventory) Cleanup() {
 	for _, texture := range inv.ItemIcons {
-		r.UnloadTexture(texture)
+		unloadTexture(texture)
 	}
 }
 
-// Update UseItem method to handle both Goodie Bag and Health Potion
-func (inv *Inventory) UseItem(itemName string) {
-	if inv.ItemCounts[itemName] > 0 {
-		inv.LastUsedItem = itemName
-		switch itemName {
-		case "Goodie Bag":
-			// Remove one goodie bag
-			inv.ItemCounts[itemName]--
-			if inv.ItemCounts[itemName] <= 0 {
-				delete(inv.ItemCounts, itemName)
-				// Remove from items list
-				for j, item := range inv.Items {
-					if item == itemName {
-						inv.Items = append(inv.Items[:j], inv.Items[j+1:]...)
-						break
-					}
-				}
-			}
+// HasRoom reports whether count more of an item fit under its max stack
+func (inv *Inventory) HasRoom(id string, count int) bool {
+	return inv.ItemCounts[id]+count <= items.Get(id).MaxStack
+}
 
-			// Add random loot
-			lootTable := []string{
-				"Stone Fragment",
-				"Strange Log",
-				"Golden Nugget",
-				"Pickaxe",
-			}
-			randomLoot := lootTable[rand.Intn(len(lootTable))]
-			inv.Items = append(inv.Items, randomLoot)
-			inv.ItemCounts[randomLoot]++
-			inv.LoadIcon(randomLoot, getIconPath(randomLoot))
-			inv.LastUsedItem = randomLoot
-
-		case "Health Potion":
-			// Remove one health potion
-			inv.ItemCounts[itemName]--
-			if inv.ItemCounts[itemName] <= 0 {
-				delete(inv.ItemCounts, itemName)
+// Add puts up to count of an item in the inventory, stopping at its max stack.
+// It returns how many were added.
+func (inv *Inventory) Add(id string, count int) int {
+	room := items.Get(id).MaxStack - inv.ItemCounts[id]
+	if count > room {
+		count = room
+	}
+	if count <= 0 {
+		return 0
+	}
+
+	if inv.ItemCounts[id] == 0 {
+		inv.Items = append(inv.Items, id)
+	}
+	inv.ItemCounts[id] += count
+	inv.LoadIcon(id)
+	return count
+}
+
+// Remove takes count of an item out of the inventory, doing nothing if there are not enough
+func (inv *Inventory) Remove(id string, count int) bool {
+	if inv.ItemCounts[id] < count {
+		return false
+	}
+
+	inv.ItemCounts[id] -= count
+	if inv.ItemCounts[id] <= 0 {
+		delete(inv.ItemCounts, id)
+		for j, item := range inv.Items {
+			if item == id {
+				inv.Items = append(inv.Items[:j], inv.Items[j+1:]...)
+				break
 			}
-			// Heal player (this will be handled in game.go)
 		}
 	}
+	return true
 }
 
-// Add this helper function to get the correct icon path:
-func getIconPath(itemName string) string {
-	switch itemName {
-	case "Health Potion":
-		return "assets/health-potion.png"
-	case "Stone Fragment":
-		return "assets/stone-pickup.png"
-	case "Strange Log":
-		return "assets/tree-pickup.png"
-	case "Golden Nugget":
-		return "assets/gold-nugget.png"
-	case "Pickaxe":
-		return "assets/pickaxe.png"
-	default:
-		return "assets/" + itemName + ".png"
+// UseItem applies an item's use effect and consumes one of it
+func (inv *Inventory) UseItem(id string) {
+	def := items.Get(id)
+	if def.Use == nil || inv.ItemCounts[id] <= 0 {
+		return
+	}
+
+	switch def.Use.Type {
+	case EffectHeal:
+		inv.player.Heal(def.Use.Amount)
+		inv.LastUsedItem = id
+
+	case EffectLoot:
+		// Each bag rolls from its own generator, so loot does not depend on when bags are opened
+		loot := inv.rng.Derive("goodie-bag", inv.BagsOpened)
+		inv.BagsOpened++
+		randomLoot := def.Use.Loot[loot.Intn(len(def.Use.Loot))]
+
+		// Keep the bag closed if the loot does not fit
+		if !inv.HasRoom(randomLoot, 1) {
+			inv.LastUsedItem = ""
+			return
+		}
+		inv.Add(randomLoot, 1)
+		inv.LastUsedItem = randomLoot
+
+	case EffectLearn:
+		// Keep the tome of a spell the player already knows
+		if !inv.player.Spells.Learn(def.Use.Spell) {
+			inv.LastUsedItem = ""
+			return
+		}
+		inv.LastUsedItem = id
 	}
+
+	inv.Remove(id, 1)
 }
 
-// Add this method to Inventory struct
+// GetSortedItems returns the IDs of held items sorted by display name
 func (inv *Inventory) GetSortedItems() []string {
-	var items []string
-	for item := range inv.ItemCounts {
-		items = append(items, item)
+	var ids []string
+	for id := range inv.ItemCounts {
+		ids = append(ids, id)
 	}
-	sort.Strings(items)
-	return items
+	sort.Slice(ids, func(i, j int) bool {
+		return items.Get(ids[i]).Name < items.Get(ids[j]).Name
+	})
+	return ids
 }
 
-// Add Pickaxe to the item icons
+// LoadDefaultIcons loads the icon of every known item, so the UI can show items before they are picked up
 func (inv *Inventory) LoadDefaultIcons() {
-	inv.LoadIcon("Pickaxe", "assets/pickaxe.png")
-	// ... other icons ...
+	for _, def := range items.All() {
+		inv.LoadIcon(def.ID)
+	}
 }
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// ItemsFile holds the definition of every item in the game
+const ItemsFile = "assets/items.json"
+
+// CurrencyItem is the item the merchant takes as payment
+const CurrencyItem = "gold_coin"
+
+// items is the item registry shared by every subsystem
+var items = NewItemRegistry()
+
+// ItemCategory groups items in the UI
+type ItemCategory string
+
+const (
+	CategoryMaterial   ItemCategory = "material"
+	CategoryConsumable ItemCategory = "consumable"
+	CategoryTool       ItemCategory = "tool"
+	CategoryCurrency   ItemCategory = "currency"
+)
+
+// Use effect types
+const (
+	EffectHeal  = "heal"  // Restores Amount health
+	EffectLoot  = "loot"  // Gives one random item of Loot
+	EffectLearn = "learn" // Teaches the spell Spell, tomes of known spells are kept
+)
+
+// ItemEffect is what happens when an item is used. Using an item consumes one of it.
+type ItemEffect struct {
+	Type   string   `json:"type"`
+	Amount int32    `json:"amount,omitempty"`
+	Loot   []string `json:"loot,omitempty"`
+	Spell  string   `json:"spell,omitempty"`
+}
+
+// ItemDef describes one kind of item
+type ItemDef struct {
+	ID           string       `json:"id"`
+	Name         string       `json:"name"`
+	Icon         string       `json:"icon"`
+	Description  string       `json:"description"`
+	MaxStack     int          `json:"max_stack"`
+	Category     ItemCategory `json:"category"`
+	Price        int          `json:"price,omitempty"`         // Merchant price in CurrencyItem, 0 when not sold
+	HarvestBonus int32        `json:"harvest_bonus,omitempty"` // Extra harvest damage while carried
+	Use          *ItemEffect  `json:"use,omitempty"`
+}
+
+// ItemRegistry looks item definitions up by ID
+type ItemRegistry struct {
+	defs  map[string]*ItemDef
+	order []*ItemDef // In file order
+}
+
+// NewItemRegistry creates an empty registry
+func NewItemRegistry() *ItemRegistry {
+	return &ItemRegistry{defs: make(map[string]*ItemDef)}
+}
+
+// LoadItems reads and validates an item definition file
+func LoadItems(path string) (*ItemRegistry, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var defs []*ItemDef
+	if err := json.Unmarshal(raw, &defs); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	reg := NewItemRegistry()
+	for _, def := range defs {
+		if def.ID == "" {
+			return nil, fmt.Errorf("%s: item %q has no id", path, def.Name)
+		}
+		if _, exists := reg.defs[def.ID]; exists {
+			return nil, fmt.Errorf("%s: item %q is defined twice", path, def.ID)
+		}
+		if def.Name == "" {
+			def.Name = def.ID
+		}
+		if def.MaxStack <= 0 {
+			def.MaxStack = 99
+		}
+		reg.defs[def.ID] = def
+		reg.order = append(reg.order, def)
+	}
+
+	// Effects may name items defined later in the file, so they are checked last
+	for _, def := range reg.order {
+		if err := reg.validateEffect(def); err != nil {
+			return nil, fmt.Errorf("%s: item %q: %w", path, def.ID, err)
+		}
+	}
+	return reg, nil
+}
+
+// validateEffect checks an item's use effect is known and only names real items
+func (reg *ItemRegistry) validateEffect(def *ItemDef) error {
+	if def.Use == nil {
+		return nil
+	}
+	switch def.Use.Type {
+	case EffectHeal:
+		if def.Use.Amount <= 0 {
+			return fmt.Errorf("heal amount must be positive")
+		}
+	case EffectLoot:
+		if len(def.Use.Loot) == 0 {
+			return fmt.Errorf("loot table is empty")
+		}
+		for _, id := range def.Use.Loot {
+			if _, exists := reg.defs[id]; !exists {
+				return fmt.Errorf("loot names unknown item %q", id)
+			}
+		}
+	case EffectLearn:
+		if _, exists := spells.Lookup(def.Use.Spell); !exists {
+			return fmt.Errorf("teaches unknown spell %q", def.Use.Spell)
+		}
+	default:
+		return fmt.Errorf("unknown use effect %q", def.Use.Type)
+	}
+	return nil
+}
+
+// Lookup returns the definition of an item, if it exists
+func (reg *ItemRegistry) Lookup(id string) (*ItemDef, bool) {
+	def, exists := reg.defs[id]
+	return def, exists
+}
+
+// Get returns the definition of an item. Unknown items, such as ones from a save
+// made with a newer item file, get a stand-in named after their ID.
+func (reg *ItemRegistry) Get(id string) *ItemDef {
+	if def, exists := reg.defs[id]; exists {
+		return def
+	}
+	return &ItemDef{ID: id, Name: id, MaxStack: 99, Category: CategoryMaterial}
+}
+
+// All returns every item in file order
+func (reg *ItemRegistry) All() []*ItemDef {
+	return reg.order
+}
+
+// ForSale returns the items the merchant sells, in file order
+func (reg *ItemRegistry) ForSale() []*ItemDef {
+	var forSale []*ItemDef
+	for _, def := range reg.order {
+		if def.Price > 0 {
+			forSale = append(forSale, def)
+		}
+	}
+	return forSale
+}
+
+// loadItems replaces the registry with the item file, keeping the old one if it is broken
+func loadItems(path string) {
+	loaded, err := LoadItems(path)
+	if err != nil {
+		fmt.Println("Warning: Could not load items:", err)
+		return
+	}
+	items = loaded
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestFile writes content to a file in a fresh temporary directory and returns its path
+func writeTestFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestItemFileLoads(t *testing.T) {
+	loadDataFiles()
+	reg, err := LoadItems(ItemsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reg.All()) != 13 {
+		t.Fatalf("got %d items, want 13", len(reg.All()))
+	}
+	if bonus := reg.Get("pickaxe").HarvestBonus; bonus != 3 {
+		t.Fatalf("pickaxe harvest bonus = %d, want 3", bonus)
+	}
+	if _, exists := reg.Lookup(CurrencyItem); !exists {
+		t.Fatal("currency item is not defined")
+	}
+	for _, def := range reg.ForSale() {
+		if def.Price <= 0 {
+			t.Fatalf("%s is for sale without a price", def.ID)
+		}
+	}
+}
+
+func TestUnknownItemGetsStandIn(t *testing.T) {
+	def := NewItemRegistry().Get("mystery")
+	if def.Name != "mystery" || def.MaxStack != 99 {
+		t.Fatalf("stand-in = %+v", def)
+	}
+}
+
+func TestLoadItemsRejectsBadFiles(t *testing.T) {
+	files := map[string]string{
+		"missing id":   `[{"name": "Nameless"}]`,
+		"duplicate":    `[{"id": "a"}, {"id": "a"}]`,
+		"unknown loot": `[{"id": "a", "use": {"type": "loot", "loot": ["b"]}}]`,
+		"empty heal":   `[{"id": "a", "use": {"type": "heal"}}]`,
+		"unknown use":  `[{"id": "a", "use": {"type": "teleport"}}]`,
+		"not json":     `[{"id": }]`,
+	}
+	for name, content := range files {
+		if _, err := LoadItems(writeTestFile(t, "items.json", content)); err == nil {
+			t.Errorf("%s: loaded without an error", name)
+		}
+	}
+	if _, err := LoadItems(filepath.Join(t.TempDir(), "none.json")); err == nil {
+		t.Error("missing file loaded without an error")
+	}
+}
+
+func TestItemsStackAndApply(t *testing.T) {
+	g := NewHeadlessGame(NewScriptedInput(), 5)
+	defer g.Cleanup()
+
+	// A pickaxe stacks to one and makes harvesting stronger while held
+	g.inventory.Add("pickaxe", 3)
+	if count := g.inventory.ItemCounts["pickaxe"]; count != 1 {
+		t.Fatalf("holding %d pickaxes, want 1", count)
+	}
+	g.Step(1.0 / 60)
+	if g.player.HarvestDamage != 4 {
+		t.Fatalf("harvest damage = %d, want 4", g.player.HarvestDamage)
+	}
+
+	g.player.CurrentHealth = 10
+	g.inventory.Add("health_potion", 1)
+	g.inventory.UseItem("health_potion")
+	if g.player.CurrentHealth != 35 || g.inventory.ItemCounts["health_potion"] != 0 {
+		t.Fatalf("health %d, potions left %d", g.player.CurrentHealth, g.inventory.ItemCounts["health_potion"])
+	}
+}
+
+func TestLoadSaveWithItemNames(t *testing.T) {
+	path := writeTestFile(t, "savegame.json", `{
+		"version": 2,
+		"seed": 5,
+		"player": {"x": 1, "y": 2, "max_health": 100, "current_health": 50, "weapons": [{"kind": "sword"}]},
+		"inventory": {"Goodie Bag": 2, "Gold Coin": 4},
+		"dropped_items": [{"x": 5, "y": 5, "name": "Strange Log", "image_path": "assets/tree-pickup.png"}],
+		"merchant": {"x": 500, "y": 200, "shop_items": [{"name": "Health Potion", "price": 7, "icon_path": "assets/health-potion.png"}]}
+	}`)
+
+	g := NewHeadlessGame(NewScriptedInput(), 5)
+	defer g.Cleanup()
+	if err := g.LoadGame(path); err != nil {
+		t.Fatal(err)
+	}
+	if g.inventory.ItemCounts["goodie_bag"] != 2 || g.inventory.ItemCounts["gold_coin"] != 4 {
+		t.Errorf("inventory = %v", g.inventory.ItemCounts)
+	}
+	if g.merchant.ShopItems[0].Item != "health_potion" {
+		t.Errorf("shop sells %q", g.merchant.ShopItems[0].Item)
+	}
+	if dropped := Query[*DroppedItem](g.world); len(dropped) != 1 || dropped[0].Item != "strange_log" {
+		t.Errorf("dropped items not renamed")
+	}
+}
@@ -6,10 +6,24 @@ import (
 	r "github.com/gen2brain/raylib-go/raylib"
 )
 
+// MenuChoice is what the player picked on the main menu
+type MenuChoice int
+
+const (
+	MenuNone MenuChoice = iota
+	MenuPlay
+	MenuContinue
+)
+
 type MainMenu struct {
-	playButton    r.Rectangle
-	settingButton r.Rectangle
-	bookIcon      struct {
+	playButton     r.Rectangle
+	continueButton r.Rectangle
+	settingButton  r.Rectangle
+	seedField      r.Rectangle
+	seedText       string
+	seedFocused    bool
+	hasSave        bool
+	bookIcon       struct {
 		Texture  r.Texture2D
 		Position r.Rectangle
 		IsOpen   bool
@@ -17,13 +31,16 @@ type MainMenu struct {
 }
 
 func (m *MainMenu) Initialize() {
-	texture := r.LoadTexture("assets/book.png")
-	if texture.ID == 0 {
-		fmt.Println("Warning: Could not load book.png, using default texture")
-		return
+	m.hasSave = HasSave(SaveFile)
+	m.seedText = fmt.Sprintf("%d", RandomSeed())
+	m.seedFocused = false
+
+	// Release the book from a previous visit to the menu
+	if m.bookIcon.Texture.ID != 0 {
+		unloadTexture(m.bookIcon.Texture)
 	}
 
-	m.bookIcon.Texture = texture
+	m.bookIcon.Texture = loadTexture("assets/book.png")
 	m.bookIcon.Position = r.Rectangle{
 		X:      750,
 		Y:      550,
@@ -34,43 +51,82 @@ func (m *MainMenu) Initialize() {
 
 func NewMainMenu(_ r.Font) *MainMenu {
 	return &MainMenu{
+		seedField: r.Rectangle{
+			X:      300,
+			Y:      252,
+			Width:  200,
+			Height: 36,
+		},
 		playButton: r.Rectangle{
 			X:      300,
 			Y:      300,
 			Width:  200,
 			Height: 50,
 		},
-		settingButton: r.Rectangle{
+		continueButton: r.Rectangle{
 			X:      300,
 			Y:      370,
 			Width:  200,
 			Height: 50,
 		},
+		settingButton: r.Rectangle{
+			X:      300,
+			Y:      440,
+			Width:  200,
+			Height: 50,
+		},
 	}
 }
 
-func (m *MainMenu) Update() bool {
-	if r.IsMouseButtonPressed(0) {
-		mousePos := r.GetMousePosition()
+// Seed returns the world seed typed into the menu
+func (m *MainMenu) Seed() int64 {
+	return ParseSeed(m.seedText)
+}
+
+// Update handles typing into the seed field and clicks on the menu, returning what the player picked
+func (m *MainMenu) Update(input Input) MenuChoice {
+	// Type into the seed field while it has focus
+	if m.seedFocused {
+		for char := input.GetCharPressed(); char > 0; char = input.GetCharPressed() {
+			if char >= 32 && char < 127 && len(m.seedText) < 18 {
+				m.seedText += string(rune(char))
+			}
+		}
+		if input.IsKeyPressed(r.KeyBackspace) && len(m.seedText) > 0 {
+			m.seedText = m.seedText[:len(m.seedText)-1]
+		}
+		if input.IsKeyPressed(r.KeyEnter) {
+			m.seedFocused = false
+		}
+	}
+
+	if input.IsMouseButtonPressed(0) {
+		mousePos := input.GetMousePosition()
 
 		if m.bookIcon.IsOpen {
 			m.bookIcon.IsOpen = false
-			return false
+			return MenuNone
 		}
 
+		m.seedFocused = r.CheckCollisionPointRec(mousePos, m.seedField)
+
 		if r.CheckCollisionPointRec(mousePos, m.playButton) {
-			return true
+			return MenuPlay
+		}
+
+		if m.hasSave && r.CheckCollisionPointRec(mousePos, m.continueButton) {
+			return MenuContinue
 		}
 
 		if r.CheckCollisionPointRec(mousePos, m.settingButton) {
-			return true
+			return MenuPlay
 		}
 
 		if r.CheckCollisionPointRec(mousePos, m.bookIcon.Position) {
 			m.bookIcon.IsOpen = true
 		}
 	}
-	return false
+	return MenuNone
 }
 
 func (m *MainMenu) Draw() {
@@ -83,6 +139,17 @@ func (m *MainMenu) Draw() {
 	titleX := 400 - titleWidth/2 // 400 is half of window width (800/2)
 	r.DrawText(titleText, titleX, 200, titleSize, r.White)
 
+	// Draw the seed field, outlined while typing
+	r.DrawRectangleRec(m.seedField, r.DarkGray)
+	if m.seedFocused {
+		r.DrawRectangleLinesEx(m.seedField, 2, r.White)
+	}
+	seedText := "SEED: " + m.seedText
+	if m.seedFocused && int(r.GetTime()*2)%2 == 0 {
+		seedText += "_"
+	}
+	r.DrawText(seedText, int32(m.seedField.X+10), int32(m.seedField.Y+8), 20, r.White)
+
 	// Draw and center PLAI text
 	r.DrawRectangleRec(m.playButton, r.DarkGray)
 	plaiText := "PLAI"
@@ -90,6 +157,17 @@ func (m *MainMenu) Draw() {
 	plaiTextX := int32(m.playButton.X) + (int32(m.playButton.Width)-plaiTextWidth)/2
 	r.DrawText(plaiText, plaiTextX, int32(m.playButton.Y+10), 30, r.White)
 
+	// Draw and center KONTINUE text, greyed out without a save
+	continueColor := r.White
+	if !m.hasSave {
+		continueColor = r.Gray
+	}
+	r.DrawRectangleRec(m.continueButton, r.DarkGray)
+	kontinueText := "KONTINUE"
+	kontinueTextWidth := r.MeasureText(kontinueText, 30)
+	kontinueTextX := int32(m.continueButton.X) + (int32(m.continueButton.Width)-kontinueTextWidth)/2
+	r.DrawText(kontinueText, kontinueTextX, int32(m.continueButton.Y+10), 30, continueColor)
+
 	// Draw and center SETTINGZ text
 	r.DrawRectangleRec(m.settingButton, r.DarkGray)
 	settingzText := "SETTINGZ"
@@ -134,7 +212,7 @@ func (m *MainMenu) Draw() {
 		r.DrawText("WASD - Move", 170, 310, 20, r.White)
 		r.DrawText("E - Inventory", 170, 340, 20, r.White)
 		r.DrawText("C - Crafting", 170, 370, 20, r.White)
-		r.DrawText("Click - Interact", 170, 400, 20, r.White)
+		r.DrawText("Click/F - Interact", 170, 400, 20, r.White)
 		r.DrawText("ESC - Close/Exit", 170, 430, 20, r.White)
 
 		r.DrawText("Click anywhere to close", 270, 470, 20, r.Gray)
@@ -142,7 +220,7 @@ func (m *MainMenu) Draw() {
 }
 
 func (m *MainMenu) Cleanup() {
-	r.UnloadTexture(m.bookIcon.Texture)
+	unloadTexture(m.bookIcon.Texture)
 }
 
 func DrawTextBoxed(font r.Font, text string, rec r.Rectangle, fontSize float32, spacing float32, wordWrap bool, tint r.Color) {
@@ -6,10 +6,10 @@ import (
 	r "github.com/gen2brain/raylib-go/raylib"
 )
 
+// ShopItem is one item the merchant sells, named by its item ID
 type ShopItem struct {
-	Name     string
-	Price    int
-	IconPath string
+	Item  string `json:"item"`
+	Price int    `json:"price"`
 }
 
 type Merchant struct {
@@ -17,47 +17,45 @@ type Merchant struct {
 	Y         float32
 	Width     int32
 	Height    int32
-	Texture   r.Texture2D
+	Anim      *Animator
 	IsOpen    bool
 	ShopItems []ShopItem
 	ItemIcons map[string]r.Texture2D
 }
 
+// NewMerchant creates a merchant selling every item with a price
 func NewMerchant(x, y float32) *Merchant {
-	return &Merchant{
+	merchant := &Merchant{
 		X:         x,
 		Y:         y,
 		Width:     48,
 		Height:    48,
-		Texture:   r.LoadTexture("assets/merchant.png"),
+		Anim:      NewAnimator("merchant"),
 		IsOpen:    false,
 		ItemIcons: make(map[string]r.Texture2D),
-		ShopItems: []ShopItem{
-			{Name: "Health Potion", Price: 5, IconPath: "assets/health-potion.png"},
-			{Name: "Strange Log", Price: 3, IconPath: "assets/tree-pickup.png"},
-			{Name: "Stone Fragment", Price: 2, IconPath: "assets/stone-pickup.png"},
-			{Name: "Golden Nugget", Price: 10, IconPath: "assets/gold-nugget.png"},
-		},
 	}
+	for _, def := range items.ForSale() {
+		merchant.ShopItems = append(merchant.ShopItems, ShopItem{Item: def.ID, Price: def.Price})
+	}
+	return merchant
 }
 
 func (m *Merchant) LoadIcons() {
 	for _, item := range m.ShopItems {
-		if _, exists := m.ItemIcons[item.Name]; !exists {
-			m.ItemIcons[item.Name] = r.LoadTexture(item.IconPath)
+		if _, exists := m.ItemIcons[item.Item]; !exists {
+			m.ItemIcons[item.Item] = loadTexture(items.Get(item.Item).Icon)
 		}
 	}
 }
 
-func (m *Merchant) Draw(gameFont r.Font, inventory *Inventory, camera r.Camera2D, debug bool) {
+// Update plays the merchant's animation
+func (m *Merchant) Update(deltaTime float32) {
+	m.Anim.Update(deltaTime)
+}
+
+func (m *Merchant) Draw(debug bool) {
 	// Draw merchant sprite in world space
-	r.DrawTextureEx(
-		m.Texture,
-		r.Vector2{X: m.X, Y: m.Y},
-		0,
-		1,
-		r.White,
-	)
+	m.Anim.DrawAt(r.Vector2{X: m.X, Y: m.Y}, 1, r.White)
 
 	// Draw interaction bounds in debug mode when shop is closed
 	if debug && !m.IsOpen {
@@ -69,13 +67,51 @@ func (m *Merchant) Draw(gameFont r.Font, inventory *Inventory, camera r.Camera2D
 		}
 		r.DrawRectangleLinesEx(interactionBounds, 1, r.Blue)
 	}
+}
 
-	if !m.IsOpen {
+// shopCloseButton closes the shop window
+var shopCloseButton = r.Rectangle{X: 350, Y: 460, Width: 100, Height: 30}
+
+// buyButton returns where the buy button of the row-th item for sale is
+func buyButton(row int) r.Rectangle {
+	return r.Rectangle{X: 520, Y: float32(160 + row*40), Width: 50, Height: 25}
+}
+
+// canBuy reports whether the player can pay for an item and has room for it
+func (m *Merchant) canBuy(item ShopItem, inventory *Inventory) bool {
+	return inventory.ItemCounts[CurrencyItem] >= item.Price && inventory.HasRoom(item.Item, 1)
+}
+
+// UpdateShop handles clicks on the shop window while it is open
+func (m *Merchant) UpdateShop(input Input, inventory *Inventory) {
+	if !m.IsOpen || !input.IsMouseButtonPressed(0) {
 		return
 	}
 
-	// Draw shop UI in screen space
-	r.EndMode2D()
+	mousePoint := input.GetMousePosition()
+	for row, item := range m.ShopItems {
+		// Items without an icon are not shown
+		if _, exists := m.ItemIcons[item.Item]; !exists {
+			continue
+		}
+		if r.CheckCollisionPointRec(mousePoint, buyButton(row)) {
+			if m.canBuy(item, inventory) {
+				m.BuyItem(item, inventory)
+			}
+			return
+		}
+	}
+
+	if r.CheckCollisionPointRec(mousePoint, shopCloseButton) {
+		m.IsOpen = false
+	}
+}
+
+// DrawShop renders the shop window in screen space while it is open
+func (m *Merchant) DrawShop(gameFont r.Font, inventory *Inventory) {
+	if !m.IsOpen {
+		return
+	}
 
 	// Draw shop window with increased width
 	r.DrawRectangle(0, 0, 800, 600, r.ColorAlpha(r.Black, 0.5))
@@ -85,8 +121,8 @@ func (m *Merchant) Draw(gameFont r.Font, inventory *Inventory, camera r.Camera2D
 	// Draw items for sale
 	y := 160
 	iconSize := int32(32)
-	for _, item := range m.ShopItems {
-		if texture, exists := m.ItemIcons[item.Name]; exists {
+	for row, item := range m.ShopItems {
+		if texture, exists := m.ItemIcons[item.Item]; exists {
 			// Draw item icon
 			r.DrawTexturePro(
 				texture,
@@ -99,7 +135,7 @@ func (m *Merchant) Draw(gameFont r.Font, inventory *Inventory, camera r.Camera2D
 
 			// Draw item name and price with adjusted positions
 			r.DrawTextEx(gameFont,
-				item.Name,
+				items.Get(item.Item).Name,
 				r.Vector2{X: 220 + float32(iconSize) + 10, Y: float32(y)},
 				20,
 				1,
@@ -114,53 +150,27 @@ func (m *Merchant) Draw(gameFont r.Font, inventory *Inventory, camera r.Camera2D
 			)
 
 			// Draw buy button with adjusted position
-			buyBtn := r.Rectangle{X: 520, Y: float32(y), Width: 50, Height: 25}
-			canBuy := inventory.ItemCounts["Gold Coin"] >= item.Price
-
-			if canBuy {
+			buyBtn := buyButton(row)
+			if m.canBuy(item, inventory) {
 				r.DrawRectangleRec(buyBtn, r.Green)
 			} else {
 				r.DrawRectangleRec(buyBtn, r.Gray)
 			}
 			r.DrawTextEx(gameFont, "BUY", r.Vector2{X: buyBtn.X + 5, Y: buyBtn.Y + 2}, 20, 1, r.White)
-
-			// Handle buy button click
-			if canBuy && r.IsMouseButtonPressed(0) {
-				mousePoint := r.GetMousePosition()
-				if r.CheckCollisionPointRec(mousePoint, buyBtn) {
-					m.BuyItem(item, inventory)
-				}
-			}
 		}
 		y += 40
 	}
 
 	// Draw close button with adjusted position
-	closeBtn := r.Rectangle{X: 350, Y: 460, Width: 100, Height: 30}
-	r.DrawRectangleRec(closeBtn, r.Gray)
+	r.DrawRectangleRec(shopCloseButton, r.Gray)
 	r.DrawTextEx(gameFont, "Close", r.Vector2{X: 370, Y: 465}, 20, 1, r.White)
-
-	if r.IsMouseButtonPressed(0) {
-		mousePoint := r.GetMousePosition()
-		if r.CheckCollisionPointRec(mousePoint, closeBtn) {
-			m.IsOpen = false
-		}
-	}
-
-	r.BeginMode2D(camera)
 }
 
 func (m *Merchant) BuyItem(item ShopItem, inventory *Inventory) {
-	// Remove gold coins from inventory
-	inventory.ItemCounts["Gold Coin"] -= item.Price
-	if inventory.ItemCounts["Gold Coin"] <= 0 {
-		delete(inventory.ItemCounts, "Gold Coin")
+	// Pay with gold coins, then add the bought item to the inventory
+	if inventory.Remove(CurrencyItem, item.Price) {
+		inventory.Add(item.Item, 1)
 	}
-
-	// Add bought item to inventory
-	inventory.Items = append(inventory.Items, item.Name)
-	inventory.ItemCounts[item.Name]++
-	inventory.LoadIcon(item.Name, item.IconPath)
 }
 
 func (m *Merchant) OnClick(mouseWorldPos r.Vector2) bool {
@@ -176,9 +186,9 @@ func (m *Merchant) OnClick(mouseWorldPos r.Vector2) bool {
 }
 
 func (m *Merchant) Unload() {
-	r.UnloadTexture(m.Texture)
+	m.Anim.Unload()
 	for _, texture := range m.ItemIcons {
-		r.UnloadTexture(texture)
+		unloadTexture(texture)
 	}
 }
 
@@ -190,3 +200,17 @@ func (m *Merchant) GetBounds() r.Rectangle {
 		Height: float32(m.Height),
 	}
 }
+
+// BlocksMovement keeps enemies from walking through the merchant
+func (m *Merchant) BlocksMovement() bool {
+	return true
+}
+
+// StationName lets recipes require the merchant's help
+func (m *Merchant) StationName() string {
+	return StationMerchant
+}
+
+func (m *Merchant) DrawLayer() Layer {
+	return LayerActors
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+)
+
+// ModifiersFile holds the definition of every spell modifier
+const ModifiersFile = "assets/modifiers.json"
+
+// modifiers is the modifier registry shared by every subsystem
+var modifiers = NewModifierRegistry()
+
+// Modifier operations, a modifier applies its operations in order
+const (
+	OpSplit   = "split"   // Casts Count copies fanned out over Spread degrees
+	OpHoming  = "homing"  // Fireballs turn towards targets by Turn radians a second
+	OpEcho    = "echo"    // Casts the spell again Count times, Delay seconds apart
+	OpGravity = "gravity" // Landing pulls enemies within Radius in with Strength for Time seconds
+	OpBounce  = "bounce"  // Landing relaunches the spell at a new target within Radius, Count times
+	OpScale   = "scale"   // Multiplies one stat of the spell by Factor
+)
+
+// Spell stats scale operations may change
+var scalableStats = map[string]bool{
+	"damage":    true,
+	"mana":      true,
+	"cooldown":  true,
+	"cast_time": true,
+	"range":     true,
+	"radius":    true,
+	"speed":     true,
+}
+
+// Modifier tuning
+const (
+	modifierSockets = 3  // Modifiers one spell can hold
+	spellCostCap    = 24 // Most spell effects one cast may put into the world
+	gravityCost     = 2  // Gravity wells move enemies every frame, so each counts this many effects
+	homingRadius    = 80.0
+)
+
+// ModifierOp is one step a modifier applies to the spells it is socketed into
+type ModifierOp struct {
+	Op       string  `json:"op"`
+	Count    int     `json:"count,omitempty"`
+	Spread   float32 `json:"spread,omitempty"`
+	Turn     float32 `json:"turn,omitempty"`
+	Delay    float32 `json:"delay,omitempty"`
+	Radius   float32 `json:"radius,omitempty"`
+	Strength float32 `json:"strength,omitempty"`
+	Time     float32 `json:"time,omitempty"`
+	Stat     string  `json:"stat,omitempty"`
+	Factor   float32 `json:"factor,omitempty"`
+}
+
+// ModifierDef describes one spell modifier
+type ModifierDef struct {
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Kinds       []string     `json:"kinds,omitempty"` // Spell kinds it fits, all when empty
+	Unlock      string       `json:"unlock"`          // Dimension the player must have ascended to
+	Ops         []ModifierOp `json:"ops"`
+}
+
+// Fits reports whether the modifier can be socketed into a spell
+func (def *ModifierDef) Fits(spell *SpellDef) bool {
+	if len(def.Kinds) == 0 {
+		return true
+	}
+	for _, kind := range def.Kinds {
+		if kind == spell.Kind {
+			return true
+		}
+	}
+	return false
+}
+
+// IsUnlocked reports whether a player in the given dimension has unlocked the modifier
+func (def *ModifierDef) IsUnlocked(dimension int) bool {
+	index, exists := dimensionIndex(def.Unlock)
+	return exists && dimension >= index
+}
+
+// ModifierRegistry looks modifier definitions up by ID
+type ModifierRegistry struct {
+	defs  map[string]*ModifierDef
+	order []*ModifierDef // In file order
+}
+
+// NewModifierRegistry creates an empty registry
+func NewModifierRegistry() *ModifierRegistry {
+	return &ModifierRegistry{defs: make(map[string]*ModifierDef)}
+}
+
+// LoadModifiers reads and validates a modifier definition file
+func LoadModifiers(path string) (*ModifierRegistry, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var defs []*ModifierDef
+	if err := json.Unmarshal(raw, &defs); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	reg := NewModifierRegistry()
+	for _, def := range defs {
+		if def.ID == "" {
+			return nil, fmt.Errorf("%s: modifier %q has no id", path, def.Name)
+		}
+		if _, exists := reg.defs[def.ID]; exists {
+			return nil, fmt.Errorf("%s: modifier %q is defined twice", path, def.ID)
+		}
+		if def.Name == "" {
+			def.Name = def.ID
+		}
+		if err := validateModifier(def); err != nil {
+			return nil, fmt.Errorf("%s: modifier %q: %w", path, def.ID, err)
+		}
+		reg.defs[def.ID] = def
+		reg.order = append(reg.order, def)
+	}
+	return reg, nil
+}
+
+// validateModifier checks a modifier unlocks somewhere, fits real spell kinds and
+// gives every operation what it needs
+func validateModifier(def *ModifierDef) error {
+	if _, exists := dimensionIndex(def.Unlock); !exists {
+		return fmt.Errorf("unlocks in unknown dimension %q", def.Unlock)
+	}
+	for _, kind := range def.Kinds {
+		if !spellKinds[kind] {
+			return fmt.Errorf("fits unknown spell kind %q", kind)
+		}
+	}
+	if len(def.Ops) == 0 {
+		return fmt.Errorf("has no operations")
+	}
+
+	for _, op := range def.Ops {
+		var err error
+		switch op.Op {
+		case OpSplit:
+			if op.Count < 2 || op.Spread < 0 {
+				err = fmt.Errorf("split needs a count of at least 2")
+			}
+		case OpHoming:
+			if op.Turn <= 0 {
+				err = fmt.Errorf("homing needs a positive turn")
+			}
+		case OpEcho:
+			if op.Count < 1 || op.Delay <= 0 {
+				err = fmt.Errorf("echo needs a count and a positive delay")
+			}
+		case OpGravity:
+			if op.Radius <= 0 || op.Strength <= 0 || op.Time <= 0 {
+				err = fmt.Errorf("gravity needs a positive radius, strength and time")
+			}
+		case OpBounce:
+			if op.Count < 1 || op.Radius <= 0 {
+				err = fmt.Errorf("bounce needs a count and a positive radius")
+			}
+		case OpScale:
+			if !scalableStats[op.Stat] || op.Factor <= 0 {
+				err = fmt.Errorf("scale needs a known stat and a positive factor")
+			}
+		default:
+			err = fmt.Errorf("unknown operation %q", op.Op)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Lookup returns the definition of a modifier, if it exists
+func (reg *ModifierRegistry) Lookup(id string) (*ModifierDef, bool) {
+	def, exists := reg.defs[id]
+	return def, exists
+}
+
+// All returns every modifier in file order
+func (reg *ModifierRegistry) All() []*ModifierDef {
+	return reg.order
+}
+
+// loadModifiers replaces the registry with the modifier file, keeping the old one if it is broken
+func loadModifiers(path string) {
+	loaded, err := LoadModifiers(path)
+	if err != nil {
+		fmt.Println("Warning: Could not load modifiers:", err)
+		return
+	}
+	modifiers = loaded
+}
+
+// gravityWell pulls enemies towards where a spell landed
+type gravityWell struct {
+	Radius   float32
+	Strength float32 // How far enemies are pulled per second
+	Time     float32
+}
+
+// castPlan is a spell the way its modifiers change it
+type castPlan struct {
+	Spell        SpellDef // Copy of the spell with its stats scaled
+	Split        int
+	Spread       float32 // Degrees between the outermost copies
+	Homing       float32
+	Echoes       int
+	EchoDelay    float32
+	Bounces      int
+	BounceRadius float32
+	Gravity      gravityWell
+}
+
+// Cost counts the effects a cast of the plan may put into the world at once
+func (plan *castPlan) Cost() int {
+	cost := plan.Split * (1 + plan.Echoes) * (1 + plan.Bounces)
+	if plan.Gravity.Time > 0 {
+		cost *= gravityCost
+	}
+	return cost
+}
+
+// planCast runs a spell through its modifiers in order. Modifiers that are
+// missing, do not fit or would push the cost over the cap are skipped, and
+// the number of modifiers that made it into the plan is returned with it.
+func planCast(spell *SpellDef, socketed []string) (castPlan, int) {
+	plan := castPlan{Spell: *spell, Split: 1}
+	applied := 0
+	for _, id := range socketed {
+		def, exists := modifiers.Lookup(id)
+		if !exists || !def.Fits(spell) {
+			continue
+		}
+		next := plan
+		for _, op := range def.Ops {
+			next.apply(op)
+		}
+		if next.Cost() > spellCostCap {
+			continue
+		}
+		plan = next
+		applied++
+	}
+	return plan, applied
+}
+
+// apply changes the plan by one operation. Splits multiply, counts add up and gravity wells merge.
+func (plan *castPlan) apply(op ModifierOp) {
+	switch op.Op {
+	case OpSplit:
+		plan.Split *= op.Count
+		plan.Spread += op.Spread
+	case OpHoming:
+		plan.Homing += op.Turn
+	case OpEcho:
+		plan.Echoes += op.Count
+		plan.EchoDelay = max(plan.EchoDelay, op.Delay)
+	case OpGravity:
+		plan.Gravity.Radius = max(plan.Gravity.Radius, op.Radius)
+		plan.Gravity.Strength += op.Strength
+		plan.Gravity.Time = max(plan.Gravity.Time, op.Time)
+	case OpBounce:
+		plan.Bounces += op.Count
+		plan.BounceRadius = max(plan.BounceRadius, op.Radius)
+	case OpScale:
+		spell := &plan.Spell
+		switch op.Stat {
+		case "damage":
+			spell.Damage = int32(math.Max(1, math.Round(float64(float32(spell.Damage)*op.Factor))))
+		case "mana":
+			spell.Mana *= op.Factor
+		case "cooldown":
+			spell.Cooldown *= op.Factor
+		case "cast_time":
+			spell.CastTime *= op.Factor
+		case "range":
+			spell.Range *= op.Factor
+		case "radius":
+			spell.Radius *= op.Factor
+		case "speed":
+			spell.Speed *= op.Factor
+		}
+	}
+}
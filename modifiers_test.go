@@ -0,0 +1,199 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+// castModified casts a slot with plenty of mana and returns every hit it lands in the given frames,
+// failing if the cast ever puts more effects into the world than the cap allows
+func castModified(t *testing.T, g *Game, slot int, aim r.Vector2, frames int) []DamageEvent {
+	t.Helper()
+	book := g.player.Spells
+	g.player.Mana = 1000
+	g.player.AimTarget = aim
+	book.Cast(slot, g.player)
+	if book.Casting == nil {
+		t.Fatalf("slot %d did not cast", slot)
+	}
+	var hits []DamageEvent
+	for i := 0; i < frames; i++ {
+		book.Update(1.0/60, g.player, g.world)
+		hits = append(hits, book.Hits(g.world, g.player)...)
+		if len(book.effects) > spellCostCap {
+			t.Fatalf("%d spell effects, over the cap of %d", len(book.effects), spellCostCap)
+		}
+	}
+	return hits
+}
+
+func TestSocketingRules(t *testing.T) {
+	g := NewHeadlessGame(NewScriptedInput(), 9)
+	defer g.Cleanup()
+	book := g.player.Spells
+	book.Learn("dark_bolt")
+	book.Learn("fireball")
+	sorcery, _ := dimensionIndex("sorcery")
+	cosmic, _ := dimensionIndex("cosmic")
+
+	// Modifiers unlock with the dimension
+	if book.CanSocket("dark_bolt", "splinter", 0) {
+		t.Fatal("splinter socketed in the first dimension")
+	}
+	if !book.Socket("dark_bolt", "splinter", sorcery) || book.Socket("dark_bolt", "echo", sorcery) {
+		t.Fatal("sorcery unlocks the wrong modifiers")
+	}
+
+	// Stacking stops at the cost cap and the socket count
+	if book.Socket("dark_bolt", "starfall", cosmic) {
+		t.Fatal("starfall socketed over the cost cap")
+	}
+	if !book.Socket("dark_bolt", "ricochet", cosmic) || !book.Socket("dark_bolt", "echo", cosmic) {
+		t.Fatalf("could not fill the sockets: %v", book.Modifiers)
+	}
+	bolt, _ := spells.Lookup("dark_bolt")
+	if plan := book.Plan(bolt); plan.Cost() != 18 {
+		t.Fatalf("plan costs %d, want 18", plan.Cost())
+	}
+	if book.Socket("dark_bolt", "gravity_well", cosmic) {
+		t.Fatal("socketed past the last socket")
+	}
+
+	book.Unsocket("dark_bolt", 2)
+	if len(book.Modifiers["dark_bolt"]) != 2 {
+		t.Fatalf("after unsocketing: %v", book.Modifiers["dark_bolt"])
+	}
+	if book.Socket("dark_bolt", "seeker", cosmic) {
+		t.Fatal("seeker fits fireballs only")
+	}
+}
+
+func TestSocketingThroughTheGrimoire(t *testing.T) {
+	input := NewScriptedInput()
+	g := NewHeadlessGame(input, 10)
+	defer g.Cleanup()
+	book := g.player.Spells
+	book.Learn("dark_bolt")
+	book.Learn("fireball")
+	sorcery, _ := dimensionIndex("sorcery")
+	g.enterDimension(sorcery)
+
+	click := func(button r.Rectangle) {
+		input.MoveMouse(button.X+1, button.Y+1)
+		input.PressButton(0)
+		g.Step(1.0 / 60)
+		input.ReleaseButton(0)
+	}
+
+	input.PressKey(r.KeyG)
+	g.Step(1.0 / 60)
+	input.ReleaseKey(r.KeyG)
+	if !g.grimoire.IsOpen || g.grimoire.Selected != "dark_bolt" {
+		t.Fatalf("grimoire open %v showing %q", g.grimoire.IsOpen, g.grimoire.Selected)
+	}
+
+	// Pick the fireball and socket the first modifier sorcery unlocks
+	click(grimoireSpellRow(1))
+	click(grimoireModifierRow(0))
+	if got := book.Modifiers["fireball"]; len(got) != 1 || got[0] != "splinter" {
+		t.Fatalf("fireball sockets = %v, want splinter", got)
+	}
+	if len(book.Modifiers["dark_bolt"]) != 0 {
+		t.Fatal("socketed into the spell that was not selected")
+	}
+
+	click(grimoireSocket(0))
+	if len(book.Modifiers["fireball"]) != 0 {
+		t.Fatalf("socket was not emptied: %v", book.Modifiers["fireball"])
+	}
+
+	click(grimoireCloseButton)
+	if g.grimoire.IsOpen {
+		t.Fatal("close button left the grimoire open")
+	}
+}
+
+func TestModifiedSpellsHit(t *testing.T) {
+	g := NewHeadlessGame(NewScriptedInput(), 9)
+	defer g.Cleanup()
+	book := g.player.Spells
+	book.Learn("dark_bolt")
+	book.Learn("fireball")
+	cosmic, _ := dimensionIndex("cosmic")
+	enemies := lineUpEnemies(g, 6)
+	for _, enemy := range enemies {
+		enemy.MaxHealth, enemy.CurrentHealth = 500, 500
+	}
+
+	book.Socket("dark_bolt", "splinter", cosmic)
+	book.Socket("dark_bolt", "ricochet", cosmic)
+	if hits := castModified(t, g, book.SlotOf("dark_bolt"), enemies[0].center(), 120); len(hits) < 3 {
+		t.Fatalf("splintered ricochet bolt landed %d hits", len(hits))
+	}
+
+	// A homing fireball aimed off to the side still finds the enemies, and its well pulls them in
+	book.Socket("fireball", "seeker", cosmic)
+	book.Socket("fireball", "gravity_well", cosmic)
+	var before []float32
+	for _, enemy := range enemies {
+		before = append(before, enemy.X)
+	}
+	center := g.player.center()
+	hits := castModified(t, g, book.SlotOf("fireball"), r.Vector2{X: center.X + 60, Y: center.Y + 60}, 200)
+	if len(hits) == 0 {
+		t.Fatal("homing fireball missed")
+	}
+	moved := 0
+	for i, enemy := range enemies {
+		if enemy.X != before[i] {
+			moved++
+		}
+	}
+	if moved == 0 {
+		t.Fatal("gravity well pulled no enemies")
+	}
+}
+
+func TestLoadModifiersRejectsBadFiles(t *testing.T) {
+	files := map[string]string{
+		"no id":          `[{"unlock": "sorcery", "ops": [{"op": "homing", "turn": 1}]}]`,
+		"twice":          `[{"id": "a", "unlock": "sorcery", "ops": [{"op": "homing", "turn": 1}]}, {"id": "a", "unlock": "sorcery", "ops": [{"op": "homing", "turn": 1}]}]`,
+		"unknown unlock": `[{"id": "a", "unlock": "nowhere", "ops": [{"op": "homing", "turn": 1}]}]`,
+		"unknown kind":   `[{"id": "a", "unlock": "sorcery", "kinds": ["beam"], "ops": [{"op": "homing", "turn": 1}]}]`,
+		"no ops":         `[{"id": "a", "unlock": "sorcery"}]`,
+		"small split":    `[{"id": "a", "unlock": "sorcery", "ops": [{"op": "split", "count": 1}]}]`,
+		"unknown stat":   `[{"id": "a", "unlock": "sorcery", "ops": [{"op": "scale", "stat": "luck", "factor": 2}]}]`,
+		"unknown op":     `[{"id": "a", "unlock": "sorcery", "ops": [{"op": "teleport"}]}]`,
+	}
+	for name, content := range files {
+		if _, err := LoadModifiers(writeTestFile(t, "modifiers.json", content)); err == nil {
+			t.Errorf("%s: loaded without an error", name)
+		}
+	}
+}
+
+func TestModifiersSurviveSave(t *testing.T) {
+	g := NewHeadlessGame(NewScriptedInput(), 9)
+	defer g.Cleanup()
+	book := g.player.Spells
+	book.Learn("fireball")
+	sorcery, _ := dimensionIndex("sorcery")
+	g.enterDimension(sorcery)
+	if !book.Socket("fireball", "seeker", sorcery) || !book.Socket("fireball", "splinter", sorcery) {
+		t.Fatal("could not socket the fireball")
+	}
+
+	path := filepath.Join(t.TempDir(), "savegame.json")
+	if err := g.SaveGame(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.LoadGame(path); err != nil {
+		t.Fatal(err)
+	}
+	socketed := g.player.Spells.Modifiers["fireball"]
+	if len(socketed) != 2 || socketed[0] != "seeker" || socketed[1] != "splinter" {
+		t.Fatalf("fireball modifiers after loading: %v", socketed)
+	}
+}
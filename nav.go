@@ -0,0 +1,215 @@
+package main
+
+import (
+	"math"
+
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Navigation tuning
+const (
+	navCellSize        = 16  // Side of one navigation cell in pixels
+	navPadding         = 8   // Obstacles are grown by half a body so enemies do not clip their corners
+	navRebuildInterval = 0.5 // Seconds between rescans of the obstacles, trees fall and stones break
+)
+
+// NavGrid is a flow field over the dimension. Every reachable cell knows how many
+// steps it is from the target, so any number of chasers find their way around
+// obstacles by walking downhill, with one search for the whole horde.
+type NavGrid struct {
+	Columns      int
+	Rows         int
+	blocked      []bool
+	distance     []int32 // Steps to the target cell, -1 where it cannot be reached
+	target       int     // Cell the field leads to, -1 before the first search
+	rebuildTimer float32
+}
+
+// NewNavGrid creates an empty grid covering a dimension, it scans the world on its first update
+func NewNavGrid(width, height int32) *NavGrid {
+	columns := int(width+navCellSize-1) / navCellSize
+	rows := int(height+navCellSize-1) / navCellSize
+	distance := make([]int32, columns*rows)
+	for i := range distance {
+		distance[i] = -1
+	}
+	return &NavGrid{
+		Columns:  columns,
+		Rows:     rows,
+		blocked:  make([]bool, columns*rows),
+		distance: distance,
+		target:   -1,
+	}
+}
+
+// cellOf returns the index of the cell holding a point, or -1 outside the grid
+func (n *NavGrid) cellOf(point r.Vector2) int {
+	column := int(point.X) / navCellSize
+	row := int(point.Y) / navCellSize
+	if point.X < 0 || point.Y < 0 || column >= n.Columns || row >= n.Rows {
+		return -1
+	}
+	return row*n.Columns + column
+}
+
+// cellCenter returns the middle of a cell in world space
+func (n *NavGrid) cellCenter(cell int) r.Vector2 {
+	return r.Vector2{
+		X: float32(cell%n.Columns*navCellSize) + navCellSize/2,
+		Y: float32(cell/n.Columns*navCellSize) + navCellSize/2,
+	}
+}
+
+// Blocked reports whether nothing can walk through the cell holding a point
+func (n *NavGrid) Blocked(point r.Vector2) bool {
+	cell := n.cellOf(point)
+	return cell < 0 || n.blocked[cell]
+}
+
+// Update rescans the obstacles now and then and points the field at the target
+func (n *NavGrid) Update(deltaTime float32, world *World, target r.Vector2) {
+	rescanned := false
+	n.rebuildTimer -= deltaTime
+	if n.rebuildTimer <= 0 {
+		n.rebuildTimer = navRebuildInterval
+		n.scan(world)
+		rescanned = true
+	}
+
+	cell := n.cellOf(target)
+	if rescanned || cell != n.target {
+		n.target = cell
+		n.flood()
+	}
+}
+
+// scan marks the cells covered by anything that blocks movement
+func (n *NavGrid) scan(world *World) {
+	for i := range n.blocked {
+		n.blocked[i] = false
+	}
+	for _, blocker := range Query[MovementBlocker](world) {
+		if !blocker.BlocksMovement() {
+			continue
+		}
+		bounds := blocker.GetBounds()
+		minColumn := max(0, int(bounds.X-navPadding)/navCellSize)
+		minRow := max(0, int(bounds.Y-navPadding)/navCellSize)
+		maxColumn := min(n.Columns-1, int(math.Ceil(float64(bounds.X+bounds.Width+navPadding)/navCellSize))-1)
+		maxRow := min(n.Rows-1, int(math.Ceil(float64(bounds.Y+bounds.Height+navPadding)/navCellSize))-1)
+		for row := minRow; row <= maxRow; row++ {
+			for column := minColumn; column <= maxColumn; column++ {
+				n.blocked[row*n.Columns+column] = true
+			}
+		}
+	}
+}
+
+// flood fills in the distances with a breadth-first search from the target
+func (n *NavGrid) flood() {
+	for i := range n.distance {
+		n.distance[i] = -1
+	}
+	if n.target < 0 {
+		return
+	}
+
+	// The target's own cell always counts, the player may stand right against a tree
+	n.distance[n.target] = 0
+	queue := []int{n.target}
+	for len(queue) > 0 {
+		cell := queue[0]
+		queue = queue[1:]
+		for _, next := range n.neighbors(cell, false) {
+			if n.distance[next] < 0 {
+				n.distance[next] = n.distance[cell] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+}
+
+// neighbors returns the open cells next to a cell. Diagonal steps are only
+// taken when both cells beside them are open, so paths never cut corners.
+func (n *NavGrid) neighbors(cell int, diagonal bool) []int {
+	column, row := cell%n.Columns, cell/n.Columns
+	open := func(c, w int) bool {
+		return c >= 0 && w >= 0 && c < n.Columns && w < n.Rows && !n.blocked[w*n.Columns+c]
+	}
+
+	var cells []int
+	for _, step := range [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+		if open(column+step[0], row+step[1]) {
+			cells = append(cells, (row+step[1])*n.Columns+column+step[0])
+		}
+	}
+	if diagonal {
+		for _, step := range [][2]int{{1, 1}, {1, -1}, {-1, 1}, {-1, -1}} {
+			if open(column+step[0], row+step[1]) && open(column+step[0], row) && open(column, row+step[1]) {
+				cells = append(cells, (row+step[1])*n.Columns+column+step[0])
+			}
+		}
+	}
+	return cells
+}
+
+// next returns the neighboring cell closest to the target, or -1 when there is none
+func (n *NavGrid) next(cell int) int {
+	best := -1
+	for _, neighbor := range n.neighbors(cell, true) {
+		if n.distance[neighbor] >= 0 && (best < 0 || n.distance[neighbor] < n.distance[best]) {
+			best = neighbor
+		}
+	}
+	if best < 0 || n.distance[best] >= n.distance[cell] {
+		return -1
+	}
+	return best
+}
+
+// Direction returns the way to walk from a point towards the target. It reports
+// false when the point already shares the target's cell or has no path to it.
+func (n *NavGrid) Direction(from r.Vector2) (r.Vector2, bool) {
+	cell := n.cellOf(from)
+	if cell < 0 || n.distance[cell] <= 0 {
+		return r.Vector2{}, false
+	}
+	next := n.next(cell)
+	if next < 0 {
+		return r.Vector2{}, false
+	}
+	return r.Vector2Normalize(r.Vector2Subtract(n.cellCenter(next), from)), true
+}
+
+// Path follows the field from a point to the target, for the debug overlay
+func (n *NavGrid) Path(from r.Vector2, maxSteps int) []r.Vector2 {
+	path := []r.Vector2{from}
+	cell := n.cellOf(from)
+	if cell < 0 || n.distance[cell] < 0 {
+		return path
+	}
+	for i := 0; i < maxSteps && n.distance[cell] > 0; i++ {
+		cell = n.next(cell)
+		if cell < 0 {
+			break
+		}
+		path = append(path, n.cellCenter(cell))
+	}
+	return path
+}
+
+// Draw shades the blocked cells, for the debug overlay
+func (n *NavGrid) Draw() {
+	for cell, blocked := range n.blocked {
+		if blocked {
+			center := n.cellCenter(cell)
+			r.DrawRectangle(
+				int32(center.X-navCellSize/2),
+				int32(center.Y-navCellSize/2),
+				navCellSize,
+				navCellSize,
+				r.ColorAlpha(r.Red, 0.15),
+			)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+func TestNavGridLeadsAroundObstacles(t *testing.T) {
+	headless = true
+	w := NewWorld()
+	defer w.Unload()
+	for x := float32(0); x < 192; x += 48 {
+		w.Add(NewTreeAt(x, 96, 3))
+	}
+
+	nav := NewNavGrid(300, 300)
+	target := r.Vector2{X: 40, Y: 40}
+	nav.Update(1.0/60, w, target)
+
+	start := r.Vector2{X: 40, Y: 250}
+	if !nav.Blocked(r.Vector2{X: 20, Y: 120}) || nav.Blocked(start) {
+		t.Fatal("tree cells not blocked")
+	}
+	path := nav.Path(start, 100)
+	last := path[len(path)-1]
+	if nav.cellOf(last) != nav.cellOf(target) {
+		t.Fatalf("path ends at %v, want the target cell", last)
+	}
+	for _, point := range path {
+		if nav.Blocked(point) {
+			t.Fatalf("path crosses the blocked cell at %v", point)
+		}
+	}
+}
+
+func TestEnemyChasesAroundTrees(t *testing.T) {
+	headless = true
+	w := NewWorld()
+	defer w.Unload()
+	for x := float32(180); x < 420; x += 48 {
+		w.Add(NewTreeAt(x, 250, 3))
+	}
+	player := &Player{X: 292, Y: 100, Width: 16, Height: 32}
+	enemy := NewEnemy(292, 420, player, rand.New(rand.NewSource(1)))
+	enemy.Nav = NewNavGrid(600, 600)
+	w.Add(enemy)
+
+	for i := 0; i < 20*60; i++ {
+		enemy.Nav.Update(1.0/60, w, player.center())
+		w.Update(1.0 / 60)
+		if len(QueryRect[*Tree](w, enemy.GetBounds())) > 0 {
+			t.Fatalf("walked into a tree at %v,%v while in %v", enemy.X, enemy.Y, enemy.State)
+		}
+		if r.Vector2Distance(enemy.center(), player.center()) < 40 {
+			return
+		}
+	}
+	t.Fatalf("never reached the player, stopped at %v,%v in %v", enemy.X, enemy.Y, enemy.State)
+}
+
+func TestEnemyStates(t *testing.T) {
+	headless = true
+	player := &Player{X: 300, Y: 300, Width: 16, Height: 32}
+	enemy := NewEnemy(0, 0, player, rand.New(rand.NewSource(2)))
+	defer enemy.Unload()
+
+	// Far from the player it idles or wanders
+	enemy.Player = &Player{X: 5000, Y: 5000, Width: 16, Height: 32}
+	for i := 0; i < 60; i++ {
+		enemy.Update(1.0 / 60)
+	}
+	if enemy.State != AIWander && enemy.State != AIIdle {
+		t.Fatalf("far from the player the enemy is in %v", enemy.State)
+	}
+
+	enemy.Player = player
+	enemy.X, enemy.Y = player.X+50, player.Y
+	enemy.Update(1.0 / 60)
+	if enemy.State != AIChase {
+		t.Fatalf("next to the player the enemy is in %v", enemy.State)
+	}
+
+	enemy.CurrentHealth = 1
+	enemy.Update(1.0 / 60)
+	if enemy.State != AIFlee {
+		t.Fatalf("badly hurt the enemy is in %v", enemy.State)
+	}
+}
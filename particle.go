@@ -46,8 +46,7 @@ func (ps *ParticleSystem) SpawnExplosion(color r.Color, particleCount int, x, y
 	}
 }
 
-func (ps *ParticleSystem) Update() {
-	deltaTime := r.GetFrameTime()
+func (ps *ParticleSystem) Update(deltaTime float32) {
 	var activeParticles []Particle
 
 	for _, p := range ps.Particles {
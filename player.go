@@ -11,28 +11,17 @@ type Player struct {
 	Width         int32
 	Height        int32
 	Speed         float32
-	Texture       r.Texture2D
+	Anim          *Animator
 	Scale         float32
 	GameWidth     int32
 	GameHeight    int32
-	FrameWidth    int32
-	FrameHeight   int32
-	CurrentFrame  int32
-	FrameCount    int32
-	FrameTime     float32
 	IsMoving      bool
 	FacingLeft    bool
 	MaxHealth     int32
 	CurrentHealth int32
 	IsAiming      bool
-	SlashAnim     struct {
-		Texture     r.Texture2D
-		Active      bool
-		Frame       int32
-		FrameTime   float32
-		FrameWidth  int32
-		FrameHeight int32
-	}
+	AimTarget     r.Vector2 // World position the player is aiming at
+
 	InvincibleTimer float32 // Time remaining for invincibility
 	InvincibleTime  float32 // How long invincibility lasts
 
@@ -56,8 +45,14 @@ type Player struct {
 	GhostTrailLength int
 	Experience       int
 	NextLevelExp     int
+	Level            int
 
 	HarvestDamage int32
+
+	Mana      float32
+	MaxMana   float32
+	ManaRegen float32 // Mana regained per second
+	Spells    *Spellbook
 }
 
 // NewPlayer creates a new player instance
@@ -70,14 +65,9 @@ func NewPlayer(x, y float32, gameWidth, gameHeight int32) *Player {
 		Height:            16,
 		Speed:             1.5,
 		Scale:             1,
-		Texture:           r.LoadTexture("assets/soma_fut.png"),
+		Anim:              NewAnimator("player"),
 		GameWidth:         gameWidth,
 		GameHeight:        gameHeight,
-		FrameWidth:        16,
-		FrameHeight:       16,
-		CurrentFrame:      3,
-		FrameCount:        4,
-		FrameTime:         0,
 		IsMoving:          false,
 		FacingLeft:        false,
 		MaxHealth:         100,
@@ -103,32 +93,25 @@ func NewPlayer(x, y float32, gameWidth, gameHeight int32) *Player {
 		GhostTrailLength: 5, // Number of ghost images to show
 		Experience:       0,
 		NextLevelExp:     100, // Experience needed for next level
+		Level:            1,
 		HarvestDamage:    1,
-	}
-
-	p.SlashAnim = struct {
-		Texture     r.Texture2D
-		Active      bool
-		Frame       int32
-		FrameTime   float32
-		FrameWidth  int32
-		FrameHeight int32
-	}{
-		Texture:     r.LoadTexture("assets/slash.png"),
-		FrameWidth:  65, // 390/6 frames = 65 pixels per frame
-		FrameHeight: 27,
+		Mana:             100,
+		MaxMana:          100,
+		ManaRegen:        5,
+		Spells:           NewSpellbook(),
 	}
 
 	return p
 }
 
-// Update updates the player's position based on input
-func (p *Player) Update(camera r.Camera2D) {
-	deltaTime := r.GetFrameTime()
+// Update updates the player's position based on input, stopping at anything solid in the world
+func (p *Player) Update(deltaTime float32, input Input, controls *InputMap, camera r.Camera2D, world *World) {
+	// Track where the cursor points in the world for aiming
+	p.AimTarget = r.GetScreenToWorld2D(input.GetMousePosition(), camera)
 
 	// Handle weapon activation
-	if r.IsMouseButtonDown(1) { // 1 is right mouse button
-		p.CurrentWeapon.OnActivate(p, camera)
+	if controls.IsDown(input, ActionFire) {
+		p.CurrentWeapon.OnActivate(p)
 	} else {
 		p.CurrentWeapon.OnDeactivate(p)
 	}
@@ -136,13 +119,20 @@ func (p *Player) Update(camera r.Camera2D) {
 	// Update current weapon
 	p.CurrentWeapon.Update(deltaTime, p)
 
+	// Cast the spell of a slot when its key goes down
+	for slot := 0; slot < spellSlotCount; slot++ {
+		if controls.IsPressed(input, CastSpellAction(slot)) {
+			p.Spells.Cast(slot, p)
+		}
+	}
+
 	// Update dash cooldown
 	if p.DashCooldownTimer > 0 {
 		p.DashCooldownTimer -= deltaTime
 	}
 
 	// Handle dash input
-	if r.IsKeyPressed(r.KeySpace) && p.DashCooldownTimer <= 0 && !p.IsDashing {
+	if controls.IsPressed(input, ActionDash) && p.DashCooldownTimer <= 0 && !p.IsDashing {
 		p.IsDashing = true
 		p.DashTimer = p.DashDuration
 		p.DashCooldownTimer = p.DashCooldown
@@ -151,8 +141,7 @@ func (p *Player) Update(camera r.Camera2D) {
 	// Handle dash movement
 	if p.IsDashing {
 		p.DashTimer -= deltaTime
-		p.X += p.LastMoveDirection.X * p.DashSpeed * deltaTime * 60
-		p.Y += p.LastMoveDirection.Y * p.DashSpeed * deltaTime * 60
+		p.move(world, r.Vector2Scale(p.LastMoveDirection, p.DashSpeed*deltaTime*60))
 
 		if p.DashTimer <= 0 {
 			p.IsDashing = false
@@ -192,51 +181,53 @@ func (p *Player) Update(camera r.Camera2D) {
 		}
 
 		// Normal movement code
-		nextX := p.X
-		nextY := p.Y
+		var step r.Vector2
 		isMoving := false
 
 		// Track movement direction for dash
-		if r.IsKeyDown(r.KeyA) {
-			nextX -= p.Speed * deltaTime * 60
+		if controls.IsDown(input, ActionMoveLeft) {
+			step.X -= p.Speed * deltaTime * 60
 			isMoving = true
 			p.FacingLeft = true
 			p.LastMoveDirection = r.Vector2{X: -1, Y: 0}
 		}
-		if r.IsKeyDown(r.KeyD) {
-			nextX += p.Speed * deltaTime * 60
+		if controls.IsDown(input, ActionMoveRight) {
+			step.X += p.Speed * deltaTime * 60
 			isMoving = true
 			p.FacingLeft = false
 			p.LastMoveDirection = r.Vector2{X: 1, Y: 0}
 		}
-		if r.IsKeyDown(r.KeyW) {
-			nextY -= p.Speed * deltaTime * 60
+		if controls.IsDown(input, ActionMoveUp) {
+			step.Y -= p.Speed * deltaTime * 60
 			isMoving = true
 			p.LastMoveDirection = r.Vector2{X: 0, Y: -1}
 		}
-		if r.IsKeyDown(r.KeyS) {
-			nextY += p.Speed * deltaTime * 60
+		if controls.IsDown(input, ActionMoveDown) {
+			step.Y += p.Speed * deltaTime * 60
 			isMoving = true
 			p.LastMoveDirection = r.Vector2{X: 0, Y: 1}
 		}
 
-		// Keep player within bounds
-		p.X = float32(Max(0, Min(float64(nextX), float64(p.GameWidth-p.Width))))
-		p.Y = float32(Max(0, Min(float64(nextY), float64(p.GameHeight-p.Height))))
-
-		// Update animation
+		p.move(world, step)
 		p.IsMoving = isMoving
-		if p.IsMoving {
-			p.FrameTime += deltaTime
-			if p.FrameTime >= 0.2 {
-				p.FrameTime = 0
-				p.CurrentFrame = (p.CurrentFrame + 1) % p.FrameCount
-			}
-		} else {
-			p.CurrentFrame = 3
-			p.FrameTime = 0
+	}
+
+	// Pick the clip for what the player is doing, letting a hit play out first
+	p.Anim.FacingLeft = p.FacingLeft
+	if !p.Anim.Busy() {
+		switch {
+		case p.IsDashing:
+			p.Anim.Play("dash")
+		case p.IsMoving:
+			p.Anim.Play("walk")
+		default:
+			p.Anim.Play("idle")
 		}
 	}
+	p.Anim.Update(deltaTime)
+
+	// Let a finished cast go off, after the dash that could break it
+	p.Spells.Update(deltaTime, p, world)
 
 	// Update invincibility timer
 	if p.InvincibleTimer > 0 {
@@ -251,25 +242,18 @@ func (p *Player) Update(camera r.Camera2D) {
 			p.Heal(1) // Heal 1 HP every 2 seconds
 		}
 	}
+
+	// Regenerate mana
+	p.Mana = min(p.MaxMana, p.Mana+p.ManaRegen*deltaTime)
 }
 
 // Draw renders the player
 func (p *Player) Draw(debug bool, camera r.Camera2D) {
 	// Draw ghost trail
 	for _, ghost := range p.GhostTrail {
-		frameRect := r.Rectangle{
-			X:      float32(p.CurrentFrame) * float32(p.FrameWidth),
-			Y:      0,
-			Width:  float32(p.FrameWidth),
-			Height: float32(p.FrameHeight),
-		}
-		if ghost.FacingLeft {
-			frameRect.Width = -frameRect.Width
-		}
-
 		r.DrawTexturePro(
-			p.Texture,
-			frameRect,
+			p.Anim.Texture,
+			p.Anim.SourceFacing(ghost.FacingLeft),
 			r.Rectangle{
 				X:      ghost.Position.X,
 				Y:      ghost.Position.Y,
@@ -282,17 +266,6 @@ func (p *Player) Draw(debug bool, camera r.Camera2D) {
 		)
 	}
 
-	// Calculate source rectangle for current frame
-	sourceRec := r.Rectangle{
-		X:      float32(p.CurrentFrame * p.FrameWidth),
-		Y:      0,
-		Width:  float32(p.FrameWidth),
-		Height: float32(p.FrameHeight),
-	}
-	if p.FacingLeft {
-		sourceRec.Width = -sourceRec.Width
-	}
-
 	// Calculate destination rectangle
 	destRec := r.Rectangle{
 		X:      p.X,
@@ -321,14 +294,7 @@ func (p *Player) Draw(debug bool, camera r.Camera2D) {
 	}
 
 	// Draw the current frame with alpha
-	r.DrawTexturePro(
-		p.Texture,
-		sourceRec,
-		destRec,
-		origin,
-		0,
-		r.Color{R: 255, G: 255, B: 255, A: alpha},
-	)
+	p.Anim.Draw(destRec, origin, 0, r.Color{R: 255, G: 255, B: 255, A: alpha})
 
 	// Debug: Draw collision box only when debug is true
 	if debug {
@@ -341,47 +307,20 @@ func (p *Player) Draw(debug bool, camera r.Camera2D) {
 		)
 	}
 
-	// Draw slash animation if active
-	if p.SlashAnim.Active {
-		p.SlashAnim.FrameTime += r.GetFrameTime()
-		if p.SlashAnim.FrameTime >= 0.05 {
-			p.SlashAnim.Frame++
-			p.SlashAnim.FrameTime = 0
-			if p.SlashAnim.Frame >= 6 {
-				p.SlashAnim.Active = false
-				p.SlashAnim.Frame = 0
-			}
-		}
-
-		// Draw the current frame with 0.5 scale
-		r.DrawTexturePro(
-			p.SlashAnim.Texture,
-			r.Rectangle{
-				X:      float32(p.SlashAnim.Frame) * float32(p.SlashAnim.FrameWidth),
-				Y:      0,
-				Width:  float32(p.SlashAnim.FrameWidth),
-				Height: float32(p.SlashAnim.FrameHeight),
-			},
-			r.Rectangle{
-				X:      p.X + float32(p.Width)/2 - (float32(p.SlashAnim.FrameWidth)*0.5)/2,
-				Y:      p.Y + float32(p.Height)/2 - (float32(p.SlashAnim.FrameHeight)*0.5)/2,
-				Width:  float32(p.SlashAnim.FrameWidth) * 0.5,
-				Height: float32(p.SlashAnim.FrameHeight) * 0.5,
-			},
-			r.Vector2{X: 0, Y: 0},
-			0,
-			r.White,
-		)
-	}
-
 	// Draw current weapon
 	p.CurrentWeapon.Draw(p, camera, debug)
+
+	// Draw the cast under way and the spells going off
+	p.Spells.Draw(p, debug)
 }
 
-// Unload frees the texture from memory
+// Unload frees the player's textures and weapons from memory
 func (p *Player) Unload() {
-	r.UnloadTexture(p.Texture)
-	r.UnloadTexture(p.SlashAnim.Texture)
+	p.Anim.Unload()
+	for _, weapon := range p.Weapons {
+		weapon.Unload()
+	}
+	p.Spells.Unload()
 }
 
 // GetBounds returns the player's bounding rectangle
@@ -394,6 +333,21 @@ func (p *Player) GetBounds() r.Rectangle {
 	}
 }
 
+// move walks the player by step, sliding along solid entities and staying inside the dimension
+func (p *Player) move(world *World, step r.Vector2) {
+	position := r.Vector2{X: p.X + step.X, Y: p.Y + step.Y}
+	if world != nil {
+		position = moveAndSlide(world, p.GetBounds(), step)
+	}
+	p.X = float32(Max(0, Min(float64(position.X), float64(p.GameWidth-p.Width))))
+	p.Y = float32(Max(0, Min(float64(position.Y), float64(p.GameHeight-p.Height))))
+}
+
+// center returns the middle of the player
+func (p *Player) center() r.Vector2 {
+	return r.Vector2{X: p.X + float32(p.Width)/2, Y: p.Y + float32(p.Height)/2}
+}
+
 // Helper functions for min/max operations
 func Min(a, b float64) float64 {
 	if a < b {
@@ -425,6 +379,7 @@ func (p *Player) TakeDamage(damage int32) {
 			p.CurrentHealth = 0
 		}
 		p.InvincibleTimer = p.InvincibleTime // Start invincibility period
+		p.Anim.Restart("hit")
 	}
 }
 
@@ -459,10 +414,13 @@ func (p *Player) GainExperience(amount int) {
 
 // Add method for leveling up
 func (p *Player) LevelUp() {
+	p.Level++
 	p.Experience -= p.NextLevelExp
 	p.NextLevelExp = int(float32(p.NextLevelExp) * 1.5) // Increase required exp by 50%
 	p.MaxHealth += 10
 	p.CurrentHealth = p.MaxHealth
+	p.MaxMana += 10
+	p.Mana = p.MaxMana
 	// You can add more level-up bonuses here
 }
 
@@ -471,10 +429,12 @@ func (p *Player) UpdateHarvestDamage(inventory *Inventory) {
 	// Base harvest damage
 	p.HarvestDamage = 1
 
-	// Check if inventory exists and has pickaxe
+	// Carried tools like the pickaxe add their bonus once, however many are held
 	if inventory != nil {
-		if count, exists := inventory.ItemCounts["Pickaxe"]; exists && count > 0 {
-			p.HarvestDamage += 3 // Add 3 harvest damage if pickaxe is in inventory
+		for id, count := range inventory.ItemCounts {
+			if count > 0 {
+				p.HarvestDamage += items.Get(id).HarvestBonus
+			}
 		}
 	}
 }
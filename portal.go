@@ -1,60 +1,82 @@
 package main
 
 import (
+	"math"
 	"math/rand"
 
 	r "github.com/gen2brain/raylib-go/raylib"
 )
 
+// Spawn is one enemy a portal will let through
+type Spawn struct {
+	Kind  string `json:"kind"`
+	Elite bool   `json:"elite,omitempty"`
+}
+
 type Portal struct {
 	X          float32
 	Y          float32
 	Width      int32
 	Height     int32
-	Texture    r.Texture2D
+	Anim       *Animator
 	SpawnTimer float32
 	SpawnRate  float32
 	SpawnCount int
-	MaxSpawns  int
+	Spawns     []Spawn // Enemies still to come through, planned by the wave director
 	IsDone     bool
+	rng        *rand.Rand // Drives placement and spawn offsets
+}
+
+// NewPortal places a portal for the given enemies on a free spot, drawing from its own spawn generator
+func NewPortal(gameWidth, gameHeight int32, world *World, spawns []Spawn, rng *rand.Rand) *Portal {
+	spot := freePortalSpot(gameWidth, gameHeight, world, rng)
+	return NewPortalAt(spot.X, spot.Y, spawns, rng)
 }
 
-func NewPortal(gameWidth, gameHeight int32, game *Game) *Portal {
+// freePortalSpot picks a position away from the edges where a portal overlaps no obstacle
+func freePortalSpot(gameWidth, gameHeight int32, world *World, rng *rand.Rand) r.Vector2 {
 	const padding float32 = 100
 	var x, y float32
 	var bounds r.Rectangle
 
 	// Keep trying positions until we find an unoccupied spot
 	for {
-		x = padding + float32(rand.Float64()*float64(float32(gameWidth)-2*padding))
-		y = padding + float32(rand.Float64()*float64(float32(gameHeight)-2*padding))
+		x = padding + float32(rng.Float64()*float64(float32(gameWidth)-2*padding))
+		y = padding + float32(rng.Float64()*float64(float32(gameHeight)-2*padding))
 		bounds = r.Rectangle{
 			X:      x,
 			Y:      y,
 			Width:  16,
 			Height: 32,
 		}
-		if !game.IsPositionOccupied(bounds, 30) {
+		if !world.IsOccupied(bounds, 30) {
 			break
 		}
 	}
 
+	return r.Vector2{X: x, Y: y}
+}
+
+// NewPortalAt creates a fresh portal for the given enemies at a known position
+func NewPortalAt(x, y float32, spawns []Spawn, rng *rand.Rand) *Portal {
 	return &Portal{
 		X:          x,
 		Y:          y,
 		Width:      16,
 		Height:     32,
-		Texture:    r.LoadTexture("assets/portal.png"),
+		Anim:       NewAnimator("portal"),
 		SpawnRate:  5.0,
 		SpawnTimer: 5.0,
 		SpawnCount: 0,
-		MaxSpawns:  10,
+		Spawns:     spawns,
 		IsDone:     false,
+		rng:        rng,
 	}
 }
 
 func (p *Portal) Update(deltaTime float32) bool {
-	if p.SpawnCount >= p.MaxSpawns {
+	p.Anim.Update(deltaTime)
+	if len(p.Spawns) == 0 {
 		p.IsDone = true
 		return false
 	}
@@ -69,13 +91,7 @@ func (p *Portal) Update(deltaTime float32) bool {
 }
 
 func (p *Portal) Draw(debug bool) {
-	r.DrawTextureEx(
-		p.Texture,
-		r.Vector2{X: p.X, Y: p.Y},
-		0,
-		1,
-		r.White,
-	)
+	p.Anim.DrawAt(r.Vector2{X: p.X, Y: p.Y}, 1, r.White)
 
 	if debug {
 		r.DrawRectangleLines(
@@ -89,15 +105,114 @@ func (p *Portal) Draw(debug bool) {
 }
 
 func (p *Portal) Unload() {
-	r.UnloadTexture(p.Texture)
+	p.Anim.Unload()
+}
+
+// NextSpawn takes the enemy coming through next
+func (p *Portal) NextSpawn() Spawn {
+	spawn := p.Spawns[0]
+	p.Spawns = p.Spawns[1:]
+	return spawn
 }
 
 // Add method to get spawn position
 func (p *Portal) GetSpawnPosition() r.Vector2 {
 	// Spawn enemy slightly offset from portal center
-	offset := float32(rand.Float32()*40 - 20) // Random offset between -20 and 20
+	offset := float32(p.rng.Float32()*40 - 20) // Random offset between -20 and 20
 	return r.Vector2{
 		X: p.X + float32(p.Width)/2 + offset,
 		Y: p.Y + float32(p.Height)/2 + offset,
 	}
 }
+
+// DrawLayer puts portals on the ground
+func (p *Portal) DrawLayer() Layer {
+	return LayerGround
+}
+
+// BlocksPlacement keeps trees, stones and other portals off the portal
+func (p *Portal) BlocksPlacement() bool {
+	return true
+}
+
+// GetBounds returns the portal's bounding rectangle
+func (p *Portal) GetBounds() r.Rectangle {
+	return r.Rectangle{
+		X:      p.X,
+		Y:      p.Y,
+		Width:  float32(p.Width),
+		Height: float32(p.Height),
+	}
+}
+
+// ExitPortal opens once a dimension's goal is met and leads to the next dimension
+type ExitPortal struct {
+	X      float32
+	Y      float32
+	Width  int32
+	Height int32
+	Anim   *Animator
+	Timer  float32 // Drives the pulsing glow
+}
+
+// NewExitPortal creates an exit portal at a known position
+func NewExitPortal(x, y float32) *ExitPortal {
+	return &ExitPortal{
+		X:      x,
+		Y:      y,
+		Width:  16,
+		Height: 32,
+		Anim:   NewAnimator("exit_portal"),
+	}
+}
+
+func (p *ExitPortal) Update(deltaTime float32) {
+	p.Timer += deltaTime
+	p.Anim.Update(deltaTime)
+}
+
+func (p *ExitPortal) Draw(debug bool) {
+	// Pulse between sky blue and white so it stands out from enemy portals
+	// The swirl is larger than the exit, so it is centered on it
+	glow := uint8(180 + 75*math.Sin(float64(p.Timer*4)))
+	width, height := p.Anim.FrameSize()
+	position := r.Vector2{
+		X: p.X + float32(p.Width)/2 - width/2,
+		Y: p.Y + float32(p.Height)/2 - height/2,
+	}
+	p.Anim.DrawAt(position, 1, r.Color{R: glow, G: 220, B: 255, A: 255})
+
+	if debug {
+		r.DrawRectangleLines(
+			int32(p.X),
+			int32(p.Y),
+			p.Width,
+			p.Height,
+			r.SkyBlue,
+		)
+	}
+}
+
+func (p *ExitPortal) Unload() {
+	p.Anim.Unload()
+}
+
+// DrawLayer puts the exit on the ground
+func (p *ExitPortal) DrawLayer() Layer {
+	return LayerGround
+}
+
+// BlocksPlacement keeps enemy portals off the exit
+func (p *ExitPortal) BlocksPlacement() bool {
+	return true
+}
+
+// GetBounds returns the exit's bounding rectangle
+func (p *ExitPortal) GetBounds() r.Rectangle {
+	return r.Rectangle{
+		X:      p.X,
+		Y:      p.Y,
+		Width:  float32(p.Width),
+		Height: float32(p.Height),
+	}
+}
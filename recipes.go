@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// RecipesFile holds every crafting recipe
+const RecipesFile = "assets/recipes.json"
+
+// recipeReloadInterval is how often the recipe file is checked for changes
+const recipeReloadInterval = 1.0
+
+// StationRange is how close the player must stand to a station to use it
+const StationRange = 60
+
+// StationMerchant is the crafting station the merchant provides
+const StationMerchant = "merchant"
+
+// knownStations lists the stations recipes may require
+var knownStations = map[string]bool{
+	StationMerchant: true,
+}
+
+// Recipe represents a crafting recipe, naming items by their ID
+type Recipe struct {
+	ID        string         `json:"id"`
+	Result    string         `json:"result"`
+	Quantity  int            `json:"quantity"`
+	Materials map[string]int `json:"materials"`
+	Tool      string         `json:"tool,omitempty"`    // Item that must be held, it is not used up
+	Station   string         `json:"station,omitempty"` // Station the player must stand near
+	Unlock    RecipeUnlock   `json:"unlock"`
+}
+
+// RecipeUnlock lists what a player needs before a recipe shows up at all
+type RecipeUnlock struct {
+	Level     int      `json:"level,omitempty"`     // Minimum player level
+	Items     []string `json:"items,omitempty"`     // Items the player must hold, such as a first golden nugget
+	Dimension string   `json:"dimension,omitempty"` // Dimension the player must have ascended to
+}
+
+// LoadRecipes reads a recipe file, checking every item and station it names exists
+func LoadRecipes(path string) ([]Recipe, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var recipes []Recipe
+	if err := json.Unmarshal(raw, &recipes); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	seen := make(map[string]bool)
+	for i := range recipes {
+		recipe := &recipes[i]
+		if recipe.ID == "" {
+			recipe.ID = recipe.Result
+		}
+		if seen[recipe.ID] {
+			return nil, fmt.Errorf("%s: recipe %q is defined twice", path, recipe.ID)
+		}
+		seen[recipe.ID] = true
+
+		if recipe.Quantity == 0 {
+			recipe.Quantity = 1
+		}
+		if err := validateRecipe(recipe); err != nil {
+			return nil, fmt.Errorf("%s: recipe %q: %w", path, recipe.ID, err)
+		}
+	}
+	return recipes, nil
+}
+
+// validateRecipe checks a recipe only names known items and stations
+func validateRecipe(recipe *Recipe) error {
+	if _, exists := items.Lookup(recipe.Result); !exists {
+		return fmt.Errorf("result is unknown item %q", recipe.Result)
+	}
+	if recipe.Quantity < 0 {
+		return fmt.Errorf("quantity must be positive")
+	}
+	if len(recipe.Materials) == 0 {
+		return fmt.Errorf("has no materials")
+	}
+	for id, count := range recipe.Materials {
+		if _, exists := items.Lookup(id); !exists {
+			return fmt.Errorf("material is unknown item %q", id)
+		}
+		if count <= 0 {
+			return fmt.Errorf("material %q needs a positive count", id)
+		}
+	}
+	if recipe.Tool != "" {
+		if _, exists := items.Lookup(recipe.Tool); !exists {
+			return fmt.Errorf("tool is unknown item %q", recipe.Tool)
+		}
+	}
+	if recipe.Station != "" && !knownStations[recipe.Station] {
+		return fmt.Errorf("unknown station %q", recipe.Station)
+	}
+	for _, id := range recipe.Unlock.Items {
+		if _, exists := items.Lookup(id); !exists {
+			return fmt.Errorf("unlock needs unknown item %q", id)
+		}
+	}
+	if recipe.Unlock.Dimension != "" {
+		if _, exists := dimensionIndex(recipe.Unlock.Dimension); !exists {
+			return fmt.Errorf("unlock needs unknown dimension %q", recipe.Unlock.Dimension)
+		}
+	}
+	return nil
+}
+
+// recipeWatcher reloads the recipe file when it changes on disk
+type recipeWatcher struct {
+	path    string
+	modTime time.Time
+	timer   float32
+}
+
+// changed reports whether the file was modified since the last check, checking at most once per interval
+func (w *recipeWatcher) changed(deltaTime float32) bool {
+	w.timer -= deltaTime
+	if w.timer > 0 {
+		return false
+	}
+	w.timer = recipeReloadInterval
+
+	info, err := os.Stat(w.path)
+	if err != nil || info.ModTime().Equal(w.modTime) {
+		return false
+	}
+	w.modTime = info.ModTime()
+	return true
+}
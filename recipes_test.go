@@ -0,0 +1,176 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+// findRecipe returns the recipe with the given ID
+func findRecipe(t *testing.T, recipes []Recipe, id string) Recipe {
+	t.Helper()
+	for _, recipe := range recipes {
+		if recipe.ID == id {
+			return recipe
+		}
+	}
+	t.Fatalf("no recipe %q", id)
+	return Recipe{}
+}
+
+func TestRecipeFileLoads(t *testing.T) {
+	loadDataFiles()
+	recipes, err := LoadRecipes(RecipesFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recipes) != 8 {
+		t.Fatalf("got %d recipes, want 8", len(recipes))
+	}
+	if bulk := findRecipe(t, recipes, "gold_coin_bulk"); bulk.Quantity != 4 || bulk.Station != StationMerchant {
+		t.Fatalf("bulk coin recipe = %+v", bulk)
+	}
+}
+
+func TestRecipeDefaults(t *testing.T) {
+	loadDataFiles()
+	recipes, err := LoadRecipes(writeTestFile(t, "recipes.json", `[{"result": "pickaxe", "materials": {"strange_log": 1}}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recipes[0].ID != "pickaxe" || recipes[0].Quantity != 1 {
+		t.Fatalf("recipe = %+v, want ID and quantity filled in", recipes[0])
+	}
+}
+
+func TestLoadRecipesRejectsBadFiles(t *testing.T) {
+	loadDataFiles()
+	files := map[string]string{
+		"unknown result":    `[{"result": "nope", "materials": {"strange_log": 1}}]`,
+		"unknown material":  `[{"result": "pickaxe", "materials": {"nope": 1}}]`,
+		"no materials":      `[{"result": "pickaxe"}]`,
+		"zero material":     `[{"result": "pickaxe", "materials": {"strange_log": 0}}]`,
+		"unknown tool":      `[{"result": "pickaxe", "materials": {"strange_log": 1}, "tool": "nope"}]`,
+		"unknown station":   `[{"result": "pickaxe", "materials": {"strange_log": 1}, "station": "forge"}]`,
+		"unknown dimension": `[{"result": "pickaxe", "materials": {"strange_log": 1}, "unlock": {"dimension": "nope"}}]`,
+		"duplicate": `[{"result": "pickaxe", "materials": {"strange_log": 1}},
+			{"result": "pickaxe", "materials": {"stone_fragment": 1}}]`,
+	}
+	for name, content := range files {
+		if _, err := LoadRecipes(writeTestFile(t, "recipes.json", content)); err == nil {
+			t.Errorf("%s: loaded without an error", name)
+		}
+	}
+}
+
+func TestRecipesHotReload(t *testing.T) {
+	loadDataFiles()
+	path := writeTestFile(t, "recipes.json", `[{"result": "pickaxe", "materials": {"strange_log": 1}}]`)
+	cs := NewCraftingSystem(path)
+	if len(cs.Recipes) != 1 {
+		t.Fatalf("got %d recipes, want 1", len(cs.Recipes))
+	}
+	input := NewScriptedInput()
+
+	rewrite := func(content string, age time.Duration) {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		modTime := time.Now().Add(age)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A broken edit keeps the recipes that were loaded
+	rewrite(`[{"result": "pickaxe", "materials": {"nope": 1}}]`, time.Hour)
+	cs.Update(recipeReloadInterval+0.1, input, nil)
+	if len(cs.Recipes) != 1 {
+		t.Fatalf("broken file replaced the recipes: %v", cs.Recipes)
+	}
+
+	// The file is only checked once per interval
+	rewrite(`[{"result": "pickaxe", "materials": {"strange_log": 1}},
+		{"result": "gold_coin", "materials": {"strange_log": 1}}]`, 2*time.Hour)
+	cs.Update(recipeReloadInterval/2, input, nil)
+	if len(cs.Recipes) != 1 {
+		t.Fatal("reloaded before the interval passed")
+	}
+	cs.Update(recipeReloadInterval/2+0.1, input, nil)
+	if len(cs.Recipes) != 2 {
+		t.Fatalf("got %d recipes after the reload, want 2", len(cs.Recipes))
+	}
+}
+
+func TestCraftingRequirements(t *testing.T) {
+	g := NewHeadlessGame(NewScriptedInput(), 5)
+	defer g.Cleanup()
+
+	bulk := findRecipe(t, g.crafting.Recipes, "gold_coin_bulk")
+	g.inventory.Add("golden_nugget", 10)
+	g.inventory.Add("pickaxe", 1)
+	if g.crafting.IsUnlocked(bulk, g.inventory) {
+		t.Fatal("unlocked below the required level")
+	}
+
+	g.player.Level = 2
+	g.Step(1.0 / 60)
+	if !g.crafting.IsUnlocked(bulk, g.inventory) || g.crafting.CanCraft(bulk, g.inventory) {
+		t.Fatal("craftable away from the merchant")
+	}
+
+	g.player.X, g.player.Y = g.merchant.X, g.merchant.Y
+	g.Step(1.0 / 60)
+	if !g.crafting.NearStations[StationMerchant] || !g.crafting.CanCraft(bulk, g.inventory) {
+		t.Fatalf("not craftable at the merchant, near %v", g.crafting.NearStations)
+	}
+	g.crafting.CraftItem(bulk, g.inventory)
+	if g.inventory.ItemCounts["gold_coin"] != 4 || g.inventory.ItemCounts["golden_nugget"] != 0 {
+		t.Fatalf("inventory after crafting = %v", g.inventory.ItemCounts)
+	}
+	if g.inventory.ItemCounts["pickaxe"] != 1 {
+		t.Fatal("the tool was used up")
+	}
+}
+
+func TestCraftingThroughTheWindow(t *testing.T) {
+	input := NewScriptedInput()
+	g := NewHeadlessGame(input, 6)
+	defer g.Cleanup()
+	clearObstacles(g)
+
+	click := func(button r.Rectangle) {
+		input.MoveMouse(button.X+button.Width/2, button.Y+button.Height/2)
+		input.PressButton(0)
+		g.Step(1.0 / 60)
+		input.ReleaseButton(0)
+	}
+
+	input.PressKey(r.KeyC)
+	g.Step(1.0 / 60)
+	input.ReleaseKey(r.KeyC)
+	if !g.crafting.IsOpen {
+		t.Fatal("crafting window did not open")
+	}
+
+	// The pickaxe is the first recipe listed
+	g.inventory.Add("strange_log", 2)
+	g.inventory.Add("stone_fragment", 3)
+	click(craftButton(0))
+	if g.inventory.ItemCounts["pickaxe"] != 1 || g.inventory.ItemCounts["strange_log"] != 0 {
+		t.Fatalf("inventory after crafting = %v", g.inventory.ItemCounts)
+	}
+
+	// Without materials the button does nothing
+	click(craftButton(0))
+	if g.inventory.ItemCounts["pickaxe"] != 1 {
+		t.Fatal("crafted without materials")
+	}
+
+	click(craftingCloseButton)
+	if g.crafting.IsOpen {
+		t.Fatal("close button left the crafting window open")
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RandomStreams hands out the random number generators of one run.
+// Every stream is derived from the world seed, so the same seed always gives
+// the same map, spawns and drops. Cosmetic effects like particles and camera
+// shake keep using the global source since they never change the outcome.
+type RandomStreams struct {
+	Seed  int64
+	World *rand.Rand // Map layout: grass, trees and stones
+}
+
+// NewRandomStreams creates the streams for a world seed
+func NewRandomStreams(seed int64) *RandomStreams {
+	return &RandomStreams{
+		Seed:  seed,
+		World: rand.New(rand.NewSource(deriveSeed(seed, "world", 0))),
+	}
+}
+
+// EnterDimension points World at the layout stream of a dimension.
+// The first dimension keeps the layout World starts with.
+func (s *RandomStreams) EnterDimension(index int) {
+	s.World = s.Derive("world", index)
+}
+
+// Derive returns a generator for one numbered event of a stream, such as the
+// fifth portal or the twelfth goodie bag. Each event gets its own generator,
+// so the order in which the player triggers events never shifts the others.
+func (s *RandomStreams) Derive(stream string, index int) *rand.Rand {
+	return rand.New(rand.NewSource(deriveSeed(s.Seed, stream, index)))
+}
+
+// deriveSeed mixes the world seed with a stream name and index
+func deriveSeed(seed int64, stream string, index int) int64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(seed))
+	h.Write(buf[:])
+	h.Write([]byte(stream))
+	binary.LittleEndian.PutUint64(buf[:], uint64(index))
+	h.Write(buf[:])
+	return int64(h.Sum64())
+}
+
+// ParseSeed turns what the player typed into a seed.
+// Numbers are used as they are, any other text is hashed.
+func ParseSeed(text string) int64 {
+	text = strings.TrimSpace(text)
+	if seed, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return seed
+	}
+	h := fnv.New64a()
+	h.Write([]byte(text))
+	return int64(h.Sum64())
+}
+
+// RandomSeed picks a fresh seed for players who do not type one
+func RandomSeed() int64 {
+	return time.Now().UnixNano() % 1000000000
+}
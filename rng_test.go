@@ -0,0 +1,121 @@
+package main
+
+import (
+	"testing"
+
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+func TestSameSeedSameWorld(t *testing.T) {
+	a := NewHeadlessGame(NewScriptedInput(), 7)
+	defer a.Cleanup()
+	b := NewHeadlessGame(NewScriptedInput(), 7)
+	defer b.Cleanup()
+	c := NewHeadlessGame(NewScriptedInput(), 8)
+	defer c.Cleanup()
+
+	aTrees, bTrees, cTrees := Query[*Tree](a.world), Query[*Tree](b.world), Query[*Tree](c.world)
+	if len(aTrees) != len(bTrees) {
+		t.Fatalf("got %d and %d trees for the same seed", len(aTrees), len(bTrees))
+	}
+	for i := range aTrees {
+		if aTrees[i].X != bTrees[i].X || aTrees[i].Y != bTrees[i].Y {
+			t.Fatalf("tree %d differs for the same seed", i)
+		}
+	}
+	aStones, bStones := Query[*Stone](a.world), Query[*Stone](b.world)
+	for i := range aStones {
+		if aStones[i].X != bStones[i].X || aStones[i].IsGolden != bStones[i].IsGolden {
+			t.Fatalf("stone %d differs for the same seed", i)
+		}
+	}
+	if aTrees[0].X == cTrees[0].X && aTrees[0].Y == cTrees[0].Y {
+		t.Fatal("another seed gave the same first tree")
+	}
+}
+
+func TestPlayerActionsDoNotShiftSpawns(t *testing.T) {
+	idle := NewHeadlessGame(NewScriptedInput(), 7)
+	defer idle.Cleanup()
+	input := NewScriptedInput()
+	walking := NewHeadlessGame(input, 7)
+	defer walking.Cleanup()
+
+	input.PressKey(r.KeyD)
+	for i := 0; i < 1300; i++ {
+		idle.Step(1.0 / 60)
+		walking.Step(1.0 / 60)
+	}
+
+	idlePortals, walkingPortals := Query[*Portal](idle.world), Query[*Portal](walking.world)
+	if len(idlePortals) == 0 || len(idlePortals) != len(walkingPortals) {
+		t.Fatalf("got %d and %d portals", len(idlePortals), len(walkingPortals))
+	}
+	if idlePortals[0].X != walkingPortals[0].X || idlePortals[0].Y != walkingPortals[0].Y {
+		t.Fatal("walking around moved the first portal")
+	}
+
+	// Goodie bags give the same loot in the same order
+	idle.inventory.ItemCounts["goodie_bag"] = 3
+	walking.inventory.ItemCounts["goodie_bag"] = 3
+	for i := 0; i < 3; i++ {
+		idle.inventory.UseItem("goodie_bag")
+		walking.inventory.UseItem("goodie_bag")
+		if idle.inventory.LastUsedItem != walking.inventory.LastUsedItem {
+			t.Fatalf("bag %d gave %q and %q", i, idle.inventory.LastUsedItem, walking.inventory.LastUsedItem)
+		}
+	}
+}
+
+func TestDeriveIsStable(t *testing.T) {
+	streams := NewRandomStreams(99)
+	if streams.Derive("portal", 3).Int63() != streams.Derive("portal", 3).Int63() {
+		t.Fatal("same stream and index gave different numbers")
+	}
+	if streams.Derive("portal", 3).Int63() == streams.Derive("portal", 4).Int63() {
+		t.Fatal("different indexes gave the same numbers")
+	}
+}
+
+func TestParseSeed(t *testing.T) {
+	if seed := ParseSeed(" 1234 "); seed != 1234 {
+		t.Fatalf("ParseSeed(1234) = %d", seed)
+	}
+	if ParseSeed("kahozr") != ParseSeed("kahozr") || ParseSeed("kahozr") == ParseSeed("kahozs") {
+		t.Fatal("text seeds are not hashed consistently")
+	}
+}
+
+func TestTypedSeedStartsTheRun(t *testing.T) {
+	input := NewScriptedInput()
+	g := NewHeadlessGame(input, 1)
+	defer g.Cleanup()
+	g.state = StateMenu
+	g.menu.Initialize()
+
+	click := func(button r.Rectangle) {
+		input.MoveMouse(button.X+1, button.Y+1)
+		input.PressButton(0)
+		g.Step(1.0 / 60)
+		input.ReleaseButton(0)
+	}
+	press := func(key int32) {
+		input.PressKey(key)
+		g.Step(1.0 / 60)
+		input.ReleaseKey(key)
+	}
+
+	// Clear the suggested seed and type another
+	click(g.menu.seedField)
+	for i := 0; i < 20 && len(g.menu.seedText) > 0; i++ {
+		press(r.KeyBackspace)
+	}
+	input.TypeText("1234")
+	g.Step(1.0 / 60)
+	press(r.KeyEnter)
+
+	click(g.menu.playButton)
+	if g.state != StatePlaying || g.rng.Seed != 1234 {
+		t.Fatalf("state %v with seed %d, want playing seed 1234", g.state, g.rng.Seed)
+	}
+}
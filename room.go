@@ -0,0 +1,284 @@
+package main
+
+import (
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+// KeyItem is the item that unlocks a locked door, one key per door
+const KeyItem = "key"
+
+// Room tuning
+const (
+	wallSize         = 16  // Walls are square tiles of this size
+	doorTiles        = 3   // Doors span this many wall tiles, so a path fits between the walls beside them
+	lockedRoomChance = 0.4 // Share of rooms behind a locked door
+	doorReach        = 12  // How far from a door the player can open it
+)
+
+// Sides of a room a door can be in
+const (
+	SideTop    = "top"
+	SideBottom = "bottom"
+	SideLeft   = "left"
+	SideRight  = "right"
+)
+
+// roomSides lists the sides in the order they are rolled from
+var roomSides = []string{SideTop, SideBottom, SideLeft, SideRight}
+
+// Loot waiting inside rooms, locked rooms hold more
+var (
+	roomLoot       = []LootDrop{{Item: "goodie_bag", Min: 1, Max: 2}}
+	lockedRoomLoot = []LootDrop{
+		{Item: "goodie_bag", Min: 2, Max: 3},
+		{Item: "golden_nugget", Min: 2, Max: 4},
+	}
+)
+
+// Wall is one solid tile, nothing walks or shoots through it
+type Wall struct {
+	X       float32
+	Y       float32
+	Texture r.Texture2D
+}
+
+func NewWall(x, y float32) *Wall {
+	return &Wall{
+		X:       x,
+		Y:       y,
+		Texture: loadTexture("assets/wall.png"),
+	}
+}
+
+func (w *Wall) Draw(debug bool) {
+	r.DrawTexture(w.Texture, int32(w.X), int32(w.Y), r.White)
+
+	if debug {
+		r.DrawRectangleLines(int32(w.X), int32(w.Y), wallSize, wallSize, r.Red)
+	}
+}
+
+func (w *Wall) Unload() {
+	unloadTexture(w.Texture)
+}
+
+func (w *Wall) GetBounds() r.Rectangle {
+	return r.Rectangle{X: w.X, Y: w.Y, Width: wallSize, Height: wallSize}
+}
+
+// IsSolid stops the player and enemies at walls
+func (w *Wall) IsSolid() bool {
+	return true
+}
+
+// BlocksMovement makes enemies path around walls
+func (w *Wall) BlocksMovement() bool {
+	return true
+}
+
+// BlocksBullets stops bullets at walls
+func (w *Wall) BlocksBullets() bool {
+	return true
+}
+
+// BlocksPlacement keeps other objects from spawning inside walls
+func (w *Wall) BlocksPlacement() bool {
+	return true
+}
+
+// Door is a gap in a room's walls that opens on interaction. Locked doors
+// need a key, and doors seal shut while a wave is on so rooms are no hideout.
+type Door struct {
+	X      float32
+	Y      float32
+	Width  float32
+	Height float32
+	Open   bool
+	Locked bool
+	Sealed bool // Shut until the wave ends, nothing opens it meanwhile
+	Reopen bool // Was open when it sealed, so it opens again after the wave
+}
+
+func NewDoor(x, y, width, height float32, locked bool) *Door {
+	return &Door{
+		X:      x,
+		Y:      y,
+		Width:  width,
+		Height: height,
+		Locked: locked,
+	}
+}
+
+// Interact opens or closes the door for the player and returns what to tell them.
+// The door never closes on whoever stands in it.
+func (d *Door) Interact(inventory *Inventory, occupied r.Rectangle) string {
+	switch {
+	case d.Sealed:
+		return "Sealed"
+	case d.Locked:
+		if !inventory.Remove(KeyItem, 1) {
+			return "Locked"
+		}
+		d.Locked = false
+		d.Open = true
+		return "Unlocked"
+	case d.Open:
+		if !r.CheckCollisionRecs(d.GetBounds(), occupied) {
+			d.Open = false
+		}
+	default:
+		d.Open = true
+	}
+	return ""
+}
+
+// Seal shuts the door while sealed is set and opens it again once it is not
+func (d *Door) Seal(sealed bool) {
+	if sealed && d.Open {
+		d.Open = false
+		d.Reopen = true
+	}
+	if !sealed && d.Reopen {
+		d.Open = true
+		d.Reopen = false
+	}
+	d.Sealed = sealed
+}
+
+func (d *Door) Draw(debug bool) {
+	bounds := d.GetBounds()
+	if d.Open {
+		r.DrawRectangleLinesEx(bounds, 1, r.ColorAlpha(r.Brown, 0.6))
+	} else {
+		r.DrawRectangleRec(bounds, r.Brown)
+		r.DrawRectangleLinesEx(bounds, 2, r.DarkBrown)
+	}
+
+	// Locked doors show a keyhole, sealed ones glow red
+	if d.Locked {
+		r.DrawRectangle(int32(d.X+d.Width/2-2), int32(d.Y+d.Height/2-3), 4, 6, r.Gold)
+	}
+	if d.Sealed && !d.Open {
+		r.DrawRectangleLinesEx(bounds, 1, r.Red)
+	}
+
+	if debug {
+		r.DrawRectangleLinesEx(bounds, 1, r.Red)
+	}
+}
+
+func (d *Door) Unload() {}
+
+func (d *Door) GetBounds() r.Rectangle {
+	return r.Rectangle{X: d.X, Y: d.Y, Width: d.Width, Height: d.Height}
+}
+
+// IsSolid stops the player and enemies at closed doors
+func (d *Door) IsSolid() bool {
+	return !d.Open
+}
+
+// BlocksMovement makes enemies path around closed doors
+func (d *Door) BlocksMovement() bool {
+	return !d.Open
+}
+
+// BlocksBullets stops bullets at closed doors
+func (d *Door) BlocksBullets() bool {
+	return !d.Open
+}
+
+// BlocksPlacement keeps doorways clear
+func (d *Door) BlocksPlacement() bool {
+	return true
+}
+
+// Room is a walled enclosure with one door. It is the floor of the room in the
+// world, its walls and door are entities of their own.
+type Room struct {
+	X        float32
+	Y        float32
+	Columns  int // Size in wall tiles, walls included
+	Rows     int
+	DoorSide string
+	Door     *Door
+}
+
+// NewRoom creates a room with its door centered on one side
+func NewRoom(x, y float32, columns, rows int, side string, locked bool) *Room {
+	room := &Room{X: x, Y: y, Columns: columns, Rows: rows, DoorSide: side}
+	column, row := room.doorTile()
+	width, height := float32(doorTiles*wallSize), float32(wallSize)
+	if side == SideLeft || side == SideRight {
+		width, height = height, width
+	}
+	room.Door = NewDoor(x+float32(column*wallSize), y+float32(row*wallSize), width, height, locked)
+	return room
+}
+
+// doorTile returns the first wall tile the door replaces
+func (room *Room) doorTile() (int, int) {
+	switch room.DoorSide {
+	case SideBottom:
+		return (room.Columns - doorTiles) / 2, room.Rows - 1
+	case SideLeft:
+		return 0, (room.Rows - doorTiles) / 2
+	case SideRight:
+		return room.Columns - 1, (room.Rows - doorTiles) / 2
+	}
+	return (room.Columns - doorTiles) / 2, 0
+}
+
+// inDoorway reports whether the door replaces a wall tile
+func (room *Room) inDoorway(column, row int) bool {
+	doorColumn, doorRow := room.doorTile()
+	if room.DoorSide == SideLeft || room.DoorSide == SideRight {
+		return column == doorColumn && row >= doorRow && row < doorRow+doorTiles
+	}
+	return row == doorRow && column >= doorColumn && column < doorColumn+doorTiles
+}
+
+// Build adds the room's floor, walls and door to the world
+func (room *Room) Build(world *World) {
+	world.Add(room)
+	for row := 0; row < room.Rows; row++ {
+		for column := 0; column < room.Columns; column++ {
+			edge := row == 0 || column == 0 || row == room.Rows-1 || column == room.Columns-1
+			if edge && !room.inDoorway(column, row) {
+				world.Add(NewWall(room.X+float32(column*wallSize), room.Y+float32(row*wallSize)))
+			}
+		}
+	}
+	world.Add(room.Door)
+}
+
+// Center returns the middle of the room's floor
+func (room *Room) Center() r.Vector2 {
+	bounds := room.GetBounds()
+	return r.Vector2{X: bounds.X + bounds.Width/2, Y: bounds.Y + bounds.Height/2}
+}
+
+func (room *Room) Draw(debug bool) {
+	r.DrawRectangleRec(room.GetBounds(), r.ColorAlpha(r.Black, 0.2))
+}
+
+func (room *Room) Unload() {}
+
+func (room *Room) GetBounds() r.Rectangle {
+	return r.Rectangle{
+		X:      room.X,
+		Y:      room.Y,
+		Width:  float32(room.Columns * wallSize),
+		Height: float32(room.Rows * wallSize),
+	}
+}
+
+// DrawLayer puts the floor on the ground
+func (room *Room) DrawLayer() Layer {
+	return LayerGround
+}
+
+// BlocksPlacement keeps trees, stones and portals out of rooms
+func (room *Room) BlocksPlacement() bool {
+	return true
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+func TestWallsStopMovement(t *testing.T) {
+	headless = true
+	w := NewWorld()
+	defer w.Unload()
+	NewRoom(100, 100, 9, 8, SideTop, true).Build(w)
+
+	// Walking into the left wall slides along it
+	moved := moveAndSlide(w, r.Rectangle{X: 80, Y: 150, Width: 16, Height: 16}, r.Vector2{X: 10, Y: 3})
+	if moved.X != 84 || moved.Y != 153 {
+		t.Fatalf("moved to %v,%v, want 84,153", moved.X, moved.Y)
+	}
+}
+
+func TestLockedDoor(t *testing.T) {
+	g := NewHeadlessGame(NewScriptedInput(), 9)
+	defer g.Cleanup()
+	room := NewRoom(100, 100, 9, 8, SideTop, true)
+	g.world.Add(room.Door)
+
+	if result := room.Door.Interact(g.inventory, r.Rectangle{}); result != "Locked" || room.Door.Open {
+		t.Fatalf("opening without a key: %q", result)
+	}
+	g.inventory.Add(KeyItem, 1)
+	if result := room.Door.Interact(g.inventory, r.Rectangle{}); result != "Unlocked" || !room.Door.Open {
+		t.Fatalf("opening with a key: %q", result)
+	}
+	if g.inventory.ItemCounts[KeyItem] != 0 {
+		t.Fatal("key was not used up")
+	}
+
+	// A sealed door shuts and stays shut until it is released
+	room.Door.Seal(true)
+	if room.Door.Open || room.Door.Interact(g.inventory, r.Rectangle{}) != "Sealed" {
+		t.Fatal("sealed door opened")
+	}
+	room.Door.Seal(false)
+	if !room.Door.Open {
+		t.Fatal("door did not reopen after the seal")
+	}
+}
+
+func TestPathIntoOpenRoom(t *testing.T) {
+	headless = true
+	w := NewWorld()
+	defer w.Unload()
+	room := NewRoom(100, 100, 9, 8, SideTop, false)
+	room.Build(w)
+
+	nav := NewNavGrid(600, 600)
+	outside := r.Vector2{X: 300, Y: 50}
+	nav.Update(1, w, room.Center())
+	if _, ok := nav.Direction(outside); ok {
+		t.Fatal("path through the closed door")
+	}
+
+	room.Door.Open = true
+	nav.Update(navRebuildInterval, w, room.Center())
+	if _, ok := nav.Direction(outside); !ok {
+		t.Fatal("no path through the open door")
+	}
+}
+
+func TestRoomsSurviveSave(t *testing.T) {
+	g := NewHeadlessGame(NewScriptedInput(), 9)
+	defer g.Cleanup()
+	g.enterDimension(3)
+	rooms := Query[*Room](g.world)
+	if len(rooms) == 0 {
+		t.Fatal("dimension has no rooms")
+	}
+	rooms[0].Door.Open = true
+
+	path := filepath.Join(t.TempDir(), "savegame.json")
+	if err := g.SaveGame(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.LoadGame(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded := Query[*Room](g.world)
+	if len(loaded) != len(rooms) || !loaded[0].Door.Open {
+		t.Fatal("rooms not restored")
+	}
+}
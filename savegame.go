@@ -0,0 +1,671 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// SaveFile is where the current run is stored
+const SaveFile = "savegame.json"
+
+// SaveVersion is the format written by this build. Bump it whenever SaveData
+// changes shape and register a migration from the previous version.
+const SaveVersion = 11
+
+// saveMigrations upgrade raw save data from the version they are keyed by to the next one
+var saveMigrations = map[int]func(data map[string]interface{}) error{
+	// Version 2 added the world seed and the spawn and loot counters.
+	// Older runs had no seed, so they continue on seed 0 from the start of each stream.
+	1: func(data map[string]interface{}) error {
+		data["seed"] = 0
+		data["portals_spawned"] = 0
+		data["enemies_spawned"] = 0
+		data["bags_opened"] = 0
+		return nil
+	},
+	// Version 3 names items by their registry ID instead of their display name
+	2: func(data map[string]interface{}) error {
+		if inventory, ok := data["inventory"].(map[string]interface{}); ok {
+			migrated := make(map[string]interface{})
+			for name, count := range inventory {
+				migrated[legacyItemID(name)] = count
+			}
+			data["inventory"] = migrated
+		}
+		if dropped, ok := data["dropped_items"].([]interface{}); ok {
+			for _, entry := range dropped {
+				if item, ok := entry.(map[string]interface{}); ok {
+					name, _ := item["name"].(string)
+					item["item"] = legacyItemID(name)
+					delete(item, "name")
+					delete(item, "image_path")
+				}
+			}
+		}
+		if merchant, ok := data["merchant"].(map[string]interface{}); ok {
+			if shopItems, ok := merchant["shop_items"].([]interface{}); ok {
+				for _, entry := range shopItems {
+					if item, ok := entry.(map[string]interface{}); ok {
+						name, _ := item["name"].(string)
+						item["item"] = legacyItemID(name)
+						delete(item, "name")
+						delete(item, "icon_path")
+					}
+				}
+			}
+		}
+		return nil
+	},
+	// Version 4 stores the player's level, which older saves only imply through
+	// the experience needed for the next one
+	3: func(data map[string]interface{}) error {
+		player, ok := data["player"].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		nextLevelExp, _ := player["next_level_exp"].(float64)
+		level, needed := 1, 100
+		for float64(needed) < nextLevelExp {
+			needed = int(float32(needed) * 1.5)
+			level++
+		}
+		player["level"] = level
+		return nil
+	},
+	// Version 5 stores the dimension the run is in. Older runs all took place
+	// in the first one and made no progress towards its goal.
+	4: func(data map[string]interface{}) error {
+		data["dimension"] = "tree"
+		data["progress"] = map[string]interface{}{}
+		return nil
+	},
+	// Version 6 replaced the portal timer with the wave director. The timer
+	// becomes the rest before the first wave, and open portals keep spawning
+	// the grunts they had left out of their old ten.
+	5: func(data map[string]interface{}) error {
+		timer, _ := data["portal_spawn_timer"].(float64)
+		data["waves"] = map[string]interface{}{"phase": WaveRest, "timer": timer}
+		delete(data, "portal_spawn_timer")
+
+		if portals, ok := data["portals"].([]interface{}); ok {
+			for _, entry := range portals {
+				if portal, ok := entry.(map[string]interface{}); ok {
+					spawnCount, _ := portal["spawn_count"].(float64)
+					var spawns []string
+					for i := int(spawnCount); i < 10; i++ {
+						spawns = append(spawns, "grunt")
+					}
+					portal["spawns"] = spawns
+				}
+			}
+		}
+		return nil
+	},
+	// Version 7 marks planned enemies that are elites, so every planned
+	// enemy became an object instead of the name of its kind
+	6: func(data map[string]interface{}) error {
+		if portals, ok := data["portals"].([]interface{}); ok {
+			for _, entry := range portals {
+				if portal, ok := entry.(map[string]interface{}); ok {
+					portal["spawns"] = spawnObjects(portal["spawns"])
+				}
+			}
+		}
+		if waves, ok := data["waves"].(map[string]interface{}); ok {
+			if pending, ok := waves["pending"].([]interface{}); ok {
+				for i, group := range pending {
+					pending[i] = spawnObjects(group)
+				}
+			}
+		}
+		return nil
+	},
+	// Version 8 stores the rooms of the dimension. Older runs were generated without any.
+	7: func(data map[string]interface{}) error {
+		data["rooms"] = []interface{}{}
+		return nil
+	},
+	// Version 9 stores the player's mana and spells. Older runs start with a
+	// full pool of the base size and no spells learned.
+	8: func(data map[string]interface{}) error {
+		if player, ok := data["player"].(map[string]interface{}); ok {
+			player["mana"] = 100
+			player["max_mana"] = 100
+			player["spells"] = []interface{}{}
+			player["spell_slots"] = []interface{}{}
+		}
+		return nil
+	},
+	// Version 10 stores the modifiers socketed into each spell. Older runs have none socketed.
+	9: func(data map[string]interface{}) error {
+		if player, ok := data["player"].(map[string]interface{}); ok {
+			player["spell_modifiers"] = map[string]interface{}{}
+		}
+		return nil
+	},
+	// Version 11 stores the traps the player placed. Older runs had none.
+	10: func(data map[string]interface{}) error {
+		data["deployables"] = []interface{}{}
+		return nil
+	},
+}
+
+// spawnObjects turns a list of enemy kind names into spawn objects
+func spawnObjects(kinds interface{}) []interface{} {
+	var names []string
+	switch list := kinds.(type) {
+	case []string:
+		names = list
+	case []interface{}:
+		for _, kind := range list {
+			if name, ok := kind.(string); ok {
+				names = append(names, name)
+			}
+		}
+	}
+
+	spawns := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		spawns = append(spawns, map[string]interface{}{"kind": name})
+	}
+	return spawns
+}
+
+// knownSpawns leaves out planned enemies of kinds this build does not have, such as ones
+// from a save made with a newer dimension file
+func knownSpawns(spawns []Spawn) []Spawn {
+	var known []Spawn
+	for _, spawn := range spawns {
+		if _, exists := enemyKinds[spawn.Kind]; exists {
+			known = append(known, spawn)
+		}
+	}
+	return known
+}
+
+// legacyItemID maps the display names older saves used to item IDs.
+// The names are fixed here, since display names in the item file may change.
+func legacyItemID(name string) string {
+	ids := map[string]string{
+		"Goodie Bag":     "goodie_bag",
+		"Health Potion":  "health_potion",
+		"Strange Log":    "strange_log",
+		"Stone Fragment": "stone_fragment",
+		"Golden Nugget":  "golden_nugget",
+		"Pickaxe":        "pickaxe",
+		"Gold Coin":      "gold_coin",
+	}
+	if id, exists := ids[name]; exists {
+		return id
+	}
+	return name
+}
+
+// SaveData is everything needed to resume a run.
+// Enemies are not stored, active portals keep spawning them after loading.
+type SaveData struct {
+	Version        int               `json:"version"`
+	Seed           int64             `json:"seed"`
+	PortalsSpawned int               `json:"portals_spawned"`
+	EnemiesSpawned int               `json:"enemies_spawned"`
+	BagsOpened     int               `json:"bags_opened"`
+	GameTimer      float32           `json:"game_timer"`
+	Waves          WaveDirector      `json:"waves"`
+	Dimension      string            `json:"dimension"`
+	Progress       DimensionProgress `json:"progress"`
+	Exit           *ExitSave         `json:"exit,omitempty"` // Only once the goal is met
+	Player         PlayerSave        `json:"player"`
+	Inventory      map[string]int    `json:"inventory"` // Item ID to count
+	Trees          []TreeSave        `json:"trees"`
+	Stones         []StoneSave       `json:"stones"`
+	Rooms          []RoomSave        `json:"rooms"`
+	Portals        []PortalSave      `json:"portals"`
+	DroppedItems   []DroppedItemSave `json:"dropped_items"`
+	Deployables    []DeployableSave  `json:"deployables"`
+	Merchant       MerchantSave      `json:"merchant"`
+}
+
+type PlayerSave struct {
+	X              float32             `json:"x"`
+	Y              float32             `json:"y"`
+	FacingLeft     bool                `json:"facing_left"`
+	MaxHealth      int32               `json:"max_health"`
+	CurrentHealth  int32               `json:"current_health"`
+	Level          int                 `json:"level"`
+	Experience     int                 `json:"experience"`
+	NextLevelExp   int                 `json:"next_level_exp"`
+	Weapons        []WeaponSave        `json:"weapons"`
+	CurrentWeapon  int                 `json:"current_weapon"`
+	Mana           float32             `json:"mana"`
+	MaxMana        float32             `json:"max_mana"`
+	Spells         []string            `json:"spells"`          // Spell IDs in the order they were learned
+	SpellSlots     []string            `json:"spell_slots"`     // Spell bound to each cast key, empty for none
+	SpellModifiers map[string][]string `json:"spell_modifiers"` // Modifiers socketed into each spell
+}
+
+type WeaponSave struct {
+	Kind         string  `json:"kind"`
+	HeatLevel    float32 `json:"heat_level,omitempty"`
+	IsOverheated bool    `json:"is_overheated,omitempty"`
+}
+
+type TreeSave struct {
+	X      float32 `json:"x"`
+	Y      float32 `json:"y"`
+	Health int32   `json:"health"`
+}
+
+type StoneSave struct {
+	X        float32 `json:"x"`
+	Y        float32 `json:"y"`
+	Health   int32   `json:"health"`
+	IsGolden bool    `json:"is_golden"`
+}
+
+type RoomSave struct {
+	X        float32 `json:"x"`
+	Y        float32 `json:"y"`
+	Columns  int     `json:"columns"`
+	Rows     int     `json:"rows"`
+	DoorSide string  `json:"door_side"`
+	Open     bool    `json:"open"`
+	Locked   bool    `json:"locked"`
+	Reopen   bool    `json:"reopen,omitempty"` // Sealed by a wave while open
+}
+
+type PortalSave struct {
+	X          float32 `json:"x"`
+	Y          float32 `json:"y"`
+	SpawnTimer float32 `json:"spawn_timer"`
+	SpawnCount int     `json:"spawn_count"`
+	Spawns     []Spawn `json:"spawns"`
+}
+
+type ExitSave struct {
+	X float32 `json:"x"`
+	Y float32 `json:"y"`
+}
+
+type DroppedItemSave struct {
+	X    float32 `json:"x"`
+	Y    float32 `json:"y"`
+	Item string  `json:"item"`
+}
+
+type DeployableSave struct {
+	ID       string  `json:"id"`
+	X        float32 `json:"x"`
+	Y        float32 `json:"y"`
+	ArmTimer float32 `json:"arm_timer,omitempty"`
+	Sprung   bool    `json:"sprung,omitempty"` // Caught enemies are not stored, so sprung traps come back empty
+}
+
+type MerchantSave struct {
+	X         float32    `json:"x"`
+	Y         float32    `json:"y"`
+	ShopItems []ShopItem `json:"shop_items"`
+}
+
+// weaponKind names a weapon for the save file
+func weaponKind(weapon Weapon) string {
+	switch weapon.(type) {
+	case *RayGun:
+		return "raygun"
+	case *Sword:
+		return "sword"
+	case *Pistol:
+		return "pistol"
+	}
+	return ""
+}
+
+// newWeapon creates a weapon from its save file name
+func newWeapon(kind string) (Weapon, error) {
+	switch kind {
+	case "raygun":
+		return NewRayGun(), nil
+	case "sword":
+		return NewSword(), nil
+	case "pistol":
+		return NewPistol(), nil
+	}
+	return nil, fmt.Errorf("unknown weapon %q", kind)
+}
+
+// SaveGame writes the current run to a file
+func (g *Game) SaveGame(path string) error {
+	if g.player == nil {
+		return fmt.Errorf("no game in progress")
+	}
+
+	data := SaveData{
+		Version:        SaveVersion,
+		Seed:           g.rng.Seed,
+		PortalsSpawned: g.portalsSpawned,
+		EnemiesSpawned: g.enemiesSpawned,
+		BagsOpened:     g.inventory.BagsOpened,
+		GameTimer:      g.gameTimer,
+		Waves:          *g.waves,
+		Dimension:      g.currentDimension().ID,
+		Progress:       g.progress,
+		Player: PlayerSave{
+			X:              g.player.X,
+			Y:              g.player.Y,
+			FacingLeft:     g.player.FacingLeft,
+			MaxHealth:      g.player.MaxHealth,
+			CurrentHealth:  g.player.CurrentHealth,
+			Level:          g.player.Level,
+			Experience:     g.player.Experience,
+			NextLevelExp:   g.player.NextLevelExp,
+			Mana:           g.player.Mana,
+			MaxMana:        g.player.MaxMana,
+			Spells:         g.player.Spells.Known,
+			SpellSlots:     g.player.Spells.Slots[:],
+			SpellModifiers: g.player.Spells.Modifiers,
+		},
+		Inventory: make(map[string]int),
+		Merchant: MerchantSave{
+			X:         g.merchant.X,
+			Y:         g.merchant.Y,
+			ShopItems: g.merchant.ShopItems,
+		},
+	}
+
+	for i, weapon := range g.player.Weapons {
+		saved := WeaponSave{Kind: weaponKind(weapon)}
+		if raygun, ok := weapon.(*RayGun); ok {
+			saved.HeatLevel = raygun.HeatLevel
+			saved.IsOverheated = raygun.IsOverheated
+		}
+		data.Player.Weapons = append(data.Player.Weapons, saved)
+		if weapon == g.player.CurrentWeapon {
+			data.Player.CurrentWeapon = i
+		}
+	}
+
+	for item, count := range g.inventory.ItemCounts {
+		if count > 0 {
+			data.Inventory[item] = count
+		}
+	}
+	for _, tree := range Query[*Tree](g.world) {
+		data.Trees = append(data.Trees, TreeSave{X: tree.X, Y: tree.Y, Health: tree.Health})
+	}
+	for _, stone := range Query[*Stone](g.world) {
+		data.Stones = append(data.Stones, StoneSave{
+			X:        stone.X,
+			Y:        stone.Y,
+			Health:   stone.Health,
+			IsGolden: stone.IsGolden,
+		})
+	}
+	for _, room := range Query[*Room](g.world) {
+		data.Rooms = append(data.Rooms, RoomSave{
+			X:        room.X,
+			Y:        room.Y,
+			Columns:  room.Columns,
+			Rows:     room.Rows,
+			DoorSide: room.DoorSide,
+			Open:     room.Door.Open,
+			Locked:   room.Door.Locked,
+			Reopen:   room.Door.Reopen,
+		})
+	}
+	for _, portal := range Query[*Portal](g.world) {
+		data.Portals = append(data.Portals, PortalSave{
+			X:          portal.X,
+			Y:          portal.Y,
+			SpawnTimer: portal.SpawnTimer,
+			SpawnCount: portal.SpawnCount,
+			Spawns:     portal.Spawns,
+		})
+	}
+	for _, exit := range Query[*ExitPortal](g.world) {
+		data.Exit = &ExitSave{X: exit.X, Y: exit.Y}
+	}
+	for _, item := range Query[*DroppedItem](g.world) {
+		data.DroppedItems = append(data.DroppedItems, DroppedItemSave{
+			X:    item.X,
+			Y:    item.Y,
+			Item: item.Item,
+		})
+	}
+	for _, deployable := range Query[*Deployable](g.world) {
+		data.Deployables = append(data.Deployables, DeployableSave{
+			ID:       deployable.Def.ID,
+			X:        deployable.X,
+			Y:        deployable.Y,
+			ArmTimer: max(deployable.ArmTimer, 0),
+			Sprung:   deployable.State == DeploySprung,
+		})
+	}
+
+	encoded, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a crash never leaves a half-written save
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, encoded, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// ReadSave reads a save file, migrating it to the current version
+func ReadSave(path string) (*SaveData, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if err := migrateSave(fields); err != nil {
+		return nil, fmt.Errorf("migrating %s: %w", path, err)
+	}
+
+	// Round-trip the migrated fields into the typed structure
+	migrated, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	data := &SaveData{}
+	if err := json.Unmarshal(migrated, data); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", path, err)
+	}
+	return data, nil
+}
+
+// migrateSave upgrades raw save fields step by step until they match SaveVersion
+func migrateSave(fields map[string]interface{}) error {
+	version := 0
+	if v, ok := fields["version"].(float64); ok {
+		version = int(v)
+	}
+	if version > SaveVersion {
+		return fmt.Errorf("save version %d is newer than this game (%d)", version, SaveVersion)
+	}
+
+	for version < SaveVersion {
+		migrate, ok := saveMigrations[version]
+		if !ok {
+			return fmt.Errorf("no migration from save version %d", version)
+		}
+		if err := migrate(fields); err != nil {
+			return fmt.Errorf("from version %d: %w", version, err)
+		}
+		version++
+		fields["version"] = version
+	}
+	return nil
+}
+
+// LoadGame replaces the current run with one read from a file and resumes playing
+func (g *Game) LoadGame(path string) error {
+	data, err := ReadSave(path)
+	if err != nil {
+		return err
+	}
+
+	// Check the dimension and build the weapons first so a bad save leaves the current run untouched
+	dimension, exists := dimensionIndex(data.Dimension)
+	if !exists {
+		return fmt.Errorf("unknown dimension %q", data.Dimension)
+	}
+	var weapons []Weapon
+	for _, saved := range data.Player.Weapons {
+		weapon, err := newWeapon(saved.Kind)
+		if err != nil {
+			return err
+		}
+		if raygun, ok := weapon.(*RayGun); ok {
+			raygun.HeatLevel = saved.HeatLevel
+			raygun.IsOverheated = saved.IsOverheated
+		}
+		weapons = append(weapons, weapon)
+	}
+
+	g.unloadWorld()
+	g.restoreWorld(data, dimension, weapons)
+	g.state = StatePlaying
+	g.isPaused = false
+	return nil
+}
+
+// restoreWorld rebuilds every object of a run from save data
+func (g *Game) restoreWorld(data *SaveData, dimension int, weapons []Weapon) {
+	g.rng = NewRandomStreams(data.Seed)
+	g.rng.EnterDimension(dimension)
+	g.dimension = dimension
+	g.progress = data.Progress
+	dim := g.currentDimension()
+	g.buildTilemap()
+	g.nav = NewNavGrid(dim.Width, dim.Height)
+	g.portalsSpawned = data.PortalsSpawned
+	g.enemiesSpawned = data.EnemiesSpawned
+	g.gameTimer = data.GameTimer
+	waves := data.Waves
+	for i, group := range waves.Pending {
+		waves.Pending[i] = knownSpawns(group)
+	}
+	g.waves = &waves
+
+	// Restore the player
+	g.player = NewPlayer(data.Player.X, data.Player.Y, dim.Width, dim.Height)
+	g.player.FacingLeft = data.Player.FacingLeft
+	g.player.MaxHealth = data.Player.MaxHealth
+	g.player.CurrentHealth = data.Player.CurrentHealth
+	g.player.Level = data.Player.Level
+	g.player.Experience = data.Player.Experience
+	g.player.NextLevelExp = data.Player.NextLevelExp
+	g.player.Mana = data.Player.Mana
+	g.player.MaxMana = data.Player.MaxMana
+
+	// Spells missing from the spell file are forgotten
+	for _, id := range data.Player.Spells {
+		g.player.Spells.Learn(id)
+	}
+	g.player.Spells.Slots = [spellSlotCount]string{}
+	for slot, id := range data.Player.SpellSlots {
+		g.player.Spells.Bind(slot, id)
+	}
+
+	// Modifiers that no longer fit their spell fall out of their sockets
+	g.player.Spells.Modifiers = make(map[string][]string)
+	for id, socketed := range data.Player.SpellModifiers {
+		for _, modifier := range socketed {
+			g.player.Spells.Socket(id, modifier, g.dimension)
+		}
+	}
+	if len(weapons) > 0 {
+		for _, weapon := range g.player.Weapons {
+			weapon.Unload()
+		}
+		g.player.Weapons = weapons
+		g.player.SwitchWeapon(0)
+		g.player.SwitchWeapon(data.Player.CurrentWeapon)
+	}
+
+	// Restore the inventory in a stable order
+	g.inventory = NewInventory(g.player, g.rng)
+	g.inventory.BagsOpened = data.BagsOpened
+	var names []string
+	for name := range data.Inventory {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		g.inventory.Add(name, data.Inventory[name])
+	}
+	g.inventory.LoadDefaultIcons()
+
+	for _, saved := range data.Rooms {
+		room := NewRoom(saved.X, saved.Y, saved.Columns, saved.Rows, saved.DoorSide, saved.Locked)
+		room.Door.Open = saved.Open
+		room.Door.Reopen = saved.Reopen
+		room.Build(g.world)
+	}
+	for _, saved := range data.Trees {
+		g.world.Add(NewTreeAt(saved.X, saved.Y, saved.Health))
+	}
+	for _, saved := range data.Stones {
+		g.world.Add(NewStoneAt(saved.X, saved.Y, saved.Health, saved.IsGolden))
+	}
+	for i, saved := range data.Portals {
+		portal := NewPortalAt(saved.X, saved.Y, knownSpawns(saved.Spawns), g.rng.Derive("restored-portal", i))
+		portal.SpawnRate = g.waves.SpawnRate()
+		portal.SpawnTimer = saved.SpawnTimer
+		portal.SpawnCount = saved.SpawnCount
+		g.world.Add(portal)
+	}
+	if data.Exit != nil {
+		g.world.Add(NewExitPortal(data.Exit.X, data.Exit.Y))
+	}
+	for _, saved := range data.DroppedItems {
+		g.world.Add(NewDroppedItem(saved.X, saved.Y, saved.Item))
+	}
+
+	// Deployables missing from the deployable file are lost
+	for _, saved := range data.Deployables {
+		def, exists := deployables.Lookup(saved.ID)
+		if !exists {
+			continue
+		}
+		deployable := NewDeployable(def, saved.X, saved.Y)
+		deployable.ArmTimer = saved.ArmTimer
+		if saved.ArmTimer <= 0 {
+			deployable.State = DeployArmed
+		}
+		if saved.Sprung {
+			deployable.State = DeploySprung
+			deployable.Anim.Restart("shut")
+		}
+		g.world.Add(deployable)
+	}
+
+	g.merchant = NewMerchant(data.Merchant.X, data.Merchant.Y)
+	if data.Merchant.ShopItems != nil {
+		g.merchant.ShopItems = data.Merchant.ShopItems
+	}
+	g.merchant.LoadIcons()
+	g.world.Add(g.merchant)
+
+	g.world.Add(NewDummy(float32(dim.Width)/2, float32(dim.Height)/2+100))
+
+	// Bosses are not stored, an interrupted fight starts over around the player
+	if g.progress.BossFight {
+		g.startBossFight()
+	}
+}
+
+// HasSave reports whether a save file exists
+func HasSave(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "savegame.json")
+	g := NewHeadlessGame(NewScriptedInput(), 42)
+	defer g.Cleanup()
+
+	g.inventory.ItemCounts["gold_coin"] = 2
+	g.player.SwitchWeapon(2)
+	g.player.Weapons[0].(*RayGun).HeatLevel = 42
+	g.world.Add(NewPortalAt(100, 100, []Spawn{{Kind: "grunt"}, {Kind: "grunt"}}, g.rng.Derive("portal", 1)))
+	g.gameTimer = 77
+	trees := Query[*Tree](g.world)
+	if err := g.SaveGame(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := NewHeadlessGame(NewScriptedInput(), 1)
+	defer loaded.Cleanup()
+	if err := loaded.LoadGame(path); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.gameTimer != 77 {
+		t.Errorf("game timer = %v, want 77", loaded.gameTimer)
+	}
+	if loaded.inventory.ItemCounts["gold_coin"] != 2 {
+		t.Errorf("gold coins = %d, want 2", loaded.inventory.ItemCounts["gold_coin"])
+	}
+	if loaded.player.CurrentWeapon != loaded.player.Weapons[2] {
+		t.Error("current weapon not restored")
+	}
+	if heat := loaded.player.Weapons[0].(*RayGun).HeatLevel; heat != 42 {
+		t.Errorf("ray gun heat = %v, want 42", heat)
+	}
+	if portals := Query[*Portal](loaded.world); len(portals) != 1 || len(portals[0].Spawns) != 2 {
+		t.Errorf("portals not restored: %d", len(portals))
+	}
+	loadedTrees := Query[*Tree](loaded.world)
+	if len(loadedTrees) != len(trees) {
+		t.Fatalf("got %d trees, want %d", len(loadedTrees), len(trees))
+	}
+	for i := range trees {
+		if loadedTrees[i].X != trees[i].X || loadedTrees[i].Y != trees[i].Y {
+			t.Fatalf("tree %d moved from %v,%v to %v,%v", i, trees[i].X, trees[i].Y, loadedTrees[i].X, loadedTrees[i].Y)
+		}
+	}
+}
+
+func TestMigrateFirstVersionSave(t *testing.T) {
+	fields := map[string]interface{}{
+		"version":            1.0,
+		"portal_spawn_timer": 12.0,
+		"inventory":          map[string]interface{}{"Gold Coin": 3.0, "Strange Log": 1.0},
+		"player":             map[string]interface{}{"next_level_exp": 225.0},
+		"portals":            []interface{}{map[string]interface{}{"spawn_count": 7.0}},
+	}
+	if err := migrateSave(fields); err != nil {
+		t.Fatal(err)
+	}
+
+	if fields["version"] != SaveVersion {
+		t.Errorf("version = %v, want %d", fields["version"], SaveVersion)
+	}
+	if seed, exists := fields["seed"]; !exists || seed != 0 {
+		t.Errorf("seed = %v, want 0", seed)
+	}
+	inventory := fields["inventory"].(map[string]interface{})
+	if inventory["gold_coin"] != 3.0 || inventory["strange_log"] != 1.0 {
+		t.Errorf("items not renamed: %v", inventory)
+	}
+	if level := fields["player"].(map[string]interface{})["level"]; level != 3 {
+		t.Errorf("level = %v, want 3", level)
+	}
+	if fields["dimension"] != "tree" {
+		t.Errorf("dimension = %v, want tree", fields["dimension"])
+	}
+	spawns := fields["portals"].([]interface{})[0].(map[string]interface{})["spawns"].([]interface{})
+	if len(spawns) != 3 {
+		t.Errorf("portal has %d spawns left, want 3", len(spawns))
+	}
+	if _, exists := fields["portal_spawn_timer"]; exists {
+		t.Error("portal timer kept")
+	}
+}
+
+func TestEveryOlderSaveVersionMigrates(t *testing.T) {
+	for version := 1; version < SaveVersion; version++ {
+		if _, exists := saveMigrations[version]; !exists {
+			t.Errorf("no migration from version %d", version)
+		}
+	}
+}
+
+func TestReadSaveRejectsNewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "savegame.json")
+	raw, _ := json.Marshal(map[string]interface{}{"version": SaveVersion + 1})
+	if err := os.WriteFile(path, raw, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadSave(path); err == nil {
+		t.Fatal("save from a newer game read without an error")
+	}
+}
+
+func TestLoadBadSaveKeepsRun(t *testing.T) {
+	saves := map[string]map[string]interface{}{
+		"unknown weapon": {
+			"version":   SaveVersion,
+			"dimension": "tree",
+			"player":    map[string]interface{}{"weapons": []interface{}{map[string]interface{}{"kind": "trebuchet"}}},
+		},
+		"unknown dimension": {"version": SaveVersion, "dimension": "nowhere"},
+	}
+	for name, save := range saves {
+		path := filepath.Join(t.TempDir(), "savegame.json")
+		raw, _ := json.Marshal(save)
+		if err := os.WriteFile(path, raw, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		g := NewHeadlessGame(NewScriptedInput(), 42)
+		player := g.player
+		err := g.LoadGame(path)
+		if err == nil || !strings.Contains(err.Error(), name) {
+			t.Errorf("%s: loaded with error %v", name, err)
+		}
+		if g.player != player {
+			t.Errorf("%s: failed load replaced the run", name)
+		}
+		g.Cleanup()
+	}
+}
+
+func TestLoadDropsUnknownEnemyKinds(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "savegame.json")
+	g := NewHeadlessGame(NewScriptedInput(), 42)
+	defer g.Cleanup()
+	g.world.Add(NewPortalAt(100, 100, []Spawn{{Kind: "dragon"}, {Kind: "grunt"}}, g.rng.Derive("portal", 1)))
+	g.waves.Pending = [][]Spawn{{{Kind: "dragon"}}}
+	if err := g.SaveGame(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := NewHeadlessGame(NewScriptedInput(), 1)
+	defer loaded.Cleanup()
+	if err := loaded.LoadGame(path); err != nil {
+		t.Fatal(err)
+	}
+	portals := Query[*Portal](loaded.world)
+	if len(portals) != 1 || len(portals[0].Spawns) != 1 || portals[0].Spawns[0].Kind != "grunt" {
+		t.Fatalf("restored portals = %+v", portals)
+	}
+	if len(loaded.waves.Pending) != 1 || len(loaded.waves.Pending[0]) != 0 {
+		t.Fatalf("restored pending spawns = %+v", loaded.waves.Pending)
+	}
+
+	// The portal spawns what is left without tripping over the unknown kind
+	portals[0].SpawnTimer = 0
+	for i := 0; i < 10; i++ {
+		loaded.Step(1.0 / 60)
+	}
+}
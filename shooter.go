@@ -0,0 +1,120 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Shooter tuning
+const (
+	shooterDistance     = 90.0  // Distance shooters try to keep from the player
+	shooterSlack        = 15.0  // How far off that distance they may drift before moving
+	shooterRange        = 150.0 // Shooters only aim at players this close
+	shooterAimTime      = 0.6   // How long the aim line shows before the shot
+	shooterFireCooldown = 2.0
+	shooterBulletSpeed  = 140.0
+	shooterBulletLife   = 1.5
+	shooterDamage       = 8
+)
+
+// RangedAttack is the state of an enemy that keeps its distance and shoots
+type RangedAttack struct {
+	CooldownTimer float32   // Time until the next shot can be aimed
+	AimTimer      float32   // Time left until an aimed shot fires, 0 when not aiming
+	AimDirection  r.Vector2 // Locked when aiming starts, so players can step aside
+	Strafe        float32   // 1 or -1, the way the shooter circles the player
+	Bullets       Bullets
+}
+
+// NewShooter creates an enemy that keeps its distance and fires at the player
+func NewShooter(x, y float32, target *Player, rng *rand.Rand) *Enemy {
+	enemy := NewEnemy(x, y, target, rng)
+	enemy.Kind = "shooter"
+	enemy.Speed = 40.0
+	enemy.MaxHealth = 2
+	enemy.CurrentHealth = 2
+	enemy.DropChance = 0.5
+	enemy.Tint = r.Orange
+	enemy.Steering = shooterSteering
+
+	strafe := float32(1)
+	if rng.Intn(2) == 0 {
+		strafe = -1
+	}
+	enemy.Ranged = &RangedAttack{
+		CooldownTimer: shooterFireCooldown / 2,
+		Strafe:        strafe,
+	}
+	return enemy
+}
+
+// updateRanged moves a shooter to its preferred distance and fires when ready
+func (e *Enemy) updateRanged(deltaTime float32) {
+	ranged := e.Ranged
+	ranged.Bullets = ranged.Bullets.Advance(deltaTime)
+	if ranged.CooldownTimer > 0 {
+		ranged.CooldownTimer -= deltaTime
+	}
+
+	center := r.Vector2{X: e.X + float32(e.Width)/2, Y: e.Y + float32(e.Height)/2}
+	target := r.Vector2{X: e.Player.X + float32(e.Player.Width)/2, Y: e.Player.Y + float32(e.Player.Height)/2}
+	toPlayer := r.Vector2Subtract(target, center)
+	distance := r.Vector2Length(toPlayer)
+	direction := r.Vector2Normalize(toPlayer)
+	if direction.X != 0 {
+		e.FacingLeft = direction.X < 0
+	}
+
+	// Stand still while aiming, then fire along the locked direction
+	if ranged.AimTimer > 0 {
+		ranged.AimTimer -= deltaTime
+		if ranged.AimTimer <= 0 {
+			ranged.Bullets = append(ranged.Bullets, Bullet{
+				Position:  center,
+				Direction: ranged.AimDirection,
+				Speed:     shooterBulletSpeed,
+				LifeTimer: shooterBulletLife,
+			})
+			ranged.CooldownTimer = shooterFireCooldown
+		}
+		return
+	}
+
+	if ranged.CooldownTimer <= 0 && distance < shooterRange {
+		ranged.AimTimer = shooterAimTime
+		ranged.AimDirection = direction
+		return
+	}
+
+	// Back off when too close, close in when too far and circle the player otherwise
+	var move r.Vector2
+	switch {
+	case distance < shooterDistance-shooterSlack:
+		move = r.Vector2Negate(direction)
+	case distance > shooterDistance+shooterSlack:
+		move = direction
+	default:
+		move = r.Vector2{X: -direction.Y * ranged.Strafe, Y: direction.X * ranged.Strafe}
+	}
+	move = e.blend(move)
+	e.Direction = Vector2{X: move.X, Y: move.Y}
+	speed := e.slowed(e.Speed)
+	e.X += move.X * speed * deltaTime
+	e.Y += move.Y * speed * deltaTime
+}
+
+// drawRanged draws the aim line while a shot is coming and the bullets in flight
+func (e *Enemy) drawRanged() {
+	ranged := e.Ranged
+	if ranged.AimTimer > 0 {
+		center := r.Vector2{X: e.X + float32(e.Width)/2, Y: e.Y + float32(e.Height)/2}
+		end := r.Vector2Add(center, r.Vector2Scale(ranged.AimDirection, shooterRange))
+
+		// Fade in as the shot gets closer
+		alpha := float32(1 - math.Max(0, float64(ranged.AimTimer/shooterAimTime)))
+		r.DrawLineV(center, end, r.ColorAlpha(r.Red, 0.2+0.6*alpha))
+	}
+	ranged.Bullets.Draw(r.Orange)
+}
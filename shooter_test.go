@@ -0,0 +1,93 @@
+package main
+
+import (
+	"testing"
+
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+// clearObstacles removes every tree and stone so enemies have open ground
+func clearObstacles(g *Game) {
+	for _, tree := range Query[*Tree](g.world) {
+		g.world.Remove(tree)
+	}
+	for _, stone := range Query[*Stone](g.world) {
+		g.world.Remove(stone)
+	}
+}
+
+func TestShooterKeepsDistanceAndHits(t *testing.T) {
+	g := NewHeadlessGame(NewScriptedInput(), 13)
+	defer g.Cleanup()
+	clearObstacles(g)
+
+	g.player.X, g.player.Y = 300, 300
+	shooter := NewShooter(330, 300, g.player, g.rng.Derive("test", 0))
+	g.world.Add(shooter)
+	health := g.player.CurrentHealth
+	for i := 0; i < 300; i++ {
+		g.player.X, g.player.Y = 300, 300
+		g.Step(1.0 / 60)
+	}
+
+	distance := r.Vector2Distance(r.Vector2{X: shooter.X, Y: shooter.Y}, r.Vector2{X: 300, Y: 300})
+	if distance < 60 {
+		t.Fatalf("shooter stayed %v away, want it to back off", distance)
+	}
+	if g.player.CurrentHealth >= health {
+		t.Fatal("shooter never hit the player")
+	}
+}
+
+func TestShooterAimsBeforeFiring(t *testing.T) {
+	g := NewHeadlessGame(NewScriptedInput(), 13)
+	defer g.Cleanup()
+
+	shooter := NewShooter(g.player.X+shooterDistance, g.player.Y, g.player, g.rng.Derive("test", 0))
+	defer shooter.Unload()
+	shooter.Ranged.CooldownTimer = 0
+	shooter.updateRanged(1.0 / 60)
+	if shooter.Ranged.AimTimer <= 0 || len(shooter.Ranged.Bullets) != 0 {
+		t.Fatal("shooter fired without aiming first")
+	}
+	for i := 0; i < int(shooterAimTime*60)+1; i++ {
+		shooter.updateRanged(1.0 / 60)
+	}
+	if len(shooter.Ranged.Bullets) != 1 || shooter.Ranged.Bullets[0].Direction.X >= 0 {
+		t.Fatalf("shooter fired %d bullets", len(shooter.Ranged.Bullets))
+	}
+}
+
+func TestBulletsStopAtStones(t *testing.T) {
+	g := NewHeadlessGame(NewScriptedInput(), 13)
+	defer g.Cleanup()
+	clearObstacles(g)
+	g.world.Add(NewStoneAt(315, 290, 5, false))
+
+	bullets := Bullets{{Position: r.Vector2{X: 340, Y: 305}, Direction: r.Vector2{X: -1}, Speed: 140, LifeTimer: 1}}
+	for i := 0; i < 30; i++ {
+		bullets = bullets.Advance(1.0 / 60).Blocked(g.world)
+	}
+	if len(bullets) != 0 {
+		t.Fatal("enemy bullet flew through a stone")
+	}
+
+	pistol := NewPistol()
+	defer pistol.Unload()
+	pistol.Bullets = Bullets{{Position: r.Vector2{X: 320, Y: 305}, Direction: r.Vector2{X: 1}, Speed: 1, LifeTimer: 1}}
+	pistol.Hits(g.world, g.player)
+	if len(pistol.Bullets) != 0 {
+		t.Fatal("pistol bullet flew through a stone")
+	}
+}
+
+func TestBulletsExpire(t *testing.T) {
+	bullets := Bullets{{Direction: r.Vector2{X: 1}, Speed: 100, LifeTimer: 0.5}}
+	bullets = bullets.Advance(0.25)
+	if len(bullets) != 1 || bullets[0].Position.X != 25 {
+		t.Fatalf("bullets after a quarter second = %v", bullets)
+	}
+	if bullets = bullets.Advance(0.25); len(bullets) != 0 {
+		t.Fatal("bullet outlived its life")
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"math"
+
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+// SpatialCellSize is the side of one spatial hash cell in world units,
+// a little larger than the biggest common object so most sit in one to four cells
+const SpatialCellSize = 64
+
+// cell is the coordinate of one spatial hash bucket
+type cell struct {
+	X, Y int32
+}
+
+// cellRange is the block of cells an entity's bounds cover
+type cellRange struct {
+	MinX, MinY, MaxX, MaxY int32
+}
+
+// SpatialHash is a uniform grid broadphase. Entities are filed in every cell
+// their bounds touch, so a query only tests the entities near it.
+type SpatialHash struct {
+	CellSize float32
+	cells    map[cell][]Entity
+	placed   map[Entity]cellRange
+}
+
+// NewSpatialHash creates an empty grid with the given cell size
+func NewSpatialHash(cellSize float32) *SpatialHash {
+	return &SpatialHash{
+		CellSize: cellSize,
+		cells:    make(map[cell][]Entity),
+		placed:   make(map[Entity]cellRange),
+	}
+}
+
+// Insert files an entity under the cells its bounds cover
+func (h *SpatialHash) Insert(e Entity) {
+	if _, exists := h.placed[e]; exists {
+		return
+	}
+	cells := h.rangeOf(e.GetBounds())
+	h.placed[e] = cells
+	for y := cells.MinY; y <= cells.MaxY; y++ {
+		for x := cells.MinX; x <= cells.MaxX; x++ {
+			key := cell{x, y}
+			h.cells[key] = append(h.cells[key], e)
+		}
+	}
+}
+
+// Remove takes an entity out of the grid
+func (h *SpatialHash) Remove(e Entity) {
+	cells, exists := h.placed[e]
+	if !exists {
+		return
+	}
+	delete(h.placed, e)
+	for y := cells.MinY; y <= cells.MaxY; y++ {
+		for x := cells.MinX; x <= cells.MaxX; x++ {
+			h.removeFromCell(cell{x, y}, e)
+		}
+	}
+}
+
+// Move refiles an entity whose bounds changed, doing nothing if it stayed in the same cells
+func (h *SpatialHash) Move(e Entity) {
+	cells, exists := h.placed[e]
+	if !exists {
+		return
+	}
+	if h.rangeOf(e.GetBounds()) == cells {
+		return
+	}
+	h.Remove(e)
+	h.Insert(e)
+}
+
+// Clear empties the grid
+func (h *SpatialHash) Clear() {
+	h.cells = make(map[cell][]Entity)
+	h.placed = make(map[Entity]cellRange)
+}
+
+// QueryRect returns every entity whose bounds overlap rect
+func (h *SpatialHash) QueryRect(rect r.Rectangle) []Entity {
+	return h.collect(h.rangeOf(rect), func(e Entity) bool {
+		return r.CheckCollisionRecs(rect, e.GetBounds())
+	})
+}
+
+// QueryPoint returns every entity whose bounds contain point
+func (h *SpatialHash) QueryPoint(point r.Vector2) []Entity {
+	x, y := h.cellOf(point.X), h.cellOf(point.Y)
+	return h.collect(cellRange{x, y, x, y}, func(e Entity) bool {
+		return r.CheckCollisionPointRec(point, e.GetBounds())
+	})
+}
+
+// QueryRadius returns every entity whose bounds touch the circle
+func (h *SpatialHash) QueryRadius(center r.Vector2, radius float32) []Entity {
+	area := r.Rectangle{X: center.X - radius, Y: center.Y - radius, Width: radius * 2, Height: radius * 2}
+	return h.collect(h.rangeOf(area), func(e Entity) bool {
+		return r.CheckCollisionCircleRec(center, radius, e.GetBounds())
+	})
+}
+
+// QueryRay returns every entity whose bounds the segment from start to end crosses,
+// walking only the cells along the segment
+func (h *SpatialHash) QueryRay(start, end r.Vector2) []Entity {
+	var found []Entity
+	seen := make(map[Entity]bool)
+	h.walkRay(start, end, func(key cell) {
+		for _, e := range h.cells[key] {
+			if !seen[e] && segmentHitsRect(start, end, e.GetBounds()) {
+				seen[e] = true
+				found = append(found, e)
+			}
+		}
+	})
+	return found
+}
+
+// collect gathers the entities of a block of cells that pass the exact test, each once
+func (h *SpatialHash) collect(cells cellRange, test func(Entity) bool) []Entity {
+	var found []Entity
+	seen := make(map[Entity]bool)
+	for y := cells.MinY; y <= cells.MaxY; y++ {
+		for x := cells.MinX; x <= cells.MaxX; x++ {
+			for _, e := range h.cells[cell{x, y}] {
+				if !seen[e] && test(e) {
+					seen[e] = true
+					found = append(found, e)
+				}
+			}
+		}
+	}
+	return found
+}
+
+// walkRay visits the cells crossed by a segment in order, stepping one cell border at a time
+func (h *SpatialHash) walkRay(start, end r.Vector2, visit func(cell)) {
+	x, y := h.cellOf(start.X), h.cellOf(start.Y)
+	endX, endY := h.cellOf(end.X), h.cellOf(end.Y)
+	dx, dy := end.X-start.X, end.Y-start.Y
+
+	stepX, stepY := int32(1), int32(1)
+	if dx < 0 {
+		stepX = -1
+	}
+	if dy < 0 {
+		stepY = -1
+	}
+
+	// How far along the segment, from 0 to 1, the next vertical and horizontal borders are
+	nextX, deltaX := h.borderDistance(start.X, dx, x, stepX)
+	nextY, deltaY := h.borderDistance(start.Y, dy, y, stepY)
+
+	// Every step crosses one border, so the walk takes exactly this many steps
+	steps := (endX-x)*stepX + (endY-y)*stepY
+	visit(cell{x, y})
+	for ; steps > 0; steps-- {
+		if (nextX < nextY && x != endX) || y == endY {
+			x += stepX
+			nextX += deltaX
+		} else {
+			y += stepY
+			nextY += deltaY
+		}
+		visit(cell{x, y})
+	}
+}
+
+// borderDistance returns the segment fraction to the first cell border on one axis
+// and the fraction between two borders
+func (h *SpatialHash) borderDistance(from, delta float32, index, step int32) (float32, float32) {
+	if delta == 0 {
+		return float32(math.Inf(1)), float32(math.Inf(1))
+	}
+	border := float32(index) * h.CellSize
+	if step > 0 {
+		border += h.CellSize
+	}
+	abs := float32(math.Abs(float64(delta)))
+	return (border - from) / delta, h.CellSize / abs
+}
+
+// rangeOf returns the cells a rectangle covers
+func (h *SpatialHash) rangeOf(rect r.Rectangle) cellRange {
+	return cellRange{
+		MinX: h.cellOf(rect.X),
+		MinY: h.cellOf(rect.Y),
+		MaxX: h.cellOf(rect.X + rect.Width),
+		MaxY: h.cellOf(rect.Y + rect.Height),
+	}
+}
+
+// cellOf returns the cell index of one coordinate
+func (h *SpatialHash) cellOf(v float32) int32 {
+	return int32(math.Floor(float64(v / h.CellSize)))
+}
+
+// removeFromCell drops an entity from one bucket, keeping the others in order
+func (h *SpatialHash) removeFromCell(key cell, e Entity) {
+	bucket := h.cells[key]
+	for i, other := range bucket {
+		if other == e {
+			bucket = append(bucket[:i], bucket[i+1:]...)
+			break
+		}
+	}
+	if len(bucket) == 0 {
+		delete(h.cells, key)
+	} else {
+		h.cells[key] = bucket
+	}
+}
+
+// segmentHitsRect reports whether the segment from start to end touches rect
+func segmentHitsRect(start, end r.Vector2, rect r.Rectangle) bool {
+	// Clip the segment against both slabs of the rectangle
+	tMin, tMax := float32(0), float32(1)
+	axes := [2][4]float32{
+		{start.X, end.X - start.X, rect.X, rect.X + rect.Width},
+		{start.Y, end.Y - start.Y, rect.Y, rect.Y + rect.Height},
+	}
+	for _, axis := range axes {
+		from, delta, low, high := axis[0], axis[1], axis[2], axis[3]
+		if delta == 0 {
+			if from < low || from > high {
+				return false
+			}
+			continue
+		}
+		t1, t2 := (low-from)/delta, (high-from)/delta
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+		if t1 > tMin {
+			tMin = t1
+		}
+		if t2 < tMax {
+			tMax = t2
+		}
+		if tMin > tMax {
+			return false
+		}
+	}
+	return true
+}
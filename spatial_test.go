@@ -0,0 +1,88 @@
+package main
+
+import (
+	"testing"
+
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+func TestSpatialHashMatchesBruteForce(t *testing.T) {
+	g := NewHeadlessGame(NewScriptedInput(), 3)
+	defer g.Cleanup()
+
+	rng := g.rng.Derive("test", 0)
+	for i := 0; i < 2000; i++ {
+		start := r.Vector2{X: rng.Float32()*1000 - 50, Y: rng.Float32()*900 - 50}
+		end := r.Vector2{X: start.X + rng.Float32()*300 - 150, Y: start.Y + rng.Float32()*300 - 150}
+		rect := r.Rectangle{X: start.X, Y: start.Y, Width: rng.Float32() * 100, Height: rng.Float32() * 100}
+
+		var wantRay, wantRect, wantRadius int
+		for _, e := range g.world.entities {
+			if segmentHitsRect(start, end, e.GetBounds()) {
+				wantRay++
+			}
+			if r.CheckCollisionRecs(rect, e.GetBounds()) {
+				wantRect++
+			}
+			if r.CheckCollisionCircleRec(start, 40, e.GetBounds()) {
+				wantRadius++
+			}
+		}
+		if got := len(QueryRay[Entity](g.world, start, end)); got != wantRay {
+			t.Fatalf("ray %v to %v found %d, want %d", start, end, got, wantRay)
+		}
+		if got := len(QueryRect[Entity](g.world, rect)); got != wantRect {
+			t.Fatalf("rect %v found %d, want %d", rect, got, wantRect)
+		}
+		if got := len(QueryRadius[Entity](g.world, start, 40)); got != wantRadius {
+			t.Fatalf("circle at %v found %d, want %d", start, got, wantRadius)
+		}
+	}
+}
+
+func TestSpatialHashReturnsEachEntityOnce(t *testing.T) {
+	h := NewSpatialHash(SpatialCellSize)
+	wide := &box{bounds: r.Rectangle{X: 10, Y: 10, Width: SpatialCellSize * 3, Height: SpatialCellSize * 2}}
+	h.Insert(wide)
+
+	if got := h.QueryRect(r.Rectangle{X: 0, Y: 0, Width: 500, Height: 500}); len(got) != 1 {
+		t.Fatalf("entity over several cells found %d times", len(got))
+	}
+	h.Remove(wide)
+	if got := h.QueryRect(r.Rectangle{X: 0, Y: 0, Width: 500, Height: 500}); len(got) != 0 {
+		t.Fatalf("removed entity still found %d times", len(got))
+	}
+}
+
+func TestSpatialHashTracksMovingEntities(t *testing.T) {
+	g := NewHeadlessGame(NewScriptedInput(), 3)
+	defer g.Cleanup()
+
+	// Let enemies spawn and chase the player around
+	for i := 0; i < 3000; i++ {
+		g.Step(1.0 / 60)
+	}
+	for _, e := range g.world.entities {
+		if g.world.grid.placed[e] != g.world.grid.rangeOf(e.GetBounds()) {
+			t.Fatalf("%T at %v is filed in stale cells", e, e.GetBounds())
+		}
+	}
+}
+
+func TestSegmentHitsRect(t *testing.T) {
+	rect := r.Rectangle{X: 10, Y: 10, Width: 10, Height: 10}
+	cases := []struct {
+		start, end r.Vector2
+		want       bool
+	}{
+		{r.Vector2{X: 0, Y: 15}, r.Vector2{X: 30, Y: 15}, true},
+		{r.Vector2{X: 12, Y: 12}, r.Vector2{X: 14, Y: 14}, true},
+		{r.Vector2{X: 0, Y: 0}, r.Vector2{X: 30, Y: 0}, false},
+		{r.Vector2{X: 0, Y: 15}, r.Vector2{X: 5, Y: 15}, false},
+	}
+	for _, c := range cases {
+		if got := segmentHitsRect(c.start, c.end, rect); got != c.want {
+			t.Errorf("segment %v to %v: got %v, want %v", c.start, c.end, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,596 @@
+package main
+
+import (
+	"math"
+
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+// spellSlotCount is how many spells can be bound to cast keys at once
+const spellSlotCount = 4
+
+// Spell tuning
+const (
+	boltSnapRadius   = 24.0 // Bolts strike targets this close to where the player aims
+	spellKnockback   = 6.0  // How hard bursts push what they hit
+	spellHitCooldown = 0.1
+	spellVFXScale    = 0.5 // The VFX sheets are drawn for a bigger scale than the world
+	fireballSize     = 3.0
+	novaShards       = 8 // Ice shards flying out of a nova
+	gravityRingSpeed = 1.5
+)
+
+// Cast is a spell on its way out, it goes off once its timer runs out
+type Cast struct {
+	Spell *SpellDef
+	Plan  castPlan // The spell as its modifiers change it
+	Timer float32
+}
+
+// spellEffect is a spell that went off, from its release until its VFX finished playing
+type spellEffect struct {
+	Spell     *SpellDef
+	Origin    r.Vector2   // Where the player stood when it went off
+	Aim       r.Vector2   // Where the player aimed, no further away than the spell's range
+	Position  r.Vector2   // Where a fireball is
+	Direction r.Vector2   // Where a fireball flies
+	Travel    float32     // Distance a fireball has left to fly
+	Landed    bool        // Hit its targets, only the VFX is left to play
+	Spots     []r.Vector2 // Where the VFX plays, chains play it on every target they hit
+	Time      float32     // Time since it landed
+
+	// What modifiers add to the spell
+	Delay        float32 // Time until an echo goes off
+	Homing       float32
+	Bounces      int
+	BounceRadius float32
+	Gravity      gravityWell
+	Hit          map[Damageable]bool // Targets it and the spells it bounced from struck
+}
+
+// Spellbook holds the spells the player learned, the cast key each is bound to
+// and the spells that are going off. Like a weapon, it reports what its spells
+// hit and the game applies the damage.
+type Spellbook struct {
+	Known     []string               // Spell IDs in the order they were learned
+	Slots     [spellSlotCount]string // Spell bound to each cast key, empty for none
+	Cooldowns map[string]float32     // Time until a spell can be cast again
+	Casting   *Cast                  // Spell being cast, nil when none is
+	Modifiers map[string][]string    // Modifiers socketed into each spell, in the order they apply
+	effects   []*spellEffect
+	sheets    map[string]r.Texture2D // VFX sheets of the known spells
+}
+
+// NewSpellbook creates a spellbook without any spells
+func NewSpellbook() *Spellbook {
+	return &Spellbook{
+		Cooldowns: make(map[string]float32),
+		Modifiers: make(map[string][]string),
+		sheets:    make(map[string]r.Texture2D),
+	}
+}
+
+// Knows reports whether a spell was learned
+func (book *Spellbook) Knows(id string) bool {
+	for _, known := range book.Known {
+		if known == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Learn teaches a spell and binds it to the first free slot. It reports
+// false for spells that are already known or that do not exist.
+func (book *Spellbook) Learn(id string) bool {
+	def, exists := spells.Lookup(id)
+	if !exists || book.Knows(id) {
+		return false
+	}
+
+	book.Known = append(book.Known, id)
+	if _, loaded := book.sheets[def.VFX.Sheet]; !loaded {
+		book.sheets[def.VFX.Sheet] = loadTexture(def.VFX.Sheet)
+	}
+	for slot := range book.Slots {
+		if book.Slots[slot] == "" {
+			book.Slots[slot] = id
+			break
+		}
+	}
+	return true
+}
+
+// Bind puts a known spell in a slot, taking it out of the slot it was in before
+func (book *Spellbook) Bind(slot int, id string) {
+	if slot < 0 || slot >= spellSlotCount || !book.Knows(id) {
+		return
+	}
+	for i := range book.Slots {
+		if book.Slots[i] == id {
+			book.Slots[i] = ""
+		}
+	}
+	book.Slots[slot] = id
+}
+
+// SlotOf returns the slot a spell is bound to, -1 when it is in none
+func (book *Spellbook) SlotOf(id string) int {
+	for slot, bound := range book.Slots {
+		if bound == id {
+			return slot
+		}
+	}
+	return -1
+}
+
+// Spell returns the spell bound to a slot, nil when the slot is empty
+func (book *Spellbook) Spell(slot int) *SpellDef {
+	if slot < 0 || slot >= spellSlotCount {
+		return nil
+	}
+	def, _ := spells.Lookup(book.Slots[slot])
+	return def
+}
+
+// Plan runs a spell through the modifiers socketed into it
+func (book *Spellbook) Plan(def *SpellDef) castPlan {
+	plan, _ := planCast(def, book.Modifiers[def.ID])
+	return plan
+}
+
+// CanSocket reports whether a modifier can go into the next socket of a known
+// spell: it has to be unlocked, fit the spell and keep the cost under the cap.
+func (book *Spellbook) CanSocket(id, modifier string, dimension int) bool {
+	spell, exists := spells.Lookup(id)
+	def, found := modifiers.Lookup(modifier)
+	if !exists || !found || !book.Knows(id) || !def.IsUnlocked(dimension) || !def.Fits(spell) {
+		return false
+	}
+	socketed := append(append([]string(nil), book.Modifiers[id]...), modifier)
+	if len(socketed) > modifierSockets {
+		return false
+	}
+	_, applied := planCast(spell, socketed)
+	return applied == len(socketed)
+}
+
+// Socket puts a modifier into the next socket of a spell, reporting false when it cannot go there
+func (book *Spellbook) Socket(id, modifier string, dimension int) bool {
+	if !book.CanSocket(id, modifier, dimension) {
+		return false
+	}
+	book.Modifiers[id] = append(book.Modifiers[id], modifier)
+	return true
+}
+
+// Unsocket takes the modifier in one socket out of a spell, the ones after it move up
+func (book *Spellbook) Unsocket(id string, socket int) {
+	socketed := book.Modifiers[id]
+	if socket < 0 || socket >= len(socketed) {
+		return
+	}
+	socketed = append(socketed[:socket:socket], socketed[socket+1:]...)
+	if len(socketed) == 0 {
+		delete(book.Modifiers, id)
+	} else {
+		book.Modifiers[id] = socketed
+	}
+}
+
+// Cast starts casting the spell in a slot if it is off cooldown and the player has the mana for it
+func (book *Spellbook) Cast(slot int, player *Player) {
+	def := book.Spell(slot)
+	if def == nil || book.Casting != nil || book.Cooldowns[def.ID] > 0 {
+		return
+	}
+	plan := book.Plan(def)
+	if player.Mana < plan.Spell.Mana {
+		return
+	}
+	book.Casting = &Cast{Spell: def, Plan: plan, Timer: plan.Spell.CastTime}
+}
+
+// Update counts down the cooldowns, lets a finished cast go off and plays the spells already out
+func (book *Spellbook) Update(deltaTime float32, player *Player, world *World) {
+	for id, cooldown := range book.Cooldowns {
+		if cooldown -= deltaTime; cooldown > 0 {
+			book.Cooldowns[id] = cooldown
+		} else {
+			delete(book.Cooldowns, id)
+		}
+	}
+
+	// Dashing breaks a cast, the mana is only spent once a spell goes off
+	if book.Casting != nil && player.IsDashing {
+		book.Casting = nil
+	}
+	if book.Casting != nil {
+		book.Casting.Timer -= deltaTime
+		if book.Casting.Timer <= 0 {
+			plan := &book.Casting.Plan
+			book.Casting = nil
+			if def := &plan.Spell; player.Mana >= def.Mana {
+				player.Mana -= def.Mana
+				if def.Cooldown > 0 {
+					book.Cooldowns[def.ID] = def.Cooldown
+				}
+				book.release(plan, player)
+			}
+		}
+	}
+
+	var remaining []*spellEffect
+	for _, effect := range book.effects {
+		if effect.update(deltaTime, book.frames(effect.Spell), world) {
+			remaining = append(remaining, effect)
+		}
+	}
+	book.effects = remaining
+}
+
+// release lets a cast go off, one effect for every split copy and echo of it
+func (book *Spellbook) release(plan *castPlan, player *Player) {
+	base := newSpellEffect(&plan.Spell, player)
+	for echo := 0; echo <= plan.Echoes; echo++ {
+		for copy := 0; copy < plan.Split; copy++ {
+			// Split copies fan out evenly around the aim
+			angle := float32(0)
+			if plan.Split > 1 {
+				angle = -plan.Spread/2 + plan.Spread*float32(copy)/float32(plan.Split-1)
+			}
+			direction := rotate(base.Direction, float64(angle)*math.Pi/180)
+			effect := *base
+			effect.Aim = r.Vector2Add(base.Origin, r.Vector2Scale(direction, base.Travel))
+			effect.Direction = direction
+			effect.Delay = float32(echo) * plan.EchoDelay
+			effect.Homing = plan.Homing
+			effect.Bounces = plan.Bounces
+			effect.BounceRadius = plan.BounceRadius
+			effect.Gravity = plan.Gravity
+			effect.Hit = make(map[Damageable]bool)
+			book.effects = append(book.effects, &effect)
+		}
+	}
+}
+
+// newSpellEffect releases a spell from the player towards where they aim
+func newSpellEffect(def *SpellDef, player *Player) *spellEffect {
+	center := player.center()
+	toAim := r.Vector2Subtract(player.AimTarget, center)
+	distance := r.Vector2Length(toAim)
+
+	direction := player.LastMoveDirection
+	if distance > 0 {
+		direction = r.Vector2Scale(toAim, 1/distance)
+	}
+	distance = min(distance, def.Range)
+
+	return &spellEffect{
+		Spell:     def,
+		Origin:    center,
+		Aim:       r.Vector2Add(center, r.Vector2Scale(direction, distance)),
+		Position:  center,
+		Direction: direction,
+		Travel:    distance,
+	}
+}
+
+// update flies a fireball on, steering it if it homes, and plays the VFX of a
+// landed spell while its gravity well pulls. It reports false once it is over.
+func (effect *spellEffect) update(deltaTime float32, frames int, world *World) bool {
+	if effect.Delay > 0 {
+		effect.Delay -= deltaTime
+		return true
+	}
+
+	if !effect.Landed {
+		if effect.Spell.Kind == SpellFireball {
+			if effect.Homing > 0 && world != nil {
+				effect.steer(world, deltaTime)
+			}
+			step := min(effect.Spell.Speed*deltaTime, effect.Travel)
+			effect.Position = r.Vector2Add(effect.Position, r.Vector2Scale(effect.Direction, step))
+			effect.Travel -= step
+		}
+		return true
+	}
+
+	effect.Time += deltaTime
+	if effect.Time < effect.Gravity.Time && world != nil {
+		effect.pull(world, deltaTime)
+	}
+	return effect.Time < max(float32(frames)*effect.Spell.VFX.FrameTime, effect.Gravity.Time)
+}
+
+// steer turns a homing fireball towards the closest target it has not struck yet
+func (effect *spellEffect) steer(world *World, deltaTime float32) {
+	target := closestTarget(spellTargets(world, effect.Position, homingRadius), effect.Position, effect.Hit)
+	if target == nil {
+		return
+	}
+	toTarget := r.Vector2Subtract(boundsCenter(target.GetBounds()), effect.Position)
+	turn := math.Atan2(float64(toTarget.Y), float64(toTarget.X)) - math.Atan2(float64(effect.Direction.Y), float64(effect.Direction.X))
+	turn = math.Remainder(turn, 2*math.Pi) // Take the short way round
+	limit := float64(effect.Homing * deltaTime)
+	effect.Direction = rotate(effect.Direction, math.Max(-limit, math.Min(limit, turn)))
+}
+
+// rotate turns a vector by an angle in radians
+func rotate(v r.Vector2, angle float64) r.Vector2 {
+	sin, cos := math.Sincos(angle)
+	return r.Vector2{
+		X: v.X*float32(cos) - v.Y*float32(sin),
+		Y: v.X*float32(sin) + v.Y*float32(cos),
+	}
+}
+
+// pull drags the enemies in a gravity well towards where the spell landed, sliding them along walls.
+// Enemies caught in a trap stay where they are.
+func (effect *spellEffect) pull(world *World, deltaTime float32) {
+	center := effect.Spots[len(effect.Spots)-1]
+	for _, enemy := range QueryRadius[*Enemy](world, center, effect.Gravity.Radius) {
+		if enemy.IsDead() || enemy.Rooted() {
+			continue
+		}
+		toCenter := r.Vector2Subtract(center, boundsCenter(enemy.GetBounds()))
+		distance := r.Vector2Length(toCenter)
+		if distance < 1 {
+			continue
+		}
+		step := r.Vector2Scale(toCenter, min(effect.Gravity.Strength*deltaTime, distance)/distance)
+		position := moveAndSlide(world, enemy.GetBounds(), step)
+		enemy.X, enemy.Y = position.X, position.Y
+		world.Moved(enemy)
+	}
+}
+
+// frames returns how many frames the VFX sheet of a spell has
+func (book *Spellbook) frames(def *SpellDef) int {
+	sheet := book.sheets[def.VFX.Sheet]
+	return max(1, int(sheet.Width/def.VFX.FrameWidth))
+}
+
+// Hits lands the spells that are due and reports everything they hurt
+func (book *Spellbook) Hits(world *World, player *Player) []DamageEvent {
+	var hits []DamageEvent
+	for _, effect := range book.effects {
+		if effect.Landed || effect.Delay > 0 {
+			continue
+		}
+
+		def := effect.Spell
+		switch def.Kind {
+		case SpellBolt:
+			effect.Landed = true
+			effect.Spots = []r.Vector2{effect.Aim}
+			if target := closestTarget(spellTargets(world, effect.Aim, boltSnapRadius), effect.Aim, effect.Hit); target != nil {
+				effect.Hit[target] = true
+				effect.Spots[0] = boundsCenter(target.GetBounds())
+				hits = append(hits, newHit(book, player, target, def.Damage, DamageSpell, 0, spellHitCooldown))
+			}
+
+		case SpellFireball:
+			if effect.Travel > 0 && !fireballStopped(world, effect.Position, effect.Hit) {
+				continue
+			}
+			effect.Landed = true
+			effect.Spots = []r.Vector2{effect.Position}
+			hits = append(hits, book.burst(world, player, effect, effect.Position)...)
+
+		case SpellChain:
+			effect.Landed = true
+			from := effect.Aim
+			for jump := 0; jump <= def.Jumps; jump++ {
+				target := closestTarget(spellTargets(world, from, def.Radius), from, effect.Hit)
+				if target == nil {
+					break
+				}
+				effect.Hit[target] = true
+				from = boundsCenter(target.GetBounds())
+				effect.Spots = append(effect.Spots, from)
+				hits = append(hits, newHit(book, player, target, def.Damage, DamageSpell, 0, spellHitCooldown))
+			}
+			if len(effect.Spots) == 0 {
+				effect.Spots = []r.Vector2{effect.Aim}
+			}
+
+		case SpellNova:
+			effect.Landed = true
+			effect.Spots = []r.Vector2{effect.Origin}
+			hits = append(hits, book.burst(world, player, effect, effect.Origin)...)
+		}
+
+		if effect.Landed && effect.Bounces > 0 {
+			book.bounce(world, effect)
+		}
+	}
+	return hits
+}
+
+// bounce launches the spell again from where it landed towards the closest
+// target it has not struck yet. Bounces end when no target is in reach.
+func (book *Spellbook) bounce(world *World, effect *spellEffect) {
+	from := effect.Spots[len(effect.Spots)-1]
+	target := closestTarget(spellTargets(world, from, effect.BounceRadius), from, effect.Hit)
+	if target == nil {
+		return
+	}
+
+	aim := boundsCenter(target.GetBounds())
+	toAim := r.Vector2Subtract(aim, from)
+	next := *effect
+	next.Origin = from
+	next.Aim = aim
+	next.Position = from
+	next.Direction = r.Vector2Normalize(toAim)
+	next.Travel = r.Vector2Length(toAim)
+	next.Landed = false
+	next.Spots = nil
+	next.Time = 0
+	next.Bounces--
+	book.effects = append(book.effects, &next)
+}
+
+// burst hurts everything around a point, pushing it away and chilling it if the spell chills
+func (book *Spellbook) burst(world *World, player *Player, effect *spellEffect, center r.Vector2) []DamageEvent {
+	def := effect.Spell
+	var hits []DamageEvent
+	for _, target := range spellTargets(world, center, def.Radius) {
+		effect.Hit[target] = true
+		event := newHit(book, player, target, def.Damage, DamageSpell, 0, spellHitCooldown)
+		away := r.Vector2Subtract(boundsCenter(target.GetBounds()), center)
+		if r.Vector2Length(away) > 0 {
+			event.Knockback = r.Vector2Scale(r.Vector2Normalize(away), spellKnockback)
+		}
+		event.Chill = def.Chill
+		hits = append(hits, event)
+	}
+	return hits
+}
+
+// fireballStopped reports whether a fireball flew into a target it has not struck yet or something that blocks bullets
+func fireballStopped(world *World, position r.Vector2, struck map[Damageable]bool) bool {
+	if closestTarget(spellTargets(world, position, fireballSize), position, struck) != nil {
+		return true
+	}
+	for _, blocker := range QueryRadius[BulletBlocker](world, position, fireballSize) {
+		if blocker.BlocksBullets() {
+			return true
+		}
+	}
+	return false
+}
+
+// spellTargets returns what spells can hit around a point. Trees and stones
+// only break from slashes and harvesting, so spells never aim at them.
+func spellTargets(world *World, center r.Vector2, radius float32) []Damageable {
+	var targets []Damageable
+	for _, target := range QueryRadius[Damageable](world, center, radius) {
+		if _, isObstacle := target.(Obstacle); !isObstacle && !target.IsDead() {
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// closestTarget returns the target nearest to a point, leaving out the ones in skip
+func closestTarget(targets []Damageable, point r.Vector2, skip map[Damageable]bool) Damageable {
+	var closest Damageable
+	closestDistance := float32(math.MaxFloat32)
+	for _, target := range targets {
+		if skip[target] {
+			continue
+		}
+		if distance := r.Vector2Distance(boundsCenter(target.GetBounds()), point); distance < closestDistance {
+			closest, closestDistance = target, distance
+		}
+	}
+	return closest
+}
+
+// boundsCenter returns the middle of a rectangle
+func boundsCenter(bounds r.Rectangle) r.Vector2 {
+	return r.Vector2{X: bounds.X + bounds.Width/2, Y: bounds.Y + bounds.Height/2}
+}
+
+// Draw shows the cast under way and the spells going off
+func (book *Spellbook) Draw(player *Player, debug bool) {
+	// Fill a bar under the player while a spell is being cast
+	if cast := book.Casting; cast != nil && cast.Plan.Spell.CastTime > 0 {
+		barWidth := float32(20)
+		barX := player.X + float32(player.Width)/2 - barWidth/2
+		barY := player.Y + float32(player.Height) + 2
+		progress := 1 - cast.Timer/cast.Plan.Spell.CastTime
+		r.DrawRectangleV(r.Vector2{X: barX, Y: barY}, r.Vector2{X: barWidth, Y: 2}, r.DarkGray)
+		r.DrawRectangleV(r.Vector2{X: barX, Y: barY}, r.Vector2{X: barWidth * progress, Y: 2}, r.SkyBlue)
+	}
+
+	for _, effect := range book.effects {
+		if effect.Delay <= 0 {
+			book.drawEffect(effect, debug)
+		}
+	}
+}
+
+// drawEffect draws one spell, its VFX sheet once it landed
+func (book *Spellbook) drawEffect(effect *spellEffect, debug bool) {
+	def := effect.Spell
+	if !effect.Landed {
+		if def.Kind == SpellFireball {
+			r.DrawCircleV(effect.Position, fireballSize, r.Orange)
+			r.DrawCircleV(effect.Position, fireballSize/2, r.Yellow)
+		}
+		return
+	}
+
+	// Rings close in on the middle of a gravity well while it pulls
+	if effect.Time < effect.Gravity.Time {
+		spot := effect.Spots[len(effect.Spots)-1]
+		for ring := 0; ring < 3; ring++ {
+			shrink := float32(math.Mod(float64(effect.Time*gravityRingSpeed)+float64(ring)/3, 1))
+			radius := effect.Gravity.Radius * (1 - shrink)
+			r.DrawCircleLines(int32(spot.X), int32(spot.Y), radius, r.ColorAlpha(r.Purple, 0.8*shrink))
+		}
+	}
+
+	vfx := def.VFX
+	frames := book.frames(def)
+	frame := min(int(effect.Time/vfx.FrameTime), frames-1)
+	if effect.Time >= float32(frames)*vfx.FrameTime {
+		return // Only the gravity well is left
+	}
+	sheet := book.sheets[vfx.Sheet]
+	source := r.Rectangle{
+		X:      float32(int32(frame) * vfx.FrameWidth),
+		Width:  float32(vfx.FrameWidth),
+		Height: float32(vfx.FrameHeight),
+	}
+	width := float32(vfx.FrameWidth) * spellVFXScale
+	height := float32(vfx.FrameHeight) * spellVFXScale
+
+	switch def.Kind {
+	case SpellBolt, SpellChain:
+		// Strikes come down from the sky onto their targets
+		for i, spot := range effect.Spots {
+			if i > 0 && frame < frames/2 {
+				r.DrawLineEx(effect.Spots[i-1], spot, 1, r.ColorAlpha(r.SkyBlue, 0.8))
+			}
+			dest := r.Rectangle{X: spot.X - width/2, Y: spot.Y - height*0.9, Width: width, Height: height}
+			r.DrawTexturePro(sheet, source, dest, r.Vector2{}, 0, r.White)
+		}
+
+	case SpellFireball:
+		// The burst covers the area it hurt
+		spot := effect.Spots[0]
+		size := def.Radius * 2
+		dest := r.Rectangle{X: spot.X - size/2, Y: spot.Y - size/2, Width: size, Height: size}
+		r.DrawTexturePro(sheet, source, dest, r.Vector2{}, 0, r.White)
+
+	case SpellNova:
+		// Shards fly out of the player to the edge of the nova
+		center := effect.Spots[0]
+		progress := min(1, effect.Time/(float32(frames)*vfx.FrameTime))
+		for i := 0; i < novaShards; i++ {
+			angle := float64(i) * 2 * math.Pi / novaShards
+			position := r.Vector2Add(center, r.Vector2{
+				X: float32(math.Cos(angle)) * def.Radius * progress,
+				Y: float32(math.Sin(angle)) * def.Radius * progress,
+			})
+			dest := r.Rectangle{X: position.X, Y: position.Y, Width: width, Height: height}
+			r.DrawTexturePro(sheet, source, dest, r.Vector2{X: width / 2, Y: height / 2}, float32(angle*180/math.Pi), r.White)
+		}
+	}
+
+	if debug && def.Radius > 0 && def.Kind != SpellChain {
+		spot := effect.Spots[0]
+		r.DrawCircleLines(int32(spot.X), int32(spot.Y), def.Radius, r.Red)
+	}
+}
+
+// Unload frees the VFX sheets
+func (book *Spellbook) Unload() {
+	for _, sheet := range book.sheets {
+		unloadTexture(sheet)
+	}
+}
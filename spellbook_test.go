@@ -0,0 +1,154 @@
+package main
+
+import (
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+// lineUpEnemies lines up enemies to the right of the player, all with plenty of health
+func lineUpEnemies(g *Game, count int) []*Enemy {
+	center := g.player.center()
+	var enemies []*Enemy
+	for i := 0; i < count; i++ {
+		enemy := NewEnemy(center.X+30+float32(i)*20, center.Y-16, g.player, rand.New(rand.NewSource(1)))
+		enemy.MaxHealth, enemy.CurrentHealth = 50, 50
+		g.world.Add(enemy)
+		enemies = append(enemies, enemy)
+	}
+	return enemies
+}
+
+// castAndCollect casts the spell in a slot at a point and returns every hit it lands over two seconds
+func castAndCollect(t *testing.T, g *Game, slot int, aim r.Vector2) []DamageEvent {
+	t.Helper()
+	book := g.player.Spells
+	g.player.AimTarget = aim
+	book.Cast(slot, g.player)
+	if book.Casting == nil {
+		t.Fatalf("slot %d did not cast with %v mana", slot, g.player.Mana)
+	}
+	var hits []DamageEvent
+	for i := 0; i < 120; i++ {
+		book.Update(1.0/60, g.player, g.world)
+		hits = append(hits, book.Hits(g.world, g.player)...)
+	}
+	return hits
+}
+
+func TestTomesTeachSpells(t *testing.T) {
+	g := NewHeadlessGame(NewScriptedInput(), 9)
+	defer g.Cleanup()
+	book := g.player.Spells
+
+	g.inventory.Add("tome_dark_bolt", 2)
+	g.inventory.UseItem("tome_dark_bolt")
+	if !book.Knows("dark_bolt") || book.Slots[0] != "dark_bolt" {
+		t.Fatalf("known %v, slots %v", book.Known, book.Slots)
+	}
+
+	// A tome of a known spell is not used up
+	g.inventory.UseItem("tome_dark_bolt")
+	if g.inventory.ItemCounts["tome_dark_bolt"] != 1 || len(book.Known) != 1 {
+		t.Fatalf("second tome: %d left, %d spells known", g.inventory.ItemCounts["tome_dark_bolt"], len(book.Known))
+	}
+}
+
+func TestSpellsHitEnemies(t *testing.T) {
+	g := NewHeadlessGame(NewScriptedInput(), 9)
+	defer g.Cleanup()
+	book := g.player.Spells
+	for _, id := range []string{"dark_bolt", "fireball", "chain_lightning", "frost_nova"} {
+		book.Learn(id)
+	}
+	enemies := lineUpEnemies(g, 4)
+
+	if hits := castAndCollect(t, g, 0, enemies[0].center()); len(hits) != 1 || hits[0].Target != enemies[0] {
+		t.Fatalf("dark bolt landed %d hits", len(hits))
+	}
+	if hits := castAndCollect(t, g, 2, enemies[0].center()); len(hits) != 4 {
+		t.Fatalf("chain lightning landed %d hits, want one on every enemy", len(hits))
+	}
+	g.player.Mana = g.player.MaxMana
+	if hits := castAndCollect(t, g, 1, enemies[1].center()); len(hits) < 2 {
+		t.Fatalf("fireball landed %d hits, want several", len(hits))
+	}
+	g.player.Mana = g.player.MaxMana
+	hits := castAndCollect(t, g, 3, g.player.center())
+	for _, hit := range hits {
+		g.dealDamage(hit)
+	}
+	if len(hits) == 0 || enemies[0].ChillTimer <= 0 {
+		t.Fatal("frost nova did not chill")
+	}
+	if len(book.effects) != 0 {
+		t.Fatalf("%d spell effects left over", len(book.effects))
+	}
+}
+
+func TestSpellsNeedMana(t *testing.T) {
+	g := NewHeadlessGame(NewScriptedInput(), 9)
+	defer g.Cleanup()
+	book := g.player.Spells
+	book.Learn("frost_nova")
+
+	g.player.Mana = 0
+	book.Cast(0, g.player)
+	if book.Casting != nil {
+		t.Fatal("cast without mana")
+	}
+	for i := 0; i < 60; i++ {
+		g.Step(1.0 / 60)
+	}
+	if g.player.Mana <= 0 {
+		t.Fatal("mana did not come back")
+	}
+}
+
+func TestTomeRecipesUnlockInSorcery(t *testing.T) {
+	g := NewHeadlessGame(NewScriptedInput(), 9)
+	defer g.Cleanup()
+
+	tome := findRecipe(t, g.crafting.Recipes, "tome_fireball")
+	if g.crafting.IsUnlocked(tome, g.inventory) {
+		t.Fatal("tome recipe unlocked in the first dimension")
+	}
+	g.crafting.Dimension, _ = dimensionIndex("sorcery")
+	if !g.crafting.IsUnlocked(tome, g.inventory) {
+		t.Fatal("tome recipe locked in the sorcery dimension")
+	}
+}
+
+func TestSpellsSurviveSave(t *testing.T) {
+	input := NewScriptedInput()
+	g := NewHeadlessGame(input, 9)
+	defer g.Cleanup()
+	book := g.player.Spells
+	for _, id := range []string{"dark_bolt", "fireball", "chain_lightning", "frost_nova"} {
+		book.Learn(id)
+	}
+	book.Bind(0, "frost_nova")
+	g.player.Mana = 42
+
+	path := filepath.Join(t.TempDir(), "savegame.json")
+	if err := g.SaveGame(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.LoadGame(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded := g.player.Spells
+	if len(loaded.Known) != 4 || loaded.Slots[0] != "frost_nova" || loaded.Slots[3] != "" || g.player.Mana != 42 {
+		t.Fatalf("known %v, slots %v, mana %v", loaded.Known, loaded.Slots, g.player.Mana)
+	}
+
+	// The first slot casts from its key
+	g.player.Mana = g.player.MaxMana
+	input.PressKey(r.KeyQ)
+	g.Step(1.0 / 60)
+	if loaded.Casting == nil {
+		t.Fatal("Q did not cast")
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// SpellsFile holds the definition of every spell
+const SpellsFile = "assets/spells.json"
+
+// spells is the spell registry shared by every subsystem
+var spells = NewSpellRegistry()
+
+// Spell kinds, each goes off in its own way
+const (
+	SpellBolt     = "bolt"     // Strikes whatever is closest to where the player aims
+	SpellFireball = "fireball" // Flies towards the aim and bursts, hurting everything around it
+	SpellChain    = "chain"    // Strikes near the aim, then jumps on to targets close by
+	SpellNova     = "nova"     // Bursts out of the player, chilling everything it hits
+)
+
+// spellKinds lists the kinds spell files may use
+var spellKinds = map[string]bool{
+	SpellBolt:     true,
+	SpellFireball: true,
+	SpellChain:    true,
+	SpellNova:     true,
+}
+
+// SpellVFX is the sprite sheet played where a spell lands, its frames side by side in one row
+type SpellVFX struct {
+	Sheet       string  `json:"sheet"`
+	FrameWidth  int32   `json:"frame_width"`
+	FrameHeight int32   `json:"frame_height"`
+	FrameTime   float32 `json:"frame_time"`
+}
+
+// SpellDef describes one spell
+type SpellDef struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Kind     string   `json:"kind"`
+	Mana     float32  `json:"mana"`
+	CastTime float32  `json:"cast_time"` // Time between pressing the key and the spell going off
+	Cooldown float32  `json:"cooldown"`  // Time after it went off until it can be cast again
+	Damage   int32    `json:"damage"`
+	Range    float32  `json:"range"`            // How far from the player the spell reaches
+	Radius   float32  `json:"radius,omitempty"` // Area of fireballs and novas, how far chains jump
+	Speed    float32  `json:"speed,omitempty"`  // Flight speed of fireballs
+	Jumps    int      `json:"jumps,omitempty"`  // Extra targets a chain hits after the first
+	Chill    float32  `json:"chill,omitempty"`  // How long novas slow what they hit
+	VFX      SpellVFX `json:"vfx"`
+}
+
+// Initials abbreviates the spell's name to the first letter of each word, short enough for a spell slot
+func (def *SpellDef) Initials() string {
+	var initials string
+	for _, word := range strings.Fields(def.Name) {
+		initials += strings.ToUpper(word[:1])
+	}
+	return initials
+}
+
+// SpellRegistry looks spell definitions up by ID
+type SpellRegistry struct {
+	defs  map[string]*SpellDef
+	order []*SpellDef // In file order
+}
+
+// NewSpellRegistry creates an empty registry
+func NewSpellRegistry() *SpellRegistry {
+	return &SpellRegistry{defs: make(map[string]*SpellDef)}
+}
+
+// LoadSpells reads and validates a spell definition file
+func LoadSpells(path string) (*SpellRegistry, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var defs []*SpellDef
+	if err := json.Unmarshal(raw, &defs); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	reg := NewSpellRegistry()
+	for _, def := range defs {
+		if def.ID == "" {
+			return nil, fmt.Errorf("%s: spell %q has no id", path, def.Name)
+		}
+		if _, exists := reg.defs[def.ID]; exists {
+			return nil, fmt.Errorf("%s: spell %q is defined twice", path, def.ID)
+		}
+		if def.Name == "" {
+			def.Name = def.ID
+		}
+		if err := validateSpell(def); err != nil {
+			return nil, fmt.Errorf("%s: spell %q: %w", path, def.ID, err)
+		}
+		reg.defs[def.ID] = def
+		reg.order = append(reg.order, def)
+	}
+	return reg, nil
+}
+
+// validateSpell checks a spell's kind is known and it has what its kind needs
+func validateSpell(def *SpellDef) error {
+	if !spellKinds[def.Kind] {
+		return fmt.Errorf("unknown kind %q", def.Kind)
+	}
+	if def.Mana < 0 || def.CastTime < 0 || def.Cooldown < 0 {
+		return fmt.Errorf("mana, cast time and cooldown cannot be negative")
+	}
+	if def.Damage <= 0 {
+		return fmt.Errorf("damage must be positive")
+	}
+	if def.Kind != SpellNova && def.Range <= 0 {
+		return fmt.Errorf("range must be positive")
+	}
+	if def.Kind != SpellBolt && def.Radius <= 0 {
+		return fmt.Errorf("radius must be positive")
+	}
+	if def.Kind == SpellFireball && def.Speed <= 0 {
+		return fmt.Errorf("speed must be positive")
+	}
+	if def.VFX.Sheet == "" || def.VFX.FrameWidth <= 0 || def.VFX.FrameHeight <= 0 || def.VFX.FrameTime <= 0 {
+		return fmt.Errorf("vfx needs a sheet, a frame size and a frame time")
+	}
+	return nil
+}
+
+// Lookup returns the definition of a spell, if it exists
+func (reg *SpellRegistry) Lookup(id string) (*SpellDef, bool) {
+	def, exists := reg.defs[id]
+	return def, exists
+}
+
+// All returns every spell in file order
+func (reg *SpellRegistry) All() []*SpellDef {
+	return reg.order
+}
+
+// loadSpells replaces the registry with the spell file, keeping the old one if it is broken
+func loadSpells(path string) {
+	loaded, err := LoadSpells(path)
+	if err != nil {
+		fmt.Println("Warning: Could not load spells:", err)
+		return
+	}
+	spells = loaded
+}
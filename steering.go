@@ -0,0 +1,107 @@
+package main
+
+import (
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Steering tunes how an enemy moves within a crowd
+type Steering struct {
+	Radius     float32 // Other enemies closer than this affect the enemy
+	Separation float32 // How hard it steers away from close neighbors
+	Alignment  float32 // How much it goes along with the way its neighbors walk
+	Push       float32 // Share of an overlap pushed out each frame, 0 lets bodies overlap
+	Arrival    float32 // Chasers slow down within this distance of the player
+}
+
+// Steering for each kind of enemy
+var (
+	gruntSteering   = Steering{Radius: 24, Separation: 1.2, Alignment: 0.3, Push: 0.5, Arrival: 30}
+	shooterSteering = Steering{Radius: 40, Separation: 1.5, Alignment: 0, Push: 0.5}
+	ghostSteering   = Steering{Radius: 20, Separation: 0.6, Alignment: 0.2, Push: 0, Arrival: 20}
+)
+
+// arrivalMinSpeed is the share of its speed an arriving chaser keeps, so it still reaches the player
+const arrivalMinSpeed = 0.4
+
+// steer works out how the enemy's neighbors bend its course and pushes it out of bodies it overlaps
+func (e *Enemy) steer(neighbors []*Enemy) {
+	e.Crowd = r.Vector2{}
+	steering := e.Steering
+	if steering.Radius <= 0 {
+		return
+	}
+
+	center := e.center()
+	var separation, alignment, push r.Vector2
+	aligned := 0
+	for _, other := range neighbors {
+		if other == e {
+			continue
+		}
+		away := r.Vector2Subtract(center, other.center())
+		distance := r.Vector2Length(away)
+		if distance >= steering.Radius {
+			continue
+		}
+
+		// Enemies standing on the exact same spot split in a random direction
+		direction := r.Vector2{X: 1}
+		if distance > 0 {
+			direction = r.Vector2Scale(away, 1/distance)
+		} else if e.Rand.Intn(2) == 0 {
+			direction.X = -1
+		}
+
+		// The closer the neighbor, the harder the enemy steers away
+		separation = r.Vector2Add(separation, r.Vector2Scale(direction, 1-distance/steering.Radius))
+		alignment = r.Vector2Add(alignment, r.Vector2{X: other.Direction.X, Y: other.Direction.Y})
+		aligned++
+
+		// Bodies closer than their half widths overlap and are pushed apart
+		overlap := float32(e.Width+other.Width)/2 - distance
+		if overlap > 0 {
+			push = r.Vector2Add(push, r.Vector2Scale(direction, overlap/2*steering.Push))
+		}
+	}
+
+	if aligned > 0 {
+		alignment = r.Vector2Scale(alignment, 1/float32(aligned))
+	}
+	e.Crowd = r.Vector2Add(r.Vector2Scale(separation, steering.Separation), r.Vector2Scale(alignment, steering.Alignment))
+	// Snared enemies hold their ground
+	if e.Rooted() {
+		return
+	}
+	e.X += push.X
+	e.Y += push.Y
+}
+
+// blend bends a direction by the crowd around the enemy
+func (e *Enemy) blend(direction r.Vector2) r.Vector2 {
+	blended := r.Vector2Add(direction, e.Crowd)
+	if r.Vector2Length(blended) == 0 {
+		return direction
+	}
+	blended = r.Vector2Normalize(blended)
+
+	// Never let the crowd shove a chaser into an obstacle its path leads around
+	if e.Nav != nil && e.Ghost == nil {
+		step := r.Vector2Add(e.center(), r.Vector2Scale(blended, navCellSize/2))
+		if e.Nav.Blocked(step) {
+			return direction
+		}
+	}
+	return blended
+}
+
+// arrivalSpeed slows a chaser down as it closes in, so crowds settle around the player
+func (e *Enemy) arrivalSpeed(distance float32) float32 {
+	if e.Steering.Arrival <= 0 || distance >= e.Steering.Arrival {
+		return e.Speed
+	}
+	share := distance / e.Steering.Arrival
+	if share < arrivalMinSpeed {
+		share = arrivalMinSpeed
+	}
+	return e.Speed * share
+}
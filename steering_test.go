@@ -0,0 +1,68 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+func TestCrowdSpreadsOut(t *testing.T) {
+	headless = true
+	w := NewWorld()
+	defer w.Unload()
+	player := &Player{X: 300, Y: 300, Width: 16, Height: 32}
+
+	// Six enemies all start on the same spot
+	var enemies []*Enemy
+	for i := 0; i < 6; i++ {
+		enemy := NewEnemy(100, 100, player, rand.New(rand.NewSource(int64(i))))
+		enemies = append(enemies, enemy)
+		w.Add(enemy)
+	}
+	for i := 0; i < 3*60; i++ {
+		for _, enemy := range enemies {
+			enemy.steer(QueryRadius[*Enemy](w, enemy.center(), enemy.Steering.Radius))
+			w.Moved(enemy)
+		}
+		w.Update(1.0 / 60)
+	}
+
+	for i := range enemies {
+		for j := i + 1; j < len(enemies); j++ {
+			if distance := r.Vector2Distance(enemies[i].center(), enemies[j].center()); distance < 6 {
+				t.Fatalf("enemies %d and %d stacked %v apart", i, j, distance)
+			}
+		}
+	}
+}
+
+func TestSteerPushesOverlappingBodiesApart(t *testing.T) {
+	headless = true
+	player := &Player{X: 300, Y: 300, Width: 16, Height: 32}
+	left := NewEnemy(100, 100, player, rand.New(rand.NewSource(1)))
+	defer left.Unload()
+	right := NewEnemy(104, 100, player, rand.New(rand.NewSource(2)))
+	defer right.Unload()
+
+	left.steer([]*Enemy{left, right})
+	if left.X >= 100 || left.Crowd.X >= 0 {
+		t.Fatalf("left enemy at %v steering %v, want it moved away to the left", left.X, left.Crowd)
+	}
+}
+
+func TestArrivalSlowsChasers(t *testing.T) {
+	headless = true
+	enemy := NewEnemy(0, 0, &Player{Width: 16, Height: 32}, rand.New(rand.NewSource(1)))
+	defer enemy.Unload()
+
+	if speed := enemy.arrivalSpeed(enemy.Steering.Arrival * 2); speed != enemy.Speed {
+		t.Fatalf("far away speed = %v, want %v", speed, enemy.Speed)
+	}
+	if speed := enemy.arrivalSpeed(enemy.Steering.Arrival / 2); speed >= enemy.Speed {
+		t.Fatalf("close speed = %v, want slower than %v", speed, enemy.Speed)
+	}
+	if speed := enemy.arrivalSpeed(0); speed != enemy.Speed*arrivalMinSpeed {
+		t.Fatalf("arrived speed = %v, want %v", speed, enemy.Speed*arrivalMinSpeed)
+	}
+}
@@ -10,59 +10,83 @@ import (
 
 // Stone represents a stone object in the game
 type Stone struct {
-	X          float32
-	Y          float32
-	Width      int32
-	Height     int32
-	Texture    r.Texture2D
-	Health     int32
-	FlashTimer float32
-	WasHit     bool
-	IsGolden   bool
+	X              float32
+	Y              float32
+	Width          int32
+	Height         int32
+	Texture        r.Texture2D
+	Health         int32
+	FlashTimer     float32
+	DamageCooldown float32
+	WasHit         bool
+	IsGolden       bool
 }
 
-// NewStone creates a new stone instance
-func NewStone(gameWidth, gameHeight int32) *Stone {
+// NewStone creates a new stone instance using the world generator.
+// goldenChance is the chance for it to be a golden stone.
+func NewStone(gameWidth, gameHeight int32, goldenChance float32, rng *rand.Rand) *Stone {
+	const width, height = 32, 32
+
+	health := rng.Int31n(10) + 3 // Stones are a bit weaker than trees
+	isGolden := rng.Float32() < goldenChance
+
+	// Random position within game bounds
+	x := float32(rng.Float64() * float64(gameWidth-width))
+	y := float32(rng.Float64() * float64(gameHeight-height))
+
+	return NewStoneAt(x, y, health, isGolden)
+}
+
+// NewStoneAt creates a stone at a known position with a known health and type
+func NewStoneAt(x, y float32, health int32, isGolden bool) *Stone {
 	stone := &Stone{
+		X:          x,
+		Y:          y,
 		Width:      32,
 		Height:     32,
-		Health:     rand.Int31n(10) + 3, // Stones are a bit weaker than trees
+		Health:     health,
 		FlashTimer: 0,
-		IsGolden:   rand.Float32() < 0.2, // 20% chance to be golden
+		IsGolden:   isGolden,
 	}
 
-	// Random position within game bounds
-	stone.X = float32(rand.Float64() * float64(gameWidth-stone.Width))
-	stone.Y = float32(rand.Float64() * float64(gameHeight-stone.Height))
-
 	// Load appropriate texture
 	if stone.IsGolden {
-		stone.Texture = r.LoadTexture("assets/gold-stone.png")
+		stone.Texture = loadTexture("assets/gold-stone.png")
 	} else {
-		stone.Texture = r.LoadTexture("assets/stone.png")
+		stone.Texture = loadTexture("assets/stone.png")
 	}
 
 	return stone
 }
 
-// OnClick handles mouse click interactions with the stone
-func (s *Stone) OnClick(mouseWorldPos r.Vector2, harvestDamage int32) bool {
-	if r.CheckCollisionPointRec(
-		mouseWorldPos,
-		r.Rectangle{
-			X:      s.X,
-			Y:      s.Y,
-			Width:  float32(s.Width),
-			Height: float32(s.Height),
-		},
-	) {
-		s.Health -= harvestDamage
-		s.FlashTimer = 0.1
-		s.WasHit = true
-		return s.Health <= 0
+// TakeDamage chips the stone. Only harvesting breaks it.
+func (s *Stone) TakeDamage(event DamageEvent) bool {
+	if event.Type != DamageHarvest {
+		return false
+	}
+	if s.DamageCooldown > 0 {
+		return false
+	}
+	s.DamageCooldown = event.Cooldown
+	s.Health -= event.Amount
+	s.FlashTimer = 0.1
+	s.WasHit = true
+	return true
+}
+
+// IsDead reports whether the stone has been broken
+func (s *Stone) IsDead() bool {
+	return s.Health <= 0
+}
+
+// Update counts down the hit flash and cooldown
+func (s *Stone) Update(deltaTime float32) {
+	if s.FlashTimer > 0 {
+		s.FlashTimer -= deltaTime
+	}
+	if s.DamageCooldown > 0 {
+		s.DamageCooldown -= deltaTime
 	}
-	s.WasHit = false
-	return false
 }
 
 // Draw renders the stone
@@ -78,7 +102,6 @@ func (s *Stone) Draw(debug bool) {
 
 	// Draw white rectangle overlay when flashing
 	if s.FlashTimer > 0 {
-		s.FlashTimer -= r.GetFrameTime()
 		r.DrawRectangle(
 			int32(math.Floor(float64(s.X))),
 			int32(math.Floor(float64(s.Y))),
@@ -109,7 +132,7 @@ func (s *Stone) Draw(debug bool) {
 
 // Unload frees the texture from memory
 func (s *Stone) Unload() {
-	r.UnloadTexture(s.Texture)
+	unloadTexture(s.Texture)
 }
 
 // GetBounds returns the bounds of the stone
@@ -121,3 +144,18 @@ func (s *Stone) GetBounds() r.Rectangle {
 		Height: float32(s.Height),
 	}
 }
+
+// BlocksPlacement keeps other objects from spawning inside the stone
+func (s *Stone) BlocksPlacement() bool {
+	return true
+}
+
+// BlocksMovement makes enemies path around stones
+func (s *Stone) BlocksMovement() bool {
+	return true
+}
+
+// BlocksBullets stops bullets from flying through stones
+func (s *Stone) BlocksBullets() bool {
+	return true
+}
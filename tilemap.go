@@ -0,0 +1,498 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Tile layers, drawn in this order
+const (
+	TileLayerGround     = iota // Floor under everything
+	TileLayerDecoration        // Grass and other details, only for looks
+	TileLayerCollision         // Every tile on it is solid
+	tileLayerCount
+)
+
+// tileLayerNames are the names map files give the layers
+var tileLayerNames = [tileLayerCount]string{"ground", "decoration", "collision"}
+
+// Tilemap tuning
+const (
+	tileSize      = 16 // Side of a tile in generated maps
+	tileChunkSize = 16 // Tiles per side of a chunk, chunks outside the camera are skipped
+	groundPatch   = 40 // Tiles in one patch of bare ground
+)
+
+// Tile IDs of the built-in tileset generated maps use, 0 is an empty tile
+const (
+	TileGround uint32 = 1
+	TileGrass  uint32 = 2
+	TileWall   uint32 = 3
+)
+
+// Tile flip flags map files keep in the top bits of a tile ID
+const (
+	tileFlipX   uint32 = 1 << 31
+	tileFlipY   uint32 = 1 << 30
+	tileGIDMask uint32 = 0x0FFFFFFF
+)
+
+// tileSource is where a tile is cut from
+type tileSource struct {
+	Image  string
+	Source r.Rectangle
+}
+
+// builtinTiles is the tileset of generated maps
+var builtinTiles = map[uint32]tileSource{
+	TileGround: {Image: "assets/ground.png", Source: r.Rectangle{Width: tileSize, Height: tileSize}},
+	TileGrass:  {Image: "assets/grass.png", Source: r.Rectangle{Width: tileSize, Height: tileSize}},
+	TileWall:   {Image: "assets/wall.png", Source: r.Rectangle{Width: tileSize, Height: tileSize}},
+}
+
+// placedTile is a non-empty tile of a chunk
+type placedTile struct {
+	Position r.Vector2
+	ID       uint32
+}
+
+// Tilemap is the ground of a dimension, as layers of tiles. It is either
+// generated or loaded from a map file in the JSON format of the Tiled editor.
+type Tilemap struct {
+	Columns  int
+	Rows     int
+	TileSize int
+	Width    int32 // Size of the dimension in pixels, the last tiles may reach past it
+	Height   int32
+	Layers   [tileLayerCount][]uint32 // Tile ID of every cell, row by row
+	tiles    map[uint32]tileSource
+	textures map[string]r.Texture2D
+	chunks   [tileLayerCount][][]placedTile // Non-empty tiles of every chunk, row by row
+}
+
+// NewTilemap creates an empty map covering width x height pixels with the built-in tileset
+func NewTilemap(width, height int32) *Tilemap {
+	columns := int(width+tileSize-1) / tileSize
+	rows := int(height+tileSize-1) / tileSize
+	t := &Tilemap{
+		Columns:  columns,
+		Rows:     rows,
+		TileSize: tileSize,
+		Width:    width,
+		Height:   height,
+		tiles:    builtinTiles,
+	}
+	for layer := range t.Layers {
+		t.Layers[layer] = make([]uint32, columns*rows)
+	}
+	return t
+}
+
+// GenerateTilemap lays out patches of bare ground and tufts of grass
+func GenerateTilemap(width, height int32, grass int, rng *rand.Rand) *Tilemap {
+	t := NewTilemap(width, height)
+
+	// Patches of bare ground wander from a random starting tile
+	patches := t.Columns * t.Rows / 300
+	for i := 0; i < patches; i++ {
+		column, row := rng.Intn(t.Columns), rng.Intn(t.Rows)
+		for step := 0; step < groundPatch; step++ {
+			t.Set(TileLayerGround, column, row, TileGround)
+			column = max(0, min(t.Columns-1, column+rng.Intn(3)-1))
+			row = max(0, min(t.Rows-1, row+rng.Intn(3)-1))
+		}
+	}
+
+	for i := 0; i < grass; i++ {
+		t.Set(TileLayerDecoration, rng.Intn(t.Columns), rng.Intn(t.Rows), TileGrass)
+	}
+	return t
+}
+
+// Tile returns the tile ID at a cell, 0 outside the map
+func (t *Tilemap) Tile(layer, column, row int) uint32 {
+	if column < 0 || row < 0 || column >= t.Columns || row >= t.Rows {
+		return 0
+	}
+	return t.Layers[layer][row*t.Columns+column]
+}
+
+// Set places a tile, cells outside the map are ignored
+func (t *Tilemap) Set(layer, column, row int, id uint32) {
+	if column < 0 || row < 0 || column >= t.Columns || row >= t.Rows {
+		return
+	}
+	t.Layers[layer][row*t.Columns+column] = id
+}
+
+// Load loads the tile textures and sorts the tiles into chunks for drawing
+func (t *Tilemap) Load() {
+	t.textures = make(map[string]r.Texture2D)
+	for _, source := range t.tiles {
+		if _, loaded := t.textures[source.Image]; !loaded {
+			t.textures[source.Image] = loadTexture(source.Image)
+		}
+	}
+
+	chunkColumns, chunkRows := t.chunkCounts()
+	for layer := range t.chunks {
+		t.chunks[layer] = make([][]placedTile, chunkColumns*chunkRows)
+		for i, id := range t.Layers[layer] {
+			if id&tileGIDMask == 0 {
+				continue
+			}
+			column, row := i%t.Columns, i/t.Columns
+			chunk := row/tileChunkSize*chunkColumns + column/tileChunkSize
+			t.chunks[layer][chunk] = append(t.chunks[layer][chunk], placedTile{
+				Position: r.Vector2{X: float32(column * t.TileSize), Y: float32(row * t.TileSize)},
+				ID:       id,
+			})
+		}
+	}
+}
+
+// Unload frees the tile textures
+func (t *Tilemap) Unload() {
+	for _, texture := range t.textures {
+		unloadTexture(texture)
+	}
+	t.textures = nil
+}
+
+// chunkCounts returns how many chunks cover the map across and down
+func (t *Tilemap) chunkCounts() (int, int) {
+	return (t.Columns + tileChunkSize - 1) / tileChunkSize, (t.Rows + tileChunkSize - 1) / tileChunkSize
+}
+
+// Draw draws the tiles of one layer in the chunks view touches
+func (t *Tilemap) Draw(layer int, view r.Rectangle) {
+	chunkColumns, chunkRows := t.chunkCounts()
+	chunkPixels := float32(tileChunkSize * t.TileSize)
+	minColumn := max(0, int(view.X/chunkPixels))
+	minRow := max(0, int(view.Y/chunkPixels))
+	maxColumn := min(chunkColumns-1, int((view.X+view.Width)/chunkPixels))
+	maxRow := min(chunkRows-1, int((view.Y+view.Height)/chunkPixels))
+
+	size := float32(t.TileSize)
+	for row := minRow; row <= maxRow; row++ {
+		for column := minColumn; column <= maxColumn; column++ {
+			for _, tile := range t.chunks[layer][row*chunkColumns+column] {
+				source, exists := t.tiles[tile.ID&tileGIDMask]
+				if !exists {
+					continue
+				}
+				rect := source.Source
+				if tile.ID&tileFlipX != 0 {
+					rect.Width = -rect.Width
+				}
+				if tile.ID&tileFlipY != 0 {
+					rect.Height = -rect.Height
+				}
+				r.DrawTexturePro(
+					t.textures[source.Image],
+					rect,
+					r.Rectangle{X: tile.Position.X, Y: tile.Position.Y, Width: size, Height: size},
+					r.Vector2{},
+					0,
+					r.White,
+				)
+			}
+		}
+	}
+}
+
+// Colliders returns solid entities covering the collision layer, one per run of tiles in a row
+func (t *Tilemap) Colliders() []*Collider {
+	var colliders []*Collider
+	size := float32(t.TileSize)
+	for row := 0; row < t.Rows; row++ {
+		for column := 0; column < t.Columns; column++ {
+			if t.Tile(TileLayerCollision, column, row)&tileGIDMask == 0 {
+				continue
+			}
+			start := column
+			for column+1 < t.Columns && t.Tile(TileLayerCollision, column+1, row)&tileGIDMask != 0 {
+				column++
+			}
+			colliders = append(colliders, &Collider{Bounds: r.Rectangle{
+				X:      float32(start) * size,
+				Y:      float32(row) * size,
+				Width:  float32(column-start+1) * size,
+				Height: size,
+			}})
+		}
+	}
+	return colliders
+}
+
+// Collider is a solid stretch of a tilemap's collision layer. The tilemap draws
+// the tiles, the collider only takes part in collisions.
+type Collider struct {
+	Bounds r.Rectangle
+}
+
+func (c *Collider) Draw(debug bool) {
+	if debug {
+		r.DrawRectangleLinesEx(c.Bounds, 1, r.Red)
+	}
+}
+
+func (c *Collider) Unload() {}
+
+func (c *Collider) GetBounds() r.Rectangle {
+	return c.Bounds
+}
+
+// IsSolid stops the player and enemies at collision tiles
+func (c *Collider) IsSolid() bool {
+	return true
+}
+
+// BlocksMovement makes enemies path around collision tiles
+func (c *Collider) BlocksMovement() bool {
+	return true
+}
+
+// BlocksBullets stops bullets at collision tiles
+func (c *Collider) BlocksBullets() bool {
+	return true
+}
+
+// BlocksPlacement keeps other objects from spawning inside collision tiles
+func (c *Collider) BlocksPlacement() bool {
+	return true
+}
+
+// tiledMap is the part of a Tiled JSON map export the game reads
+type tiledMap struct {
+	Width       int            `json:"width"`
+	Height      int            `json:"height"`
+	TileWidth   int            `json:"tilewidth"`
+	TileHeight  int            `json:"tileheight"`
+	Infinite    bool           `json:"infinite"`
+	Orientation string         `json:"orientation"`
+	Layers      []tiledLayer   `json:"layers"`
+	Tilesets    []tiledTileset `json:"tilesets"`
+}
+
+type tiledLayer struct {
+	Name        string          `json:"name"`
+	Type        string          `json:"type"`
+	Width       int             `json:"width"`
+	Height      int             `json:"height"`
+	Data        json.RawMessage `json:"data"`
+	Encoding    string          `json:"encoding"`
+	Compression string          `json:"compression"`
+	Layers      []tiledLayer    `json:"layers"` // Layers of a group
+}
+
+type tiledTileset struct {
+	FirstGID   uint32 `json:"firstgid"`
+	Source     string `json:"source"`
+	Name       string `json:"name"`
+	Image      string `json:"image"`
+	TileWidth  int    `json:"tilewidth"`
+	TileHeight int    `json:"tileheight"`
+	Columns    int    `json:"columns"`
+	TileCount  int    `json:"tilecount"`
+	Margin     int    `json:"margin"`
+	Spacing    int    `json:"spacing"`
+	Tiles      []struct {
+		ID          uint32 `json:"id"`
+		Image       string `json:"image"`
+		ImageWidth  int    `json:"imagewidth"`
+		ImageHeight int    `json:"imageheight"`
+	} `json:"tiles"`
+}
+
+// LoadTilemap reads a map exported from Tiled as JSON. Tile layers named ground,
+// decoration and collision are used, tilesets must be embedded in the map and
+// image paths are relative to the map file.
+func LoadTilemap(path string) (*Tilemap, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var tiled tiledMap
+	if err := json.Unmarshal(raw, &tiled); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if tiled.Infinite {
+		return nil, fmt.Errorf("%s: infinite maps are not supported", path)
+	}
+	if tiled.Orientation != "" && tiled.Orientation != "orthogonal" {
+		return nil, fmt.Errorf("%s: %s maps are not supported", path, tiled.Orientation)
+	}
+	if tiled.Width <= 0 || tiled.Height <= 0 || tiled.TileWidth <= 0 || tiled.TileWidth != tiled.TileHeight {
+		return nil, fmt.Errorf("%s: map needs a size and square tiles", path)
+	}
+
+	t := &Tilemap{
+		Columns:  tiled.Width,
+		Rows:     tiled.Height,
+		TileSize: tiled.TileWidth,
+		Width:    int32(tiled.Width * tiled.TileWidth),
+		Height:   int32(tiled.Height * tiled.TileHeight),
+		tiles:    make(map[uint32]tileSource),
+	}
+	for layer := range t.Layers {
+		t.Layers[layer] = make([]uint32, t.Columns*t.Rows)
+	}
+
+	dir := filepath.Dir(path)
+	for _, tileset := range tiled.Tilesets {
+		if err := t.addTileset(tileset, dir); err != nil {
+			return nil, fmt.Errorf("%s: tileset %q: %w", path, tileset.Name, err)
+		}
+	}
+	if err := t.addLayers(tiled.Layers); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return t, nil
+}
+
+// addTileset registers the tiles of a tileset cut from one image or made of one image per tile
+func (t *Tilemap) addTileset(tileset tiledTileset, dir string) error {
+	if tileset.Source != "" {
+		return fmt.Errorf("external tilesets are not supported, embed %s in the map", tileset.Source)
+	}
+
+	if tileset.Image != "" {
+		if tileset.Columns <= 0 || tileset.TileWidth <= 0 || tileset.TileHeight <= 0 {
+			return fmt.Errorf("needs columns and a tile size")
+		}
+		image := filepath.Join(dir, tileset.Image)
+		for i := 0; i < tileset.TileCount; i++ {
+			column, row := i%tileset.Columns, i/tileset.Columns
+			t.tiles[tileset.FirstGID+uint32(i)] = tileSource{Image: image, Source: r.Rectangle{
+				X:      float32(tileset.Margin + column*(tileset.TileWidth+tileset.Spacing)),
+				Y:      float32(tileset.Margin + row*(tileset.TileHeight+tileset.Spacing)),
+				Width:  float32(tileset.TileWidth),
+				Height: float32(tileset.TileHeight),
+			}}
+		}
+		return nil
+	}
+
+	for _, tile := range tileset.Tiles {
+		if tile.Image == "" {
+			return fmt.Errorf("tile %d has no image", tile.ID)
+		}
+		width, height := tile.ImageWidth, tile.ImageHeight
+		if width == 0 || height == 0 {
+			width, height = t.TileSize, t.TileSize
+		}
+		t.tiles[tileset.FirstGID+tile.ID] = tileSource{
+			Image:  filepath.Join(dir, tile.Image),
+			Source: r.Rectangle{Width: float32(width), Height: float32(height)},
+		}
+	}
+	return nil
+}
+
+// addLayers copies the tile layers, looking into groups. Object and image layers are skipped.
+func (t *Tilemap) addLayers(layers []tiledLayer) error {
+	for _, layer := range layers {
+		switch layer.Type {
+		case "group":
+			if err := t.addLayers(layer.Layers); err != nil {
+				return err
+			}
+			continue
+		case "tilelayer":
+		default:
+			continue
+		}
+
+		index := -1
+		for i, name := range tileLayerNames {
+			if strings.EqualFold(layer.Name, name) {
+				index = i
+			}
+		}
+		if index < 0 {
+			return fmt.Errorf("layer %q is not one of %s", layer.Name, strings.Join(tileLayerNames[:], ", "))
+		}
+		if layer.Width != t.Columns || layer.Height != t.Rows {
+			return fmt.Errorf("layer %q is not the size of the map", layer.Name)
+		}
+
+		data, err := layer.tiles()
+		if err != nil {
+			return fmt.Errorf("layer %q: %w", layer.Name, err)
+		}
+		if len(data) != t.Columns*t.Rows {
+			return fmt.Errorf("layer %q has %d tiles instead of %d", layer.Name, len(data), t.Columns*t.Rows)
+		}
+		for i, id := range data {
+			if id&tileGIDMask != 0 {
+				if _, exists := t.tiles[id&tileGIDMask]; !exists {
+					return fmt.Errorf("layer %q uses tile %d, which no tileset has", layer.Name, id&tileGIDMask)
+				}
+			}
+			t.Layers[index][i] = id
+		}
+	}
+	return nil
+}
+
+// tiles decodes a layer's tile IDs, stored as a plain list or as base64 with optional compression
+func (layer tiledLayer) tiles() ([]uint32, error) {
+	if layer.Encoding == "" || layer.Encoding == "csv" {
+		var data []uint32
+		if err := json.Unmarshal(layer.Data, &data); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+	if layer.Encoding != "base64" {
+		return nil, fmt.Errorf("unknown encoding %q", layer.Encoding)
+	}
+
+	var encoded string
+	if err := json.Unmarshal(layer.Data, &encoded); err != nil {
+		return nil, err
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, err
+	}
+
+	var reader io.Reader = bytes.NewReader(decoded)
+	switch layer.Compression {
+	case "":
+	case "zlib":
+		if reader, err = zlib.NewReader(reader); err != nil {
+			return nil, err
+		}
+	case "gzip":
+		if reader, err = gzip.NewReader(reader); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported compression %q", layer.Compression)
+	}
+	raw, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]uint32, len(raw)/4)
+	for i := range data {
+		data[i] = binary.LittleEndian.Uint32(raw[i*4:])
+	}
+	return data, nil
+}
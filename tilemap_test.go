@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+// tiledTestMap is a 3x2 map with one tileset image, holding the given layers
+func tiledTestMap(layers string) string {
+	return fmt.Sprintf(`{
+		"width": 3, "height": 2, "tilewidth": 16, "tileheight": 16, "orientation": "orthogonal",
+		"tilesets": [{"firstgid": 1, "name": "walls", "image": "walls.png",
+			"tilewidth": 16, "tileheight": 16, "columns": 2, "tilecount": 4}],
+		"layers": [%s]
+	}`, layers)
+}
+
+func TestLoadBundledMap(t *testing.T) {
+	m, err := LoadTilemap("assets/maps/sorcery.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Width != 75*16 || m.Height != 69*16 {
+		t.Fatalf("map is %dx%d pixels", m.Width, m.Height)
+	}
+	if len(m.Colliders()) == 0 {
+		t.Fatal("map has no colliders")
+	}
+}
+
+func TestLoadTilemapLayers(t *testing.T) {
+	// The collision layer is stored as base64, with a flipped tile
+	collision := make([]byte, 6*4)
+	for i, id := range []uint32{1, 2 | tileFlipX, 0, 0, 0, 3} {
+		binary.LittleEndian.PutUint32(collision[i*4:], id)
+	}
+	path := writeTestFile(t, "map.json", tiledTestMap(fmt.Sprintf(`
+		{"name": "Ground", "type": "tilelayer", "width": 3, "height": 2, "data": [4, 4, 4, 4, 4, 4]},
+		{"name": "objects", "type": "objectgroup"},
+		{"name": "walls", "type": "group", "layers": [
+			{"name": "collision", "type": "tilelayer", "width": 3, "height": 2, "encoding": "base64", "data": %q}
+		]}`, base64.StdEncoding.EncodeToString(collision))))
+
+	m, err := LoadTilemap(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Tile(TileLayerGround, 2, 1) != 4 || m.Tile(TileLayerCollision, 1, 0)&tileGIDMask != 2 {
+		t.Fatal("tiles not read")
+	}
+	if m.Tile(TileLayerGround, 5, 5) != 0 {
+		t.Fatal("tile outside the map is not empty")
+	}
+
+	// Runs of solid tiles in a row become one collider
+	colliders := m.Colliders()
+	if len(colliders) != 2 {
+		t.Fatalf("got %d colliders, want 2", len(colliders))
+	}
+	if bounds := colliders[0].GetBounds(); bounds != (r.Rectangle{X: 0, Y: 0, Width: 32, Height: 16}) {
+		t.Fatalf("first collider covers %v", bounds)
+	}
+	if bounds := colliders[1].GetBounds(); bounds != (r.Rectangle{X: 32, Y: 16, Width: 16, Height: 16}) {
+		t.Fatalf("second collider covers %v", bounds)
+	}
+}
+
+func TestLoadTilemapRejectsBadMaps(t *testing.T) {
+	files := map[string]string{
+		"infinite":         `{"width": 3, "height": 2, "tilewidth": 16, "tileheight": 16, "infinite": true}`,
+		"isometric":        `{"width": 3, "height": 2, "tilewidth": 16, "tileheight": 16, "orientation": "isometric"}`,
+		"external tileset": `{"width": 3, "height": 2, "tilewidth": 16, "tileheight": 16, "tilesets": [{"firstgid": 1, "source": "walls.tsx"}]}`,
+		"unknown layer":    tiledTestMap(`{"name": "sky", "type": "tilelayer", "width": 3, "height": 2, "data": [0, 0, 0, 0, 0, 0]}`),
+		"wrong size":       tiledTestMap(`{"name": "ground", "type": "tilelayer", "width": 2, "height": 2, "data": [0, 0, 0, 0]}`),
+		"unknown tile":     tiledTestMap(`{"name": "ground", "type": "tilelayer", "width": 3, "height": 2, "data": [9, 0, 0, 0, 0, 0]}`),
+		"short layer":      tiledTestMap(`{"name": "ground", "type": "tilelayer", "width": 3, "height": 2, "data": [0, 0]}`),
+	}
+	for name, content := range files {
+		if _, err := LoadTilemap(writeTestFile(t, "map.json", content)); err == nil {
+			t.Errorf("%s: loaded without an error", name)
+		}
+	}
+}
+
+func TestGeneratedTilemap(t *testing.T) {
+	m := GenerateTilemap(800, 600, 10, rand.New(rand.NewSource(1)))
+	ground, grass := 0, 0
+	for i := range m.Layers[TileLayerGround] {
+		if m.Layers[TileLayerGround][i] != 0 {
+			ground++
+		}
+		if m.Layers[TileLayerDecoration][i] != 0 {
+			grass++
+		}
+	}
+	if ground == 0 || grass == 0 || grass > 10 {
+		t.Fatalf("%d ground and %d grass tiles", ground, grass)
+	}
+	if len(m.Colliders()) != 0 {
+		t.Fatal("generated map has colliders")
+	}
+}
+
+func TestMapDimensionTakesMapSize(t *testing.T) {
+	g := NewHeadlessGame(NewScriptedInput(), 3)
+	defer g.Cleanup()
+	index, exists := dimensionIndex("sorcery")
+	if !exists {
+		t.Fatal("no sorcery dimension")
+	}
+	g.enterDimension(index)
+	if g.tilemap.Width != 75*16 || g.player.GameWidth != g.tilemap.Width {
+		t.Fatalf("dimension is %d wide, map is %d", g.player.GameWidth, g.tilemap.Width)
+	}
+	if len(Query[*Collider](g.world)) == 0 {
+		t.Fatal("map colliders not added to the world")
+	}
+}
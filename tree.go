@@ -10,51 +10,69 @@ import (
 
 // Tree represents a tree object in the game
 type Tree struct {
-	X          float32
-	Y          float32
-	Width      int32
-	Height     int32
-	Texture    r.Texture2D
-	Health     int32
-	FlashTimer float32
-	WasHit     bool
+	X              float32
+	Y              float32
+	Width          int32
+	Height         int32
+	Texture        r.Texture2D
+	Health         int32
+	FlashTimer     float32
+	DamageCooldown float32
+	WasHit         bool
 }
 
-// NewTree creates a new tree instance
-func NewTree(gameWidth, gameHeight int32) *Tree {
-	tree := &Tree{
+// NewTree creates a new tree instance using the world generator
+func NewTree(gameWidth, gameHeight int32, rng *rand.Rand) *Tree {
+	const width, height = 48, 64
+
+	// Random position within game bounds
+	x := float32(rng.Float64() * float64(gameWidth-width))
+	y := float32(rng.Float64() * float64(gameHeight-height))
+
+	return NewTreeAt(x, y, rng.Int31n(7)+2)
+}
+
+// NewTreeAt creates a tree at a known position with a known health
+func NewTreeAt(x, y float32, health int32) *Tree {
+	return &Tree{
+		X:          x,
+		Y:          y,
 		Width:      48,
 		Height:     64,
-		Health:     rand.Int31n(7) + 2,
+		Health:     health,
 		FlashTimer: 0,
+		Texture:    loadTexture("assets/tree.png"),
 	}
+}
 
-	// Random position within game bounds
-	tree.X = float32(rand.Float64() * float64(gameWidth-tree.Width))
-	tree.Y = float32(rand.Float64() * float64(gameHeight-tree.Height))
-	tree.Texture = r.LoadTexture("assets/tree.png")
+// TakeDamage chips the tree. Only harvesting breaks it.
+func (t *Tree) TakeDamage(event DamageEvent) bool {
+	if event.Type != DamageHarvest {
+		return false
+	}
+	if t.DamageCooldown > 0 {
+		return false
+	}
+	t.DamageCooldown = event.Cooldown
+	t.Health -= event.Amount
+	t.FlashTimer = 0.1
+	t.WasHit = true
+	return true
+}
 
-	return tree
+// IsDead reports whether the tree has been broken
+func (t *Tree) IsDead() bool {
+	return t.Health <= 0
 }
 
-// OnClick handles mouse click interactions with the tree
-func (t *Tree) OnClick(mouseWorldPos r.Vector2, harvestDamage int32) bool {
-	if r.CheckCollisionPointRec(
-		mouseWorldPos,
-		r.Rectangle{
-			X:      t.X,
-			Y:      t.Y,
-			Width:  float32(t.Width),
-			Height: float32(t.Height),
-		},
-	) {
-		t.Health -= harvestDamage
-		t.FlashTimer = 0.1
-		t.WasHit = true
-		return t.Health <= 0
+// Update counts down the hit flash and cooldown
+func (t *Tree) Update(deltaTime float32) {
+	if t.FlashTimer > 0 {
+		t.FlashTimer -= deltaTime
+	}
+	if t.DamageCooldown > 0 {
+		t.DamageCooldown -= deltaTime
 	}
-	t.WasHit = false
-	return false
 }
 
 // Draw renders the tree
@@ -70,7 +88,6 @@ func (t *Tree) Draw(debug bool) {
 
 	// Draw white rectangle overlay when flashing
 	if t.FlashTimer > 0 {
-		t.FlashTimer -= r.GetFrameTime()
 		r.DrawRectangle(
 			int32(math.Floor(float64(t.X))),
 			int32(math.Floor(float64(t.Y))),
@@ -102,7 +119,7 @@ func (t *Tree) Draw(debug bool) {
 
 // Unload frees the texture from memory
 func (t *Tree) Unload() {
-	r.UnloadTexture(t.Texture)
+	unloadTexture(t.Texture)
 }
 
 // Add this method to Tree struct
@@ -114,3 +131,18 @@ func (t *Tree) GetBounds() r.Rectangle {
 		Height: float32(t.Height),
 	}
 }
+
+// BlocksPlacement keeps other objects from spawning inside the tree
+func (t *Tree) BlocksPlacement() bool {
+	return true
+}
+
+// BlocksMovement makes enemies path around trees
+func (t *Tree) BlocksMovement() bool {
+	return true
+}
+
+// BlocksBullets stops bullets from flying through trees
+func (t *Tree) BlocksBullets() bool {
+	return true
+}
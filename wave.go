@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Wave pacing
+const (
+	firstRestTime   = 20.0 // Seconds before the first wave of a dimension
+	restTime        = 15.0 // Seconds between waves, shortened by the dimension tier
+	minRestTime     = 8.0
+	portalGroupSize = 6 // Most enemies a single portal spawns
+	baseSpawnRate   = 5.0
+	minSpawnRate    = 1.5
+
+	eliteCostMultiplier = 3 // Elites spend this many times the budget of their kind
+	eliteChancePerWave  = 0.02
+	maxEliteChance      = 0.5
+)
+
+// WavePhase is what the wave director is doing
+type WavePhase int
+
+const (
+	WaveRest   WavePhase = iota // Counting down while the player harvests and crafts
+	WaveActive                  // Opening portals until the wave's enemies are spent
+)
+
+// WaveDirector plans waves of enemies from a threat budget and opens portals
+// for them. Every wave is followed by a rest, and both the budget and the speed
+// of the portals grow with the wave number, the run time and the dimension tier.
+type WaveDirector struct {
+	Wave    int       `json:"wave"` // The current wave, or the last one while resting
+	Phase   WavePhase `json:"phase"`
+	Timer   float32   `json:"timer"`   // Rest left, or time until the next portal opens
+	Pending [][]Spawn `json:"pending"` // Enemies of the portals still to open this wave
+}
+
+// NewWaveDirector creates a director resting before the first wave
+func NewWaveDirector() *WaveDirector {
+	return &WaveDirector{Phase: WaveRest, Timer: firstRestTime}
+}
+
+// Rest starts a full rest, used when the player arrives in a dimension
+func (w *WaveDirector) Rest() {
+	w.Phase = WaveRest
+	w.Timer = firstRestTime
+	w.Pending = nil
+}
+
+// Budget is the threat the given wave spends on enemies
+func Budget(wave, tier int, gameTimer float32) int {
+	return 4 + 2*wave + 3*tier + int(gameTimer/60)
+}
+
+// Update advances the director. It returns the enemy kinds of a portal to open
+// this frame, or nil. threats counts the enemies and portals still alive, a
+// wave only ends once all of them are gone.
+func (w *WaveDirector) Update(deltaTime float32, threats int, dim *Dimension, tier int, gameTimer float32, rng *RandomStreams) []Spawn {
+	w.Timer -= deltaTime
+
+	switch w.Phase {
+	case WaveRest:
+		if w.Timer <= 0 {
+			w.Wave++
+			w.Phase = WaveActive
+			w.Timer = 0
+			w.Pending = w.plan(dim, Budget(w.Wave, tier, gameTimer), w.EliteChance(dim), rng)
+		}
+
+	case WaveActive:
+		if len(w.Pending) > 0 {
+			if w.Timer > 0 {
+				return nil
+			}
+			w.Timer = dim.PortalInterval
+			group := w.Pending[0]
+			w.Pending = w.Pending[1:]
+			return group
+		}
+		if threats == 0 {
+			w.Phase = WaveRest
+			w.Timer = float32(math.Max(minRestTime, restTime-2*float64(tier)))
+		}
+	}
+	return nil
+}
+
+// plan spends a budget on enemies from the dimension's roster and splits them into portals.
+// Elites cost eliteCostMultiplier times as much, and are only planned when the budget allows.
+func (w *WaveDirector) plan(dim *Dimension, budget int, eliteChance float32, rng *RandomStreams) [][]Spawn {
+	planRng := rng.Derive("wave", w.Wave)
+
+	var spawns []Spawn
+	for {
+		var affordable []RosterEntry
+		for _, entry := range dim.Enemies {
+			if enemyKinds[entry.Kind].Cost <= budget {
+				affordable = append(affordable, entry)
+			}
+		}
+		if len(affordable) == 0 {
+			break
+		}
+		spawn := Spawn{Kind: pickWeighted(affordable, planRng)}
+		cost := enemyKinds[spawn.Kind].Cost
+		if planRng.Float32() < eliteChance && cost*eliteCostMultiplier <= budget {
+			spawn.Elite = true
+			cost *= eliteCostMultiplier
+		}
+		spawns = append(spawns, spawn)
+		budget -= cost
+	}
+
+	var groups [][]Spawn
+	for len(spawns) > 0 {
+		size := portalGroupSize
+		if size > len(spawns) {
+			size = len(spawns)
+		}
+		groups = append(groups, spawns[:size])
+		spawns = spawns[size:]
+	}
+	return groups
+}
+
+// EliteChance is the chance for each enemy of the current wave to be an elite.
+// It starts at the dimension's chance and grows with every wave.
+func (w *WaveDirector) EliteChance(dim *Dimension) float32 {
+	return float32(math.Min(maxEliteChance, float64(dim.EliteChance+eliteChancePerWave*float32(w.Wave-1))))
+}
+
+// SpawnRate is how many seconds the portals of the current wave wait between enemies
+func (w *WaveDirector) SpawnRate() float32 {
+	return float32(math.Max(minSpawnRate, baseSpawnRate-0.3*float64(w.Wave-1)))
+}
+
+// Status describes the wave and its countdown for the HUD
+func (w *WaveDirector) Status(threats int) string {
+	if w.Phase == WaveRest {
+		return fmt.Sprintf("Wave %d in %ds", w.Wave+1, int(math.Ceil(float64(w.Timer))))
+	}
+	return fmt.Sprintf("Wave %d - %d left", w.Wave, threats)
+}
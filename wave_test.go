@@ -0,0 +1,124 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// testDimension returns the first dimension of the dimension file
+func testDimension(t *testing.T) *Dimension {
+	t.Helper()
+	loadDataFiles()
+	loaded, err := LoadDimensions(DimensionsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loaded[0]
+}
+
+func TestWavePlanStaysInBudget(t *testing.T) {
+	dim := testDimension(t)
+	rng := NewRandomStreams(11)
+	w := NewWaveDirector()
+	w.Wave = 3
+
+	budget := Budget(w.Wave, 0, 0)
+	groups := w.plan(dim, budget, w.EliteChance(dim), rng)
+	spent := 0
+	for _, group := range groups {
+		if len(group) == 0 || len(group) > portalGroupSize {
+			t.Fatalf("portal with %d enemies", len(group))
+		}
+		for _, spawn := range group {
+			cost := enemyKinds[spawn.Kind].Cost
+			if spawn.Elite {
+				cost *= eliteCostMultiplier
+			}
+			spent += cost
+		}
+	}
+	if spent == 0 || spent > budget {
+		t.Fatalf("spent %d of a %d budget", spent, budget)
+	}
+	if again := w.plan(dim, budget, w.EliteChance(dim), rng); !reflect.DeepEqual(groups, again) {
+		t.Fatal("same seed and wave planned different enemies")
+	}
+}
+
+func TestWaveDirectorCycle(t *testing.T) {
+	dim := testDimension(t)
+	rng := NewRandomStreams(11)
+	w := NewWaveDirector()
+
+	// Rest until the first wave starts
+	if spawns := w.Update(firstRestTime-1, 0, dim, 0, 0, rng); spawns != nil || w.Phase != WaveRest {
+		t.Fatal("wave started during the first rest")
+	}
+	w.Update(1, 0, dim, 0, 0, rng)
+	if w.Phase != WaveActive || w.Wave != 1 || len(w.Pending) == 0 {
+		t.Fatalf("first wave not planned: %+v", w)
+	}
+
+	// Portals open one interval apart until the plan is spent
+	portals := len(w.Pending)
+	for opened := 0; opened < portals; opened++ {
+		if spawns := w.Update(0, 1, dim, 0, 0, rng); spawns == nil {
+			t.Fatalf("portal %d did not open", opened+1)
+		}
+		if spawns := w.Update(dim.PortalInterval/2, 1, dim, 0, 0, rng); spawns != nil {
+			t.Fatal("portal opened before the interval passed")
+		}
+		w.Update(dim.PortalInterval/2, 1, dim, 0, 0, rng)
+	}
+
+	// The wave only ends once every threat is gone
+	w.Update(1, 3, dim, 0, 0, rng)
+	if w.Phase != WaveActive {
+		t.Fatal("wave ended with enemies left")
+	}
+	w.Update(1, 0, dim, 0, 0, rng)
+	if w.Phase != WaveRest || w.Timer != restTime {
+		t.Fatalf("no rest after the wave: %+v", w)
+	}
+}
+
+func TestWavesInGame(t *testing.T) {
+	g := NewHeadlessGame(NewScriptedInput(), 11)
+	defer g.Cleanup()
+	g.player.MaxHealth, g.player.CurrentHealth = 1e9, 1e9
+
+	for i := 0; i < (firstRestTime+1)*60; i++ {
+		g.Step(1.0 / 60)
+	}
+	portals := Query[*Portal](g.world)
+	if g.waves.Wave != 1 || g.waves.Phase != WaveActive || len(portals) != 1 {
+		t.Fatalf("wave %d in phase %d with %d portals", g.waves.Wave, g.waves.Phase, len(portals))
+	}
+
+	// The wave and its open portals survive a save
+	path := filepath.Join(t.TempDir(), "savegame.json")
+	if err := g.SaveGame(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded := NewHeadlessGame(NewScriptedInput(), 1)
+	defer loaded.Cleanup()
+	if err := loaded.LoadGame(path); err != nil {
+		t.Fatal(err)
+	}
+	loadedPortals := Query[*Portal](loaded.world)
+	if loaded.waves.Wave != 1 || len(loadedPortals) != 1 || len(loadedPortals[0].Spawns) != len(portals[0].Spawns) {
+		t.Fatal("wave not restored")
+	}
+
+	// Killing everything that comes through ends the wave
+	for i := 0; i < 120*60 && g.waves.Phase == WaveActive; i++ {
+		for _, enemy := range Query[*Enemy](g.world) {
+			g.destroy(enemy)
+		}
+		g.Step(1.0 / 60)
+	}
+	if g.waves.Phase != WaveRest || g.waves.Wave != 1 {
+		t.Fatalf("wave %d did not end", g.waves.Wave)
+	}
+}
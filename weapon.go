@@ -7,13 +7,16 @@ import (
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
-// Weapon is the base interface for all weapons
+// Weapon is the base interface for all weapons.
+// Hits reports every target the weapon touches this frame, the game applies them.
 type Weapon interface {
 	Update(deltaTime float32, player *Player)
 	Draw(player *Player, camera rl.Camera2D, debug bool)
-	OnActivate(player *Player, camera rl.Camera2D)
+	OnActivate(player *Player)
 	OnDeactivate(player *Player)
 	IsActive() bool
+	Hits(world *World, player *Player) []DamageEvent
+	Unload()
 }
 
 // BaseWeapon contains common weapon properties
@@ -45,11 +48,32 @@ func NewRayGun() *RayGun {
 		CooldownRate: 30.0,
 		HeatRate:     40.0,
 		AimLength:    100.0,
-		Texture:      rl.LoadTexture("assets/ray-gun.png"),
+		Texture:      loadTexture("assets/ray-gun.png"),
 	}
 }
 
 func (r *RayGun) Update(deltaTime float32, player *Player) {
+	// Aim the ray at the player's target, capped at the aim length
+	if r.Active {
+		playerCenter := rl.Vector2{
+			X: player.X + float32(player.Width)/2,
+			Y: player.Y + float32(player.Height)/2,
+		}
+		direction := rl.Vector2{
+			X: player.AimTarget.X - playerCenter.X,
+			Y: player.AimTarget.Y - playerCenter.Y,
+		}
+
+		length := float32(Sqrt(float64(direction.X*direction.X + direction.Y*direction.Y)))
+		if length > 0 {
+			rayLength := float32(Min(float64(length), float64(r.AimLength)))
+			r.rayDirection = rl.Vector2{
+				X: direction.X / length * rayLength,
+				Y: direction.Y / length * rayLength,
+			}
+		}
+	}
+
 	// Handle heat mechanics
 	if r.Active {
 		r.HeatLevel += r.HeatRate * deltaTime
@@ -72,40 +96,17 @@ func (r *RayGun) Draw(player *Player, camera rl.Camera2D, debug bool) {
 		return
 	}
 
-	mouseScreen := rl.GetMousePosition()
-	mouseWorld := rl.GetScreenToWorld2D(mouseScreen, camera)
-
 	playerCenter := rl.Vector2{
 		X: player.X + float32(player.Width)/2,
 		Y: player.Y + float32(player.Height)/2,
 	}
 
-	direction := rl.Vector2{
-		X: mouseWorld.X - playerCenter.X,
-		Y: mouseWorld.Y - playerCenter.Y,
-	}
-
-	length := float32(Sqrt(float64(direction.X*direction.X + direction.Y*direction.Y)))
-	if length > 0 {
-		normalizedDir := rl.Vector2{
-			X: direction.X / length,
-			Y: direction.Y / length,
-		}
-
-		rayLength := float32(Min(float64(length), float64(r.AimLength)))
-		endPoint := rl.Vector2{
-			X: playerCenter.X + normalizedDir.X*rayLength,
-			Y: playerCenter.Y + normalizedDir.Y*rayLength,
-		}
-
-		// Store ray direction for collision checks
-		r.rayDirection = rl.Vector2{
-			X: normalizedDir.X * rayLength,
-			Y: normalizedDir.Y * rayLength,
-		}
-
-		rl.DrawLineEx(playerCenter, endPoint, 2, rl.Red)
+	// Draw the ray aimed during Update
+	endPoint := rl.Vector2{
+		X: playerCenter.X + r.rayDirection.X,
+		Y: playerCenter.Y + r.rayDirection.Y,
 	}
+	rl.DrawLineEx(playerCenter, endPoint, 2, rl.Red)
 
 	// Draw heat bar when active
 	if r.Active || r.HeatLevel > 0 {
@@ -134,7 +135,7 @@ func (r *RayGun) Draw(player *Player, camera rl.Camera2D, debug bool) {
 	}
 }
 
-func (r *RayGun) OnActivate(player *Player, camera rl.Camera2D) {
+func (r *RayGun) OnActivate(player *Player) {
 	if !r.IsOverheated {
 		r.Active = true
 	}
@@ -152,27 +153,38 @@ func (r *RayGun) CheckRayCollision(player *Player, bounds rl.Rectangle) bool {
 	if !r.Active {
 		return false
 	}
+	start, end := r.RaySegment(player)
+	return segmentHitsRect(start, end, bounds)
+}
+
+// Hits burns everything along the ray, three times a second
+func (r *RayGun) Hits(world *World, player *Player) []DamageEvent {
+	if !r.Active || r.IsOverheated {
+		return nil
+	}
+
+	var hits []DamageEvent
+	start, end := r.RaySegment(player)
+	for _, target := range QueryRay[Damageable](world, start, end) {
+		hits = append(hits, newHit(r, player, target, 1, DamageEnergy, 0, 1.0/3))
+	}
+	return hits
+}
 
+// RaySegment returns where the ray starts and ends in world space
+func (r *RayGun) RaySegment(player *Player) (rl.Vector2, rl.Vector2) {
 	playerCenter := rl.Vector2{
 		X: player.X + float32(player.Width)/2,
 		Y: player.Y + float32(player.Height)/2,
 	}
-
-	// Check multiple points along the ray
-	for t := float32(0); t <= 1.0; t += 0.1 {
-		point := rl.Vector2{
-			X: playerCenter.X + r.rayDirection.X*t,
-			Y: playerCenter.Y + r.rayDirection.Y*t,
-		}
-		if rl.CheckCollisionPointRec(point, bounds) {
-			return true
-		}
+	return playerCenter, rl.Vector2{
+		X: playerCenter.X + r.rayDirection.X,
+		Y: playerCenter.Y + r.rayDirection.Y,
 	}
-	return false
 }
 
 func (r *RayGun) Unload() {
-	rl.UnloadTexture(r.Texture)
+	unloadTexture(r.Texture)
 }
 
 // Add Sword struct after RayGun
@@ -180,13 +192,7 @@ type Sword struct {
 	BaseWeapon
 	Texture    rl.Texture2D
 	IsSlashing bool
-	SlashAnim  struct {
-		Texture     rl.Texture2D
-		Frame       int32
-		FrameTime   float32
-		FrameWidth  int32
-		FrameHeight int32
-	}
+	SlashAnim  *Animator
 	DamageArea rl.Rectangle
 }
 
@@ -196,77 +202,59 @@ func NewSword() *Sword {
 			IsEquipped: false,
 			Active:     false,
 		},
-		Texture: rl.LoadTexture("assets/sword.png"),
+		Texture:   loadTexture("assets/sword.png"),
+		SlashAnim: NewAnimator("slash"),
 	}
 
-	sword.SlashAnim = struct {
-		Texture     rl.Texture2D
-		Frame       int32
-		FrameTime   float32
-		FrameWidth  int32
-		FrameHeight int32
-	}{
-		Texture:     rl.LoadTexture("assets/slash.png"),
-		FrameWidth:  65,
-		FrameHeight: 27,
+	// The slash hurts for as long as its animation plays
+	sword.SlashAnim.OnComplete = func(string) {
+		sword.IsSlashing = false
+		sword.Active = false
 	}
 
 	return sword
 }
 
 func (s *Sword) Update(deltaTime float32, player *Player) {
-	if s.IsSlashing {
-		s.SlashAnim.FrameTime += deltaTime
-		if s.SlashAnim.FrameTime >= 0.05 {
-			s.SlashAnim.Frame++
-			s.SlashAnim.FrameTime = 0
-			if s.SlashAnim.Frame >= 6 {
-				s.IsSlashing = false
-				s.SlashAnim.Frame = 0
-				s.Active = false
-			}
-		}
-	}
-}
-
-func (s *Sword) Draw(player *Player, camera rl.Camera2D, debug bool) {
 	if s.IsSlashing {
 		// Update damage area position based on facing direction
+		frameWidth, frameHeight := s.SlashAnim.FrameSize()
 		if player.FacingLeft {
 			s.DamageArea = rl.Rectangle{
-				X:      player.X - float32(s.SlashAnim.FrameWidth)*0.5,
+				X:      player.X - frameWidth*0.5,
 				Y:      player.Y - float32(player.Height)/2,
-				Width:  float32(s.SlashAnim.FrameWidth) * 0.5,
-				Height: float32(s.SlashAnim.FrameHeight),
+				Width:  frameWidth * 0.5,
+				Height: frameHeight,
 			}
 		} else {
 			s.DamageArea = rl.Rectangle{
 				X:      player.X + float32(player.Width),
 				Y:      player.Y - float32(player.Height)/2,
-				Width:  float32(s.SlashAnim.FrameWidth) * 0.5,
-				Height: float32(s.SlashAnim.FrameHeight),
+				Width:  frameWidth * 0.5,
+				Height: frameHeight,
 			}
 		}
 
+		s.SlashAnim.FacingLeft = player.FacingLeft
+		s.SlashAnim.Update(deltaTime)
+	}
+}
+
+func (s *Sword) Draw(player *Player, camera rl.Camera2D, debug bool) {
+	if s.IsSlashing {
 		centerX := player.X + float32(player.Width)/2
 		centerY := player.Y + float32(player.Height)/2
 
-		// Draw slash animation with flipping
-		rl.DrawTexturePro(
-			s.SlashAnim.Texture,
-			rl.Rectangle{
-				X:      float32(s.SlashAnim.Frame)*float32(s.SlashAnim.FrameWidth) + 1,
-				Y:      0,
-				Width:  (float32(s.SlashAnim.FrameWidth) - 2) * float32(map[bool]int{true: -1, false: 1}[player.FacingLeft]),
-				Height: float32(s.SlashAnim.FrameHeight),
-			},
+		// Draw slash animation, flipped by the animator
+		frameWidth, frameHeight := s.SlashAnim.FrameSize()
+		s.SlashAnim.Draw(
 			rl.Rectangle{
 				X:      centerX,
 				Y:      centerY,
-				Width:  float32(s.SlashAnim.FrameWidth),
-				Height: float32(s.SlashAnim.FrameHeight),
+				Width:  frameWidth,
+				Height: frameHeight,
 			},
-			rl.Vector2{X: float32(s.SlashAnim.FrameWidth) / 2, Y: float32(s.SlashAnim.FrameHeight) / 2},
+			rl.Vector2{X: frameWidth / 2, Y: frameHeight / 2},
 			0,
 			rl.White,
 		)
@@ -278,12 +266,11 @@ func (s *Sword) Draw(player *Player, camera rl.Camera2D, debug bool) {
 	}
 }
 
-func (s *Sword) OnActivate(player *Player, camera rl.Camera2D) {
+func (s *Sword) OnActivate(player *Player) {
 	if !s.IsSlashing {
 		s.IsSlashing = true
 		s.Active = true
-		s.SlashAnim.Frame = 0
-		s.SlashAnim.FrameTime = 0
+		s.SlashAnim.Restart("slash")
 	}
 }
 
@@ -296,8 +283,21 @@ func (s *Sword) IsActive() bool {
 }
 
 func (s *Sword) Unload() {
-	rl.UnloadTexture(s.Texture)
-	rl.UnloadTexture(s.SlashAnim.Texture)
+	unloadTexture(s.Texture)
+	s.SlashAnim.Unload()
+}
+
+// Hits cuts everything in the slash and shoves it back
+func (s *Sword) Hits(world *World, player *Player) []DamageEvent {
+	if !s.IsSlashing {
+		return nil
+	}
+
+	var hits []DamageEvent
+	for _, target := range QueryRect[Damageable](world, s.DamageArea) {
+		hits = append(hits, newHit(s, player, target, 2, DamageSlash, 8, 0.3))
+	}
+	return hits
 }
 
 // Add method to check for sword collision
@@ -314,7 +314,7 @@ type Dummy struct {
 	Y              float32
 	Width          int32
 	Height         int32
-	Texture        rl.Texture2D
+	Anim           *Animator
 	DamageCooldown float32
 	DamageText     struct {
 		Value    int32
@@ -331,27 +331,32 @@ func NewDummy(x, y float32) *Dummy {
 		Y:              y,
 		Width:          16,
 		Height:         32,
-		Texture:        rl.LoadTexture("assets/dummy.png"),
+		Anim:           NewAnimator("dummy"),
 		DamageCooldown: 0,
 	}
 }
 
 // Add Update method for Dummy
-func (d *Dummy) Update() {
+func (d *Dummy) Update(deltaTime float32) {
 	if d.DamageCooldown > 0 {
-		d.DamageCooldown -= rl.GetFrameTime()
+		d.DamageCooldown -= deltaTime
+	}
+	d.updateDamageText(deltaTime)
+	d.Anim.Update(deltaTime)
+}
+
+// updateDamageText floats the last damage taken upwards while it fades
+func (d *Dummy) updateDamageText(deltaTime float32) {
+	if d.DamageText.Timer > 0 {
+		d.DamageText.Timer -= deltaTime
+		d.DamageText.Position.Y -= 1
+		d.DamageText.Alpha = d.DamageText.Timer
 	}
 }
 
 func (d *Dummy) Draw(debug bool) {
 	// Draw dummy sprite
-	rl.DrawTextureEx(
-		d.Texture,
-		rl.Vector2{X: d.X, Y: d.Y},
-		0,
-		1,
-		rl.White,
-	)
+	d.Anim.DrawAt(rl.Vector2{X: d.X, Y: d.Y}, 1, rl.White)
 
 	// Draw damage text if active
 	if d.DamageText.Timer > 0 {
@@ -363,11 +368,6 @@ func (d *Dummy) Draw(debug bool) {
 			14,
 			rl.ColorAlpha(rl.Red, d.DamageText.Alpha),
 		)
-
-		// Update position and alpha
-		d.DamageText.Position.Y -= 1
-		d.DamageText.Timer -= rl.GetFrameTime()
-		d.DamageText.Alpha = d.DamageText.Timer
 	}
 }
 
@@ -380,7 +380,15 @@ func (d *Dummy) GetBounds() rl.Rectangle {
 	}
 }
 
-func (d *Dummy) TakeDamage(damage int32) {
+// TakeDamage shows a hit without ever breaking the dummy
+func (d *Dummy) TakeDamage(event DamageEvent) bool {
+	if d.DamageCooldown > 0 {
+		return false
+	}
+	d.DamageCooldown = event.Cooldown
+	damage := event.Amount
+	d.Anim.Restart("hit")
+
 	// Update damage text
 	d.DamageText = struct {
 		Value    int32
@@ -396,22 +404,27 @@ func (d *Dummy) TakeDamage(damage int32) {
 		Alpha: 1.0,
 		Timer: 1.0,
 	}
+	return true
+}
+
+// IsDead is always false, the dummy is there to be hit forever
+func (d *Dummy) IsDead() bool {
+	return false
 }
 
 func (d *Dummy) Unload() {
-	rl.UnloadTexture(d.Texture)
+	d.Anim.Unload()
+}
+
+func (d *Dummy) DrawLayer() Layer {
+	return LayerActors
 }
 
 // Add Pistol struct
 type Pistol struct {
 	BaseWeapon
-	Texture rl.Texture2D
-	Bullets []struct {
-		Position  rl.Vector2
-		Direction rl.Vector2
-		Speed     float32
-		LifeTimer float32
-	}
+	Texture       rl.Texture2D
+	Bullets       Bullets
 	ShootCooldown float32
 	CooldownTimer float32
 }
@@ -422,13 +435,7 @@ func NewPistol() *Pistol {
 			IsEquipped: true,
 			Active:     false,
 		},
-		Texture: rl.LoadTexture("assets/pistol.png"),
-		Bullets: make([]struct {
-			Position  rl.Vector2
-			Direction rl.Vector2
-			Speed     float32
-			LifeTimer float32
-		}, 0),
+		Texture:       loadTexture("assets/pistol.png"),
 		ShootCooldown: 0.15, // Slightly faster firing rate
 	}
 }
@@ -440,53 +447,24 @@ func (p *Pistol) Update(deltaTime float32, player *Player) {
 	}
 
 	// Update bullets
-	var remainingBullets []struct {
-		Position  rl.Vector2
-		Direction rl.Vector2
-		Speed     float32
-		LifeTimer float32
-	}
-
-	for _, bullet := range p.Bullets {
-		// Update bullet position
-		newBullet := bullet
-		newBullet.Position.X += bullet.Direction.X * bullet.Speed * deltaTime
-		newBullet.Position.Y += bullet.Direction.Y * bullet.Speed * deltaTime
-		newBullet.LifeTimer -= deltaTime
-
-		if newBullet.LifeTimer > 0 {
-			remainingBullets = append(remainingBullets, newBullet)
-		}
-	}
-	p.Bullets = remainingBullets
+	p.Bullets = p.Bullets.Advance(deltaTime)
 }
 
 func (p *Pistol) Draw(player *Player, camera rl.Camera2D, debug bool) {
 	// Draw bullets
-	for _, bullet := range p.Bullets {
-		rl.DrawRectangle(
-			int32(bullet.Position.X-2),
-			int32(bullet.Position.Y-2),
-			4, 4,
-			rl.Yellow,
-		)
-	}
+	p.Bullets.Draw(rl.Yellow)
 }
 
-func (p *Pistol) OnActivate(player *Player, camera rl.Camera2D) {
+func (p *Pistol) OnActivate(player *Player) {
 	if p.CooldownTimer <= 0 {
-		// Get mouse position in world space
-		mouseScreen := rl.GetMousePosition()
-		mouseWorld := rl.GetScreenToWorld2D(mouseScreen, camera)
-
 		// Calculate direction
 		playerCenter := rl.Vector2{
 			X: player.X + float32(player.Width)/2,
 			Y: player.Y + float32(player.Height)/2,
 		}
 		direction := rl.Vector2{
-			X: mouseWorld.X - playerCenter.X,
-			Y: mouseWorld.Y - playerCenter.Y,
+			X: player.AimTarget.X - playerCenter.X,
+			Y: player.AimTarget.Y - playerCenter.Y,
 		}
 
 		// Normalize direction
@@ -497,12 +475,7 @@ func (p *Pistol) OnActivate(player *Player, camera rl.Camera2D) {
 		}
 
 		// Create new bullet
-		p.Bullets = append(p.Bullets, struct {
-			Position  rl.Vector2
-			Direction rl.Vector2
-			Speed     float32
-			LifeTimer float32
-		}{
+		p.Bullets = append(p.Bullets, Bullet{
 			Position:  playerCenter,
 			Direction: direction,
 			Speed:     240.0,
@@ -522,13 +495,7 @@ func (p *Pistol) IsActive() bool {
 }
 
 func (p *Pistol) CheckBulletCollision(bounds rl.Rectangle) bool {
-	for _, bullet := range p.Bullets {
-		bulletBounds := rl.Rectangle{
-			X:      bullet.Position.X - 2,
-			Y:      bullet.Position.Y - 2,
-			Width:  4,
-			Height: 4,
-		}
+	for _, bulletBounds := range p.BulletBounds() {
 		if rl.CheckCollisionRecs(bulletBounds, bounds) {
 			return true
 		}
@@ -536,6 +503,29 @@ func (p *Pistol) CheckBulletCollision(bounds rl.Rectangle) bool {
 	return false
 }
 
+// Hits reports everything a bullet touches, once per target even when several bullets do.
+// Bullets stop at trees and stones, so nothing behind them gets hit.
+func (p *Pistol) Hits(world *World, player *Player) []DamageEvent {
+	p.Bullets = p.Bullets.Blocked(world)
+
+	var hits []DamageEvent
+	seen := make(map[Damageable]bool)
+	for _, bounds := range p.BulletBounds() {
+		for _, target := range QueryRect[Damageable](world, bounds) {
+			if !seen[target] {
+				seen[target] = true
+				hits = append(hits, newHit(p, player, target, 2, DamagePierce, 3, 0.2))
+			}
+		}
+	}
+	return hits
+}
+
+// BulletBounds returns the hit box of every bullet in flight
+func (p *Pistol) BulletBounds() []rl.Rectangle {
+	return p.Bullets.AllBounds()
+}
+
 func (p *Pistol) Unload() {
-	rl.UnloadTexture(p.Texture)
+	unloadTexture(p.Texture)
 }
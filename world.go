@@ -0,0 +1,143 @@
+package main
+
+import (
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+// World owns every entity of a run. It updates, draws and unloads them,
+// and answers queries so game logic never keeps its own per-type slices.
+// Area queries go through a spatial hash, so they only test nearby entities.
+type World struct {
+	entities []Entity
+	grid     *SpatialHash
+}
+
+// NewWorld creates an empty world
+func NewWorld() *World {
+	return &World{grid: NewSpatialHash(SpatialCellSize)}
+}
+
+// Add places an entity in the world, the world now owns it
+func (w *World) Add(e Entity) {
+	w.entities = append(w.entities, e)
+	w.grid.Insert(e)
+}
+
+// Moved refiles an entity in the spatial hash after it was moved outside of Update
+func (w *World) Moved(e Entity) {
+	w.grid.Move(e)
+}
+
+// Remove takes an entity out of the world and unloads it.
+// Removing while looping over a query result is safe.
+func (w *World) Remove(e Entity) {
+	for i, other := range w.entities {
+		if other == e {
+			w.entities = append(w.entities[:i], w.entities[i+1:]...)
+			w.grid.Remove(e)
+			e.Unload()
+			return
+		}
+	}
+}
+
+// Len reports how many entities are in the world
+func (w *World) Len() int {
+	return len(w.entities)
+}
+
+// Update advances every entity that changes over time
+func (w *World) Update(deltaTime float32) {
+	for _, e := range w.snapshot() {
+		if updater, ok := e.(Updater); ok {
+			updater.Update(deltaTime)
+			w.grid.Move(e)
+		}
+	}
+}
+
+// DrawLayer draws the entities of one layer in the order they were added
+func (w *World) DrawLayer(layer Layer, debug bool) {
+	for _, e := range w.entities {
+		if layerOf(e) == layer {
+			e.Draw(debug)
+		}
+	}
+}
+
+// Unload frees every entity and empties the world
+func (w *World) Unload() {
+	for _, e := range w.entities {
+		e.Unload()
+	}
+	w.entities = nil
+	w.grid.Clear()
+}
+
+// IsOccupied reports whether bounds, grown by padding on every side, overlap an obstacle
+func (w *World) IsOccupied(bounds r.Rectangle, padding float32) bool {
+	// Growing the searched area by padding finds every obstacle whose grown bounds could overlap
+	area := r.Rectangle{
+		X:      bounds.X - padding,
+		Y:      bounds.Y - padding,
+		Width:  bounds.Width + padding*2,
+		Height: bounds.Height + padding*2,
+	}
+	for _, e := range w.grid.QueryRect(area) {
+		obstacle, ok := e.(Obstacle)
+		if !ok || !obstacle.BlocksPlacement() {
+			continue
+		}
+		other := obstacle.GetBounds()
+		other.X -= padding
+		other.Y -= padding
+		other.Width += padding * 2
+		other.Height += padding * 2
+		if r.CheckCollisionRecs(bounds, other) {
+			return true
+		}
+	}
+	return false
+}
+
+// snapshot copies the entity list so it can be looped over while entities come and go
+func (w *World) snapshot() []Entity {
+	return append([]Entity(nil), w.entities...)
+}
+
+// Query returns every entity of type T, which may be a concrete type like
+// *Tree or an interface like Damageable
+func Query[T any](w *World) []T {
+	return filter[T](w.entities)
+}
+
+// QueryRect returns every entity of type T whose bounds overlap rect
+func QueryRect[T any](w *World, rect r.Rectangle) []T {
+	return filter[T](w.grid.QueryRect(rect))
+}
+
+// QueryPoint returns every entity of type T whose bounds contain point
+func QueryPoint[T any](w *World, point r.Vector2) []T {
+	return filter[T](w.grid.QueryPoint(point))
+}
+
+// QueryRadius returns every entity of type T whose bounds touch the circle
+func QueryRadius[T any](w *World, center r.Vector2, radius float32) []T {
+	return filter[T](w.grid.QueryRadius(center, radius))
+}
+
+// QueryRay returns every entity of type T the segment from start to end crosses
+func QueryRay[T any](w *World, start, end r.Vector2) []T {
+	return filter[T](w.grid.QueryRay(start, end))
+}
+
+// filter keeps the entities of type T
+func filter[T any](entities []Entity) []T {
+	var found []T
+	for _, e := range entities {
+		if t, ok := e.(T); ok {
+			found = append(found, t)
+		}
+	}
+	return found
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"testing"
+
+	r "github.com/gen2brain/raylib-go/raylib"
+)
+
+// box is a bare entity for world tests
+type box struct {
+	bounds   r.Rectangle
+	unloaded int
+}
+
+func (b *box) GetBounds() r.Rectangle { return b.bounds }
+func (b *box) Draw(debug bool)        {}
+func (b *box) Unload()                { b.unloaded++ }
+
+// rock is a box that keeps new objects off it
+type rock struct{ box }
+
+func (k *rock) BlocksPlacement() bool { return true }
+
+// drifter is a box that moves right on every update
+type drifter struct{ box }
+
+func (d *drifter) Update(deltaTime float32) { d.bounds.X += 100 * deltaTime }
+
+func TestWorldQueriesByType(t *testing.T) {
+	w := NewWorld()
+	plain := &box{bounds: r.Rectangle{X: 0, Y: 0, Width: 10, Height: 10}}
+	stone := &rock{box{bounds: r.Rectangle{X: 50, Y: 0, Width: 10, Height: 10}}}
+	w.Add(plain)
+	w.Add(stone)
+
+	if got := len(Query[Entity](w)); got != 2 {
+		t.Fatalf("Query[Entity] found %d, want 2", got)
+	}
+	if got := Query[*rock](w); len(got) != 1 || got[0] != stone {
+		t.Fatalf("Query[*rock] = %v, want the rock", got)
+	}
+	if got := Query[Obstacle](w); len(got) != 1 {
+		t.Fatalf("Query[Obstacle] found %d, want 1", len(got))
+	}
+	if got := QueryPoint[Entity](w, r.Vector2{X: 5, Y: 5}); len(got) != 1 || got[0] != plain {
+		t.Fatalf("QueryPoint = %v, want the box", got)
+	}
+}
+
+func TestWorldRemoveUnloads(t *testing.T) {
+	w := NewWorld()
+	first := &box{bounds: r.Rectangle{Width: 10, Height: 10}}
+	second := &box{bounds: r.Rectangle{Width: 10, Height: 10}}
+	w.Add(first)
+	w.Add(second)
+
+	// Removing while looping over a query is safe
+	for _, e := range Query[*box](w) {
+		w.Remove(e)
+	}
+	if w.Len() != 0 || first.unloaded != 1 || second.unloaded != 1 {
+		t.Fatalf("len %d, unloaded %d and %d", w.Len(), first.unloaded, second.unloaded)
+	}
+	if got := QueryRect[Entity](w, r.Rectangle{Width: 10, Height: 10}); len(got) != 0 {
+		t.Fatalf("removed entities still found: %v", got)
+	}
+}
+
+func TestWorldUpdateRefilesMovedEntities(t *testing.T) {
+	w := NewWorld()
+	mover := &drifter{box{bounds: r.Rectangle{Width: 10, Height: 10}}}
+	w.Add(mover)
+	for i := 0; i < 60; i++ {
+		w.Update(1.0 / 10)
+	}
+
+	far := r.Vector2{X: mover.bounds.X + 5, Y: 5}
+	if got := QueryPoint[*drifter](w, far); len(got) != 1 {
+		t.Fatalf("moved entity not found at %v", far)
+	}
+	if got := QueryPoint[*drifter](w, r.Vector2{X: 5, Y: 5}); len(got) != 0 {
+		t.Fatal("moved entity still found where it started")
+	}
+}
+
+func TestWorldIsOccupied(t *testing.T) {
+	w := NewWorld()
+	w.Add(&rock{box{bounds: r.Rectangle{X: 100, Y: 100, Width: 20, Height: 20}}})
+	w.Add(&box{bounds: r.Rectangle{X: 300, Y: 300, Width: 20, Height: 20}})
+
+	spot := r.Rectangle{X: 130, Y: 100, Width: 10, Height: 10}
+	if w.IsOccupied(spot, 0) {
+		t.Fatal("spot next to the rock is occupied without padding")
+	}
+	if !w.IsOccupied(spot, 15) {
+		t.Fatal("spot next to the rock is free with padding")
+	}
+	if w.IsOccupied(r.Rectangle{X: 300, Y: 300, Width: 10, Height: 10}, 0) {
+		t.Fatal("entity that is no obstacle blocks placement")
+	}
+}
+
+func TestWorldUnloadEmpties(t *testing.T) {
+	w := NewWorld()
+	b := &box{bounds: r.Rectangle{Width: 10, Height: 10}}
+	w.Add(b)
+	w.Unload()
+	if w.Len() != 0 || b.unloaded != 1 {
+		t.Fatalf("len %d, unloaded %d", w.Len(), b.unloaded)
+	}
+	if layerOf(b) != LayerObjects {
+		t.Fatal("entities without a layer are not drawn with objects")
+	}
+}